@@ -0,0 +1,251 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+var userAddressColumns = []string{
+	"id", "user_id", "label", "address_line1", "address_line2", "city", "postal_code", "country",
+	"latitude", "longitude", "delivery_instructions", "is_default", "created_at", "updated_at",
+}
+
+// ListUserAddresses returns a user's saved addresses, default first.
+func (s *service) ListUserAddresses(userID uuid.UUID) ([]types.UserAddress, error) {
+	addresses := []types.UserAddress{}
+	query, args, err := QB.Select(userAddressColumns...).
+		From("user_addresses").
+		Where("user_id = ?", userID).
+		OrderBy("is_default DESC", "created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&addresses, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+func (s *service) GetUserAddress(userID uuid.UUID, id string) (*types.UserAddress, error) {
+	addressID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrAddressNotFound
+	}
+	return userAddress(s.db, userID, &addressID)
+}
+
+func (s *service) CreateUserAddress(userID uuid.UUID, address types.UserAddress) (*types.UserAddress, error) {
+	if err := validateUserAddress(address); err != nil {
+		return nil, err
+	}
+
+	address.ID = uuid.New()
+	address.UserId = userID
+	address.Created_at = time.Now()
+	address.Updated_at = time.Now()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A user's first address becomes their default
+	var count int
+	query, args, err := QB.Select("COUNT(*)").From("user_addresses").Where("user_id = ?", userID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Get(&count, query, args...); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		address.IsDefault = true
+	}
+
+	if address.IsDefault {
+		if err := clearDefaultAddress(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err = QB.Insert("user_addresses").
+		Columns(userAddressColumns...).
+		Values(address.ID, address.UserId, address.Label, address.AddressLine1, address.AddressLine2, address.City,
+			address.PostalCode, address.Country, address.Latitude, address.Longitude, address.DeliveryInstructions,
+			address.IsDefault, address.Created_at, address.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(userAddressColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+	if err := tx.QueryRowx(query, args...).StructScan(&address); err != nil {
+		return nil, fmt.Errorf("error inserting address: %w", err)
+	}
+
+	return &address, tx.Commit()
+}
+
+func (s *service) UpdateUserAddress(userID uuid.UUID, id string, address types.UserAddress) (*types.UserAddress, error) {
+	existing, err := s.GetUserAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateUserAddress(address); err != nil {
+		return nil, err
+	}
+	// The default can be moved to another address but not simply removed
+	if existing.IsDefault {
+		address.IsDefault = true
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if address.IsDefault && !existing.IsDefault {
+		if err := clearDefaultAddress(tx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err := QB.Update("user_addresses").
+		Set("label", address.Label).
+		Set("address_line1", address.AddressLine1).
+		Set("address_line2", address.AddressLine2).
+		Set("city", address.City).
+		Set("postal_code", address.PostalCode).
+		Set("country", address.Country).
+		Set("latitude", address.Latitude).
+		Set("longitude", address.Longitude).
+		Set("delivery_instructions", address.DeliveryInstructions).
+		Set("is_default", address.IsDefault).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": existing.ID}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(userAddressColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	var updated types.UserAddress
+	if err := tx.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		return nil, fmt.Errorf("error updating address: %w", err)
+	}
+
+	return &updated, tx.Commit()
+}
+
+// DeleteUserAddress removes a saved address. When the default goes, the
+// oldest remaining address takes over. Orders keep their own copy.
+func (s *service) DeleteUserAddress(userID uuid.UUID, id string) error {
+	address, err := s.GetUserAddress(userID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Delete("user_addresses").Where("id = ?", address.ID).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("error deleting address: %w", err)
+	}
+
+	if address.IsDefault {
+		query, args, err = QB.Update("user_addresses").
+			Set("is_default", true).
+			Where("id = (SELECT id FROM user_addresses WHERE user_id = ? ORDER BY created_at LIMIT 1)", userID).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func validateUserAddress(address types.UserAddress) error {
+	if strings.TrimSpace(address.AddressLine1) == "" {
+		return errors.New("address_line1 is required")
+	}
+	if address.Latitude == nil || address.Longitude == nil {
+		return errors.New("latitude and longitude are required")
+	}
+	return validateCoordinates(address.Latitude, address.Longitude)
+}
+
+func clearDefaultAddress(tx *sqlx.Tx, userID uuid.UUID) error {
+	query, args, err := QB.Update("user_addresses").
+		Set("is_default", false).
+		Where(squirrel.Eq{"user_id": userID, "is_default": true}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// userAddress loads one of the user's addresses, or their default one when
+// no id is given.
+func userAddress(q sqlx.Queryer, userID uuid.UUID, addressID *uuid.UUID) (*types.UserAddress, error) {
+	builder := QB.Select(userAddressColumns...).From("user_addresses").Where("user_id = ?", userID)
+	if addressID != nil {
+		builder = builder.Where("id = ?", *addressID)
+	} else {
+		builder = builder.Where("is_default")
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var address types.UserAddress
+	if err := sqlx.Get(q, &address, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+	return &address, nil
+}
+
+// addressDestination turns a saved address into what carts and orders store.
+func addressDestination(address types.UserAddress) types.DeliveryDestination {
+	parts := []string{address.AddressLine1}
+	for _, part := range []*string{address.AddressLine2, address.City, address.PostalCode, address.Country} {
+		if part != nil && strings.TrimSpace(*part) != "" {
+			parts = append(parts, *part)
+		}
+	}
+
+	return types.DeliveryDestination{
+		AddressId:    &address.ID,
+		Address:      strings.Join(parts, ", "),
+		Point:        types.LatLng{Lat: *address.Latitude, Lng: *address.Longitude},
+		Instructions: address.DeliveryInstructions,
+	}
+}
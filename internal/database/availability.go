@@ -0,0 +1,165 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+var ErrItemUnavailable = errors.New("item is not available")
+
+// itemAvailableCondition matches items that can be ordered right now: not
+// switched off, and either without schedules or inside one of their windows
+// in the vendor's local time. It expects the items table in scope.
+const itemAvailableCondition = `items.is_available AND (
+	NOT EXISTS (SELECT 1 FROM item_availability_schedules s WHERE s.item_id = items.id)
+	OR EXISTS (
+		SELECT 1 FROM item_availability_schedules s
+		JOIN vendors v ON v.id = items.vendor_id
+		CROSS JOIN LATERAL (SELECT now() AT TIME ZONE v.timezone AS local) l
+		WHERE s.item_id = items.id AND (
+			(s.start_time < s.end_time
+				AND s.day_of_week = EXTRACT(DOW FROM l.local)
+				AND l.local::time >= s.start_time AND l.local::time < s.end_time)
+			OR (s.start_time > s.end_time AND (
+				(s.day_of_week = EXTRACT(DOW FROM l.local) AND l.local::time >= s.start_time)
+				OR (s.day_of_week = (EXTRACT(DOW FROM l.local)::int + 6) % 7 AND l.local::time < s.end_time)))
+		)
+	)
+)`
+
+func (s *service) ListItemSchedules(itemID uuid.UUID) ([]types.AvailabilitySchedule, error) {
+	schedules, err := itemSchedules(s.db, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list item schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// SetItemSchedules replaces an item's availability windows. An empty list
+// makes the item orderable at any time again.
+func (s *service) SetItemSchedules(itemID string, schedules []types.AvailabilitySchedule) ([]types.AvailabilitySchedule, error) {
+	item, err := s.GetItemByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range schedules {
+		if err := normalizeSchedule(&schedules[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Delete("item_availability_schedules").Where("item_id = ?", item.ID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, err
+	}
+
+	if len(schedules) > 0 {
+		insert := QB.Insert("item_availability_schedules").Columns("item_id", "day_of_week", "start_time", "end_time")
+		for _, schedule := range schedules {
+			insert = insert.Values(item.ID, schedule.DayOfWeek, schedule.StartTime, schedule.EndTime)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error saving item schedules: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.ListItemSchedules(item.ID)
+}
+
+func normalizeSchedule(schedule *types.AvailabilitySchedule) error {
+	if schedule.DayOfWeek < 0 || schedule.DayOfWeek > 6 {
+		return errors.New("day_of_week must be between 0 (Sunday) and 6 (Saturday)")
+	}
+
+	start, err := time.Parse("15:04", schedule.StartTime)
+	if err != nil {
+		return errors.New("start_time must be formatted as HH:MM")
+	}
+	end, err := time.Parse("15:04", schedule.EndTime)
+	if err != nil {
+		return errors.New("end_time must be formatted as HH:MM")
+	}
+	if start.Equal(end) {
+		return errors.New("start_time and end_time must differ")
+	}
+
+	schedule.StartTime = start.Format("15:04")
+	schedule.EndTime = end.Format("15:04")
+	return nil
+}
+
+func itemSchedules(q sqlx.Queryer, itemID uuid.UUID) ([]types.AvailabilitySchedule, error) {
+	schedules := []types.AvailabilitySchedule{}
+	query, args, err := QB.Select(
+		"id",
+		"item_id",
+		"day_of_week",
+		"to_char(start_time, 'HH24:MI') AS start_time",
+		"to_char(end_time, 'HH24:MI') AS end_time",
+	).
+		From("item_availability_schedules").
+		Where("item_id = ?", itemID).
+		OrderBy("day_of_week", "start_time").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.Select(q, &schedules, query, args...)
+	return schedules, err
+}
+
+// checkItemAvailable fails with ErrItemUnavailable when the item is switched
+// off or outside its schedule.
+func checkItemAvailable(q sqlx.Queryer, itemID uuid.UUID) error {
+	var available bool
+	query, args, err := QB.Select(itemAvailableCondition).From("items").Where("items.id = ?", itemID).ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Get(q, &available, query, args...); err != nil {
+		return err
+	}
+	if !available {
+		return ErrItemUnavailable
+	}
+	return nil
+}
+
+// unavailableCartItems returns the names of items in the cart that can't be
+// ordered right now.
+func unavailableCartItems(q sqlx.Queryer, cartID uuid.UUID) ([]string, error) {
+	var names []string
+	query, args, err := QB.Select("DISTINCT items.name").
+		From("cart_items").
+		Join("items ON items.id = cart_items.item_id").
+		Where("cart_items.cart_id = ?", cartID).
+		Where("NOT (" + itemAvailableCondition + ")").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.Select(q, &names, query, args...)
+	return names, err
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 
 	"fmt"
 	"net/http"
@@ -15,8 +16,12 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+var ErrCheckoutRejected = errors.New("checkout rejected")
+
 var cart_columns = []string{
-	"id", "total_price", "quantity", "vendor_id", "created_at", "updated_at",
+	"id", "subtotal", "discount_total", "tax_total", "total_price", "coupon_id", "quantity", "vendor_id", "created_at", "updated_at",
+	"delivery_address", "delivery_latitude", "delivery_longitude", "delivery_fee", "fulfillment_type", "table_id", "pickup_at",
+	"delivery_address_id", "delivery_instructions",
 }
 
 func (s *service) GetCart(userID string) (types.Cart, error) {
@@ -41,8 +46,23 @@ func (s *service) GetCartItems(cartID uuid.UUID) ([]types.CartItems, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = s.db.Select(&cartItems, query, args...)
-	return cartItems, err
+	if err := s.db.Select(&cartItems, query, args...); err != nil {
+		return nil, err
+	}
+
+	cartItemIDs := make([]uuid.UUID, len(cartItems))
+	for i, cartItem := range cartItems {
+		cartItemIDs[i] = cartItem.ID
+	}
+	modifiers, err := cartItemModifiers(s.db, cartItemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cartItems {
+		cartItems[i].Modifiers = modifiers[cartItems[i].ID]
+	}
+
+	return cartItems, nil
 }
 
 var item_columns = []string{
@@ -50,8 +70,12 @@ var item_columns = []string{
 	"vendor_id",
 	"name",
 	"price",
+	"tax_class_id",
 	"created_at",
 	"updated_at",
+	"stock_quantity",
+	"low_stock_threshold",
+	"is_available",
 	helpers.ImageFormat,
 }
 
@@ -108,7 +132,15 @@ func (s *service) ResetCart(cartID uuid.UUID, vendorID uuid.UUID) (types.Cart, e
 	}
 	query, args, err := QB.Update("carts").
 		Set("vendor_id", vendorID).
+		Set("coupon_id", nil).
+		Set("subtotal", 0).
+		Set("discount_total", 0).
+		Set("tax_total", 0).
 		Set("total_price", 0).
+		Set("delivery_fee", 0).
+		Set("fulfillment_type", nil).
+		Set("table_id", nil).
+		Set("pickup_at", nil).
 		Set("quantity", 0).
 		Set("updated_at", time.Now()).
 		Where("id = ?", cartID).
@@ -132,74 +164,85 @@ func (s *service) ClearCartItems(cartID uuid.UUID) error {
 	return err
 }
 
-func (s *service) UpdateCartItem(cartID, itemID uuid.UUID, quantity int) error {
-	var cartItem types.CartItems
-	query, args, err := QB.Select("*").From("cart_items").
-		Where("cart_id = ? AND item_id = ?", cartID, itemID).
-		ToSql()
+// UpdateCartItem sets the quantity of a cart line. Lines are told apart by
+// variant and modifier selection, so the same item with different modifiers
+// ends up on its own line.
+func (s *service) UpdateCartItem(cartID uuid.UUID, line types.AddCartItem) error {
+	if err := checkItemAvailable(s.db, line.ItemId); err != nil {
+		return err
+	}
+
+	variantID, err := resolveVariant(s.db, line.ItemId, line.VariantId)
 	if err != nil {
 		return err
 	}
-	err = s.db.Get(&cartItem, query, args...)
 
-	if err == sql.ErrNoRows {
-		query, args, err = QB.Insert("cart_items").
-			Columns("cart_id", "item_id", "quantity").
-			Values(cartID, itemID, quantity).
-			ToSql()
-	} else if err == nil {
-		query, args, err = QB.Update("cart_items").
-			Set("quantity", quantity).
-			Where("cart_id = ? AND item_id = ?", cartID, itemID).
-			ToSql()
-	} else {
+	modifierKey, err := validateModifierSelection(s.db, line.ItemId, line.ModifierOptionIds)
+	if err != nil {
 		return err
 	}
 
+	tx, err := s.db.Beginx()
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec(query, args...)
-	return err
-}
+	defer tx.Rollback()
 
-func (s *service) RecalculateCart(cartID uuid.UUID) error {
-	query, args, err := QB.
-		Select("cart_items.quantity, items.price").
-		From("cart_items").
-		Join("items ON cart_items.item_id = items.id").
-		Where("cart_items.cart_id = ?", cartID).
+	var cartItemID uuid.UUID
+	query, args, err := QB.Select("id").From("cart_items").
+		Where("cart_id = ? AND variant_id = ? AND modifier_key = ?", cartID, variantID, modifierKey).
 		ToSql()
 	if err != nil {
 		return err
 	}
+	err = tx.Get(&cartItemID, query, args...)
 
-	var cartItems []struct {
-		Quantity int     `db:"quantity"`
-		Price    float64 `db:"price"`
-	}
+	if err == sql.ErrNoRows {
+		cartItemID = uuid.New()
+		query, args, err = QB.Insert("cart_items").
+			Columns("id", "cart_id", "item_id", "variant_id", "modifier_key", "quantity").
+			Values(cartItemID, cartID, line.ItemId, variantID, modifierKey, line.Quantity).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
 
-	if err := s.db.Select(&cartItems, query, args...); err != nil {
+		if modifierKey != "" {
+			insert := QB.Insert("cart_item_modifiers").Columns("cart_item_id", "modifier_option_id")
+			for _, optionID := range strings.Split(modifierKey, ",") {
+				insert = insert.Values(cartItemID, uuid.MustParse(optionID))
+			}
+			query, args, err = insert.ToSql()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				return err
+			}
+		}
+	} else if err == nil {
+		query, args, err = QB.Update("cart_items").
+			Set("quantity", line.Quantity).
+			Where("id = ?", cartItemID).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	} else {
 		return err
 	}
 
-	var totalPrice float64
-	var totalQuantity int
-	for _, item := range cartItems {
-		totalPrice += float64(item.Quantity) * item.Price
-		totalQuantity += item.Quantity
-	}
+	return tx.Commit()
+}
 
-	query, args, err = QB.Update("carts").
-		Set("total_price", totalPrice).
-		Set("quantity", totalQuantity).
-		Set("updated_at", time.Now()).
-		Where("id = ?", cartID).
-		ToSql()
-	if err != nil {
-		return err
-	}
-	_, err = s.db.Exec(query, args...)
+func (s *service) RecalculateCart(cartID uuid.UUID) error {
+	_, err := recalculateCart(s.db, cartID)
 	return err
 }
 
@@ -214,9 +257,17 @@ func (s *service) EmptyCart(userID string) error {
 	}
 
 	query, args, err := QB.Update("carts").
+		Set("subtotal", 0).
+		Set("discount_total", 0).
+		Set("tax_total", 0).
 		Set("total_price", 0).
+		Set("delivery_fee", 0).
+		Set("fulfillment_type", nil).
+		Set("table_id", nil).
+		Set("pickup_at", nil).
 		Set("quantity", 0).
 		Set("vendor_id", nil).
+		Set("coupon_id", nil).
 		Set("updated_at", time.Now()).
 		Where("id = ?", cart.ID).
 		ToSql()
@@ -227,46 +278,180 @@ func (s *service) EmptyCart(userID string) error {
 	return err
 }
 
-func (s *service) ProcessCheckout(cart types.Cart) error {
+func (s *service) ProcessCheckout(cart types.Cart, checkout types.Checkout) (types.Order, error) {
 	tx, err := s.db.Beginx()
 	if err != nil {
-		return err
+		return types.Order{}, err
 	}
 	defer tx.Rollback()
 
+	if err := lockCartCoupon(tx, cart.ID); err != nil {
+		return types.Order{}, err
+	}
+
+	if err := checkVendorOpen(tx, cart.VendorId); err != nil {
+		return types.Order{}, err
+	}
+
+	unavailable, err := unavailableCartItems(tx, cart.ID)
+	if err != nil {
+		return types.Order{}, err
+	}
+	if len(unavailable) > 0 {
+		return types.Order{}, fmt.Errorf("%w: %s not available right now", ErrCheckoutRejected, strings.Join(unavailable, ", "))
+	}
+	if err := checkCartModifiers(tx, cart.ID); err != nil {
+		return types.Order{}, err
+	}
+
+	pricing, err := priceCart(tx, cart.ID)
+	if err != nil {
+		return types.Order{}, err
+	}
+	if pricing.CouponErr != nil {
+		return types.Order{}, pricing.CouponErr
+	}
+
 	order := types.Order{
 		ID:             uuid.New(),
-		TotalOrderCost: cart.TotalPrice,
+		Subtotal:       pricing.Subtotal,
+		DiscountTotal:  pricing.DiscountTotal,
+		TaxTotal:       pricing.TaxTotal,
+		TotalOrderCost: pricing.Total,
 		VendorId:       cart.VendorId,
 		CustomerId:     cart.ID,
 		Status:         "preparing",
+		PaymentStatus:  "unpaid",
 		Created_at:     time.Now(),
 		Updated_at:     time.Now(),
 	}
 
+	fulfillment, err := resolveFulfillment(tx, cart, checkout, order.Created_at)
+	if err != nil {
+		return types.Order{}, err
+	}
+	order.FulfillmentType = fulfillment.Type
+
+	var adjustments []types.OrderAdjustment
+	switch fulfillment.Type {
+	case "dine_in":
+		partySize := checkout.PartySize
+		if partySize < 1 {
+			partySize = 1
+		}
+		order.TableId = fulfillment.TableId
+		order.PartySize = &partySize
+
+		// Orders from the same table run up one bill on its open session
+		order.TableSessionId, err = sessionForDineIn(tx, cart.VendorId, *fulfillment.TableId, cart.ID, partySize, order.Created_at)
+		if err != nil {
+			return types.Order{}, err
+		}
+
+		adjustments, err = serviceCharges(tx, cart.VendorId, partySize, pricing.Subtotal-pricing.DiscountTotal)
+		if err != nil {
+			return types.Order{}, err
+		}
+		for _, adjustment := range adjustments {
+			order.ServiceChargeTotal += adjustment.Amount
+		}
+		order.ServiceChargeTotal = roundMoney(order.ServiceChargeTotal)
+
+	case "pickup":
+		order.PickupAt, err = pickupTime(tx, cart.VendorId, fulfillment.PickupAt, order.Created_at)
+		if err != nil {
+			return types.Order{}, err
+		}
+
+	case "delivery":
+		// The address is copied onto the order so later edits to the address
+		// book don't change it
+		destination, err := cartDestination(tx, cart)
+		if err != nil {
+			return types.Order{}, err
+		}
+		quote, err := quoteDelivery(tx, cart.VendorId, destination.Point, pricing.ListedTotal())
+		if err != nil {
+			return types.Order{}, err
+		}
+		order.DeliveryAddressId = destination.AddressId
+		order.DeliveryAddress = &destination.Address
+		order.DeliveryLatitude = &destination.Point.Lat
+		order.DeliveryLongitude = &destination.Point.Lng
+		order.DeliveryInstructions = destination.Instructions
+		order.DeliveryZoneId = &quote.ZoneId
+		order.DeliveryDistanceKm = &quote.DistanceKm
+		order.DeliveryFee = quote.Fee
+		if quote.Fee > 0 {
+			adjustments = append(adjustments, deliveryFeeAdjustment(quote))
+		}
+	}
+
+	if tip := tipAdjustment(checkout.Tip); tip.Amount > 0 {
+		adjustments = append(adjustments, tip)
+		order.TipTotal = tip.Amount
+	}
+
+	order.TotalOrderCost = roundMoney(order.TotalOrderCost + order.ServiceChargeTotal + order.TipTotal + order.DeliveryFee)
+
+	// Pay-now orders are held back from the kitchen until the payment goes through
+	if checkout.PaymentMethod == "pay_now" {
+		order.Status = "pending_payment"
+	}
+
 	if err := s.CreateOrder(tx, order); err != nil {
-		return err
+		return types.Order{}, err
 	}
 
 	if err := s.CreateOrderItems(tx, order.ID, cart.ID); err != nil {
-		return err
+		return types.Order{}, err
+	}
+
+	if err := createKitchenTickets(tx, order.ID, order.VendorId, order.Created_at); err != nil {
+		return types.Order{}, err
+	}
+
+	if err := reserveStock(tx, order, pricing.Lines); err != nil {
+		return types.Order{}, err
+	}
+
+	if err := createOrderDiscounts(tx, order, pricing); err != nil {
+		return types.Order{}, err
+	}
+
+	if err := createOrderAdjustments(tx, order.ID, adjustments); err != nil {
+		return types.Order{}, err
 	}
 
 	if err := s.ClearCartItems(cart.ID); err != nil {
-		return err
+		return types.Order{}, err
 	}
 
 	if err := s.ResetCartAfterCheckout(cart.ID); err != nil {
-		return err
+		return types.Order{}, err
 	}
 
-	return tx.Commit()
+	if err := publishOrderEvent(tx, order.ID, "order.created"); err != nil {
+		return types.Order{}, err
+	}
+
+	if err := enqueueOrderEvent(tx, order.ID, OrderCreatedEvent, ""); err != nil {
+		return types.Order{}, err
+	}
+
+	return order, tx.Commit()
 }
 
 func (s *service) CreateOrder(tx *sqlx.Tx, order types.Order) error {
 	query, args, err := QB.Insert("orders").
-		Columns("id", "total_order_cost", "vendor_id", "customer_id", "status", "created_at", "updated_at").
-		Values(order.ID, order.TotalOrderCost, order.VendorId, order.CustomerId, order.Status, order.Created_at, order.Updated_at).
+		Columns("id", "subtotal", "discount_total", "tax_total", "service_charge_total", "tip_total", "total_order_cost",
+			"vendor_id", "customer_id", "table_id", "party_size", "status", "created_at", "updated_at",
+			"delivery_address", "delivery_latitude", "delivery_longitude", "delivery_zone_id", "delivery_distance_km", "delivery_fee",
+			"fulfillment_type", "pickup_at", "delivery_address_id", "delivery_instructions", "table_session_id").
+		Values(order.ID, order.Subtotal, order.DiscountTotal, order.TaxTotal, order.ServiceChargeTotal, order.TipTotal, order.TotalOrderCost,
+			order.VendorId, order.CustomerId, order.TableId, order.PartySize, order.Status, order.Created_at, order.Updated_at,
+			order.DeliveryAddress, order.DeliveryLatitude, order.DeliveryLongitude, order.DeliveryZoneId, order.DeliveryDistanceKm, order.DeliveryFee,
+			order.FulfillmentType, order.PickupAt, order.DeliveryAddressId, order.DeliveryInstructions, order.TableSessionId).
 		ToSql()
 	if err != nil {
 		return err
@@ -276,20 +461,39 @@ func (s *service) CreateOrder(tx *sqlx.Tx, order types.Order) error {
 }
 
 func (s *service) CreateOrderItems(tx *sqlx.Tx, orderID, cartID uuid.UUID) error {
-	cartItems, err := s.GetCartItems(cartID)
+	pricing, err := priceCart(tx, cartID)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range cartItems {
-		itemDetails, err := s.GetCartItem(item.ItemId)
+	for _, line := range pricing.Lines {
+		orderItemID := uuid.New()
+		query, args, err := QB.Insert("order_items").
+			Columns("id", "order_id", "item_id", "variant_id", "variant_name", "sku", "quantity", "price",
+				"discount_amount", "tax_amount", "total").
+			Values(orderItemID, orderID, line.ItemId, line.VariantId, line.VariantName, line.Sku, line.Quantity, line.Price,
+				line.Discount, line.Tax, line.Total).
+			ToSql()
 		if err != nil {
 			return err
 		}
 
-		query, args, err := QB.Insert("order_items").
-			Columns("order_id", "item_id", "quantity", "price").
-			Values(orderID, item.ItemId, item.Quantity, itemDetails.Price).
+		_, err = tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+
+		if err := createOrderItemModifiers(tx, orderItemID, line.Modifiers); err != nil {
+			return err
+		}
+
+		if line.TaxClassId == nil {
+			continue
+		}
+
+		query, args, err = QB.Insert("order_item_taxes").
+			Columns("order_item_id", "tax_class_id", "name", "rate", "is_inclusive", "taxable_amount", "amount").
+			Values(orderItemID, line.TaxClassId, *line.TaxName, line.TaxRate, line.IsInclusive, line.Net, line.Tax).
 			ToSql()
 		if err != nil {
 			return err
@@ -306,9 +510,17 @@ func (s *service) CreateOrderItems(tx *sqlx.Tx, orderID, cartID uuid.UUID) error
 
 func (s *service) ResetCartAfterCheckout(cartID uuid.UUID) error {
 	query, args, err := QB.Update("carts").
+		Set("subtotal", 0).
+		Set("discount_total", 0).
+		Set("tax_total", 0).
 		Set("total_price", 0).
+		Set("delivery_fee", 0).
+		Set("fulfillment_type", nil).
+		Set("table_id", nil).
+		Set("pickup_at", nil).
 		Set("quantity", 0).
 		Set("vendor_id", nil).
+		Set("coupon_id", nil).
 		Set("updated_at", time.Now()).
 		Where("id = ?", cartID).
 		ToSql()
@@ -324,19 +536,90 @@ type contextKey string
 var UserIDKey = contextKey("userID")
 
 func (s *service) GetUserID(r *http.Request) string {
-	return r.Context().Value(UserIDKey).(string)
+	userID, _ := r.Context().Value(UserIDKey).(string)
+	return userID
 }
 
-func (s *service) ParseAddCartParams(r *http.Request) (uuid.UUID, int, error) {
+func (s *service) ParseAddCartParams(r *http.Request) (types.AddCartItem, error) {
+	var line types.AddCartItem
+
 	itemID, err := uuid.Parse(r.FormValue("item_id"))
 	if err != nil {
-		return uuid.Nil, 0, fmt.Errorf("invalid item ID")
+		return line, fmt.Errorf("invalid item ID")
 	}
+	line.ItemId = itemID
 
 	quantity, err := strconv.Atoi(r.FormValue("quantity"))
 	if err != nil || quantity <= 0 {
-		return uuid.Nil, 0, fmt.Errorf("quantity must be a positive integer")
+		return line, fmt.Errorf("quantity must be a positive integer")
+	}
+	line.Quantity = quantity
+
+	if variant := r.FormValue("variant_id"); variant != "" {
+		variantID, err := uuid.Parse(variant)
+		if err != nil {
+			return line, fmt.Errorf("invalid variant ID")
+		}
+		line.VariantId = &variantID
+	}
+
+	// modifier_ids may be repeated or given as a comma separated list
+	for _, value := range r.Form["modifier_ids"] {
+		for _, raw := range strings.Split(value, ",") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+			optionID, err := uuid.Parse(raw)
+			if err != nil {
+				return line, fmt.Errorf("invalid modifier ID")
+			}
+			line.ModifierOptionIds = append(line.ModifierOptionIds, optionID)
+		}
+	}
+
+	return line, nil
+}
+
+func (s *service) ParseCheckoutParams(r *http.Request) (types.Checkout, error) {
+	var checkout types.Checkout
+
+	if tableID := r.FormValue("table_id"); tableID != "" {
+		id, err := uuid.Parse(tableID)
+		if err != nil {
+			return checkout, fmt.Errorf("invalid table ID")
+		}
+		checkout.TableId = &id
+	}
+
+	if partySize := r.FormValue("party_size"); partySize != "" {
+		size, err := strconv.Atoi(partySize)
+		if err != nil || size <= 0 {
+			return checkout, fmt.Errorf("party size must be a positive integer")
+		}
+		checkout.PartySize = size
+	}
+
+	if tip := r.FormValue("tip"); tip != "" {
+		amount, err := strconv.ParseFloat(tip, 64)
+		if err != nil || amount < 0 {
+			return checkout, fmt.Errorf("tip must be a non-negative amount")
+		}
+		checkout.Tip = amount
+	}
+
+	checkout.PaymentMethod = r.FormValue("payment_method")
+	switch checkout.PaymentMethod {
+	case "":
+		checkout.PaymentMethod = "pay_at_table"
+	case "pay_now":
+		checkout.PaymentToken = r.FormValue("payment_token")
+		if checkout.PaymentToken == "" {
+			return checkout, fmt.Errorf("payment token is required to pay now")
+		}
+	case "pay_at_table":
+	default:
+		return checkout, fmt.Errorf("payment method must be pay_now or pay_at_table")
 	}
 
-	return itemID, quantity, nil
+	return checkout, nil
 }
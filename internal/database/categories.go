@@ -0,0 +1,473 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var categoryColumns = []string{
+	"id", "vendor_id", "parent_id", "name", "description", "display_order", "is_active", "created_at", "updated_at",
+}
+
+func (s *service) ListCategories(queryParams url.Values) ([]types.Category, *types.Meta, error) {
+	var categories []types.Category
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "display_order")
+	}
+
+	meta, err := s.BuildQuery(
+		&categories,
+		"categories",
+		[]string{},
+		categoryColumns,
+		[]string{"name"},
+		queryParams,
+		[]string{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	if categories == nil {
+		categories = []types.Category{}
+	}
+
+	return categories, meta, nil
+}
+
+func (s *service) GetCategoryByID(id string) (*types.Category, error) {
+	var category types.Category
+	query, args, err := QB.Select(strings.Join(categoryColumns, ", ")).
+		From("categories").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&category, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("category not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch category: %w", err)
+	}
+	return &category, nil
+}
+
+func (s *service) CreateCategory(category types.Category) (*types.Category, error) {
+	if category.VendorId == uuid.Nil || category.Name == "" {
+		return nil, errors.New("missing required parameters")
+	}
+	displayOrder, isActive := 0, true
+	setCategoryDefaults(&category, types.Category{DisplayOrder: &displayOrder, IsActive: &isActive})
+
+	category.ID = uuid.New()
+	if err := s.validateCategoryParent(category); err != nil {
+		return nil, err
+	}
+
+	category.Created_at = time.Now()
+	category.Updated_at = time.Now()
+
+	query, args, err := QB.Insert("categories").
+		Columns(categoryColumns...).
+		Values(category.ID, category.VendorId, category.ParentId, category.Name, category.Description,
+			category.DisplayOrder, category.IsActive, category.Created_at, category.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(categoryColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	if err := s.db.QueryRowx(query, args...).StructScan(&category); err != nil {
+		return nil, fmt.Errorf("error inserting category: %w", err)
+	}
+
+	return &category, nil
+}
+
+// UpdateCategory changes the fields given and keeps the rest. The parent and
+// description are only removed when listed in cleared.
+func (s *service) UpdateCategory(id string, category types.Category, cleared map[string]bool) (*types.Category, error) {
+	existing, err := s.GetCategoryByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	category.ID = existing.ID
+	category.VendorId = existing.VendorId
+	if cleared["parent_id"] {
+		existing.ParentId = nil
+	}
+	if cleared["description"] {
+		existing.Description = nil
+	}
+	setCategoryDefaults(&category, *existing)
+	if err := s.validateCategoryParent(category); err != nil {
+		return nil, err
+	}
+
+	query, args, err := QB.Update("categories").
+		Set("parent_id", category.ParentId).
+		Set("name", category.Name).
+		Set("description", category.Description).
+		Set("display_order", category.DisplayOrder).
+		Set("is_active", category.IsActive).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(categoryColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	var updated types.Category
+	if err := s.db.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		return nil, fmt.Errorf("error updating category: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (s *service) DeleteCategory(id string) error {
+	_, err := deleteById(s, id, "categories")
+	if err != nil {
+		return fmt.Errorf("error deleting category: %w", err)
+	}
+	return nil
+}
+
+// setCategoryDefaults fills in the fields a category payload left out.
+func setCategoryDefaults(category *types.Category, defaults types.Category) {
+	if category.Name == "" {
+		category.Name = defaults.Name
+	}
+	if category.ParentId == nil {
+		category.ParentId = defaults.ParentId
+	}
+	if category.Description == nil {
+		category.Description = defaults.Description
+	}
+	if category.DisplayOrder == nil {
+		category.DisplayOrder = defaults.DisplayOrder
+	}
+	if category.IsActive == nil {
+		category.IsActive = defaults.IsActive
+	}
+}
+
+// validateCategoryParent keeps the menu one level deep: a sub-category's
+// parent must be a top-level category of the same vendor, and a category
+// that already has children can't become a sub-category itself.
+func (s *service) validateCategoryParent(category types.Category) error {
+	if category.ParentId == nil {
+		return nil
+	}
+	if *category.ParentId == category.ID {
+		return errors.New("category cannot be its own parent")
+	}
+
+	parent, err := s.GetCategoryByID(category.ParentId.String())
+	if err != nil {
+		return errors.New("parent category not found")
+	}
+	if parent.VendorId != category.VendorId {
+		return errors.New("parent category belongs to another vendor")
+	}
+	if parent.ParentId != nil {
+		return errors.New("categories can only be nested one level deep")
+	}
+
+	var children int
+	query, args, err := QB.Select("COUNT(*)").From("categories").Where("parent_id = ?", category.ID).ToSql()
+	if err != nil {
+		return err
+	}
+	if err := s.db.Get(&children, query, args...); err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("a category with sub-categories cannot be nested")
+	}
+
+	return nil
+}
+
+// SetItemCategories replaces the categories an item is listed under. An item
+// keeps its place in the categories it was already in and goes to the end of
+// the ones it joins; SetCategoryItems reorders them.
+func (s *service) SetItemCategories(itemID string, categoryIDs []uuid.UUID) ([]types.Category, error) {
+	item, err := s.GetItemByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if len(categoryIDs) > 0 {
+		var count int
+		query, args, err := QB.Select("COUNT(*)").
+			From("categories").
+			Where(squirrel.Eq{"id": categoryIDs, "vendor_id": item.VendorId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Get(&count, query, args...); err != nil {
+			return nil, err
+		}
+		if count != len(categoryIDs) {
+			return nil, errors.New("categories must exist and belong to the item's vendor")
+		}
+	}
+
+	remove := QB.Delete("item_categories").Where("item_id = ?", item.ID)
+	if len(categoryIDs) > 0 {
+		remove = remove.Where(squirrel.NotEq{"category_id": categoryIDs})
+	}
+	query, args, err := remove.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, categoryID := range categoryIDs {
+		if _, err := tx.Exec(`INSERT INTO item_categories (item_id, category_id, display_order)
+			SELECT $1, $2, COALESCE(MAX(display_order) + 1, 0) FROM item_categories WHERE category_id = $2
+			ON CONFLICT (item_id, category_id) DO NOTHING`, item.ID, categoryID); err != nil {
+			return nil, fmt.Errorf("error assigning categories: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return itemCategories(s.db, item.ID)
+}
+
+// SetCategoryItems orders the items listed under a category. The given items
+// come first, in that order, followed by the rest as they were.
+func (s *service) SetCategoryItems(categoryID string, itemIDs []uuid.UUID) ([]types.Item, error) {
+	category, err := s.GetCategoryByID(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if len(itemIDs) > 0 {
+		var count int
+		query, args, err := QB.Select("COUNT(*)").
+			From("item_categories").
+			Where(squirrel.Eq{"category_id": category.ID, "item_id": itemIDs}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Get(&count, query, args...); err != nil {
+			return nil, err
+		}
+		if count != len(itemIDs) {
+			return nil, errors.New("items must be listed under the category, once each")
+		}
+
+		query, args, err = QB.Update("item_categories").
+			Set("display_order", squirrel.Expr("display_order + ?", len(itemIDs))).
+			Where(squirrel.Eq{"category_id": category.ID}).
+			Where(squirrel.NotEq{"item_id": itemIDs}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, itemID := range itemIDs {
+		query, args, err := QB.Update("item_categories").
+			Set("display_order", i).
+			Where(squirrel.Eq{"category_id": category.ID, "item_id": itemID}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error ordering items: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.categoryItems(category.ID)
+}
+
+func (s *service) categoryItems(categoryID uuid.UUID) ([]types.Item, error) {
+	items := []types.Item{}
+	query, args, err := QB.Select(itemColumns...).
+		From("items").
+		Join("item_categories ON item_categories.item_id = items.id").
+		Where(squirrel.Eq{"item_categories.category_id": categoryID}).
+		OrderBy("item_categories.display_order", "items.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&items, query, args...); err != nil {
+		return nil, err
+	}
+	if err := s.attachItemVariants(items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func itemCategories(q sqlx.Queryer, itemID uuid.UUID) ([]types.Category, error) {
+	columns := make([]string, len(categoryColumns))
+	for i, column := range categoryColumns {
+		columns[i] = "categories." + column
+	}
+
+	categories := []types.Category{}
+	query, args, err := QB.Select(columns...).
+		From("categories").
+		Join("item_categories ON item_categories.category_id = categories.id").
+		Where("item_categories.item_id = ?", itemID).
+		OrderBy("categories.display_order", "categories.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.Select(q, &categories, query, args...)
+	return categories, err
+}
+
+// categoryItemFilter restricts an items query to a category, including the
+// items listed under its sub-categories.
+func categoryItemFilter(categoryID string) (string, error) {
+	id, err := uuid.Parse(categoryID)
+	if err != nil {
+		return "", errors.New("invalid category_id")
+	}
+	return fmt.Sprintf(
+		"id IN (SELECT item_categories.item_id FROM item_categories "+
+			"JOIN categories ON categories.id = item_categories.category_id "+
+			"WHERE categories.id = '%[1]s' OR categories.parent_id = '%[1]s')", id), nil
+}
+
+type menuItem struct {
+	types.Item
+	CategoryId uuid.UUID `db:"category_id"`
+}
+
+// GetVendorMenu returns the vendor's active categories as an ordered tree
+// with the items that can be ordered right now, plus anything not filed
+// under a category.
+func (s *service) GetVendorMenu(vendorID string) (*types.Menu, error) {
+	vendor, err := s.GetVendorByID(vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var categories []types.Category
+	query, args, err := QB.Select(categoryColumns...).
+		From("categories").
+		Where(squirrel.Eq{"vendor_id": vendor.ID, "is_active": true}).
+		OrderBy("display_order", "name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&categories, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %w", err)
+	}
+
+	var items []menuItem
+	query, args, err = QB.Select(itemColumns...).
+		Column("item_categories.category_id").
+		From("items").
+		Join("item_categories ON item_categories.item_id = items.id").
+		Where(squirrel.Eq{"items.vendor_id": vendor.ID}).
+		Where(itemAvailableCondition).
+		OrderBy("item_categories.display_order", "items.name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&items, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch menu items: %w", err)
+	}
+
+	itemIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		itemIDs[i] = item.ID
+	}
+	variants, err := itemVariants(s.db, itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch item variants: %w", err)
+	}
+
+	itemsByCategory := make(map[uuid.UUID][]types.Item)
+	for _, item := range items {
+		item.Variants = variants[item.ID]
+		itemsByCategory[item.CategoryId] = append(itemsByCategory[item.CategoryId], item.Item)
+	}
+
+	// Sub-categories of an inactive parent are hidden along with it.
+	childrenByParent := make(map[uuid.UUID][]types.Category)
+	for _, category := range categories {
+		if category.ParentId != nil {
+			category.Items = itemsByCategory[category.ID]
+			childrenByParent[*category.ParentId] = append(childrenByParent[*category.ParentId], category)
+		}
+	}
+
+	menu := &types.Menu{Vendor: vendor, Categories: []types.Category{}}
+	for _, category := range categories {
+		if category.ParentId == nil {
+			category.Items = itemsByCategory[category.ID]
+			category.Children = childrenByParent[category.ID]
+			menu.Categories = append(menu.Categories, category)
+		}
+	}
+
+	query, args, err = QB.Select(itemColumns...).
+		From("items").
+		Where(squirrel.Eq{"vendor_id": vendor.ID}).
+		Where("NOT EXISTS (SELECT 1 FROM item_categories WHERE item_categories.item_id = items.id)").
+		Where(itemAvailableCondition).
+		OrderBy("name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&menu.Uncategorized, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch uncategorized items: %w", err)
+	}
+	if err := s.attachItemVariants(menu.Uncategorized); err != nil {
+		return nil, fmt.Errorf("failed to fetch item variants: %w", err)
+	}
+
+	return menu, nil
+}
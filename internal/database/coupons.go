@@ -0,0 +1,643 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCoupon = errors.New("coupon is not valid")
+
+var couponColumns = []string{
+	"id", "vendor_id", "code", "description", "discount_type", "value", "min_spend", "max_discount",
+	"starts_at", "ends_at", "usage_limit", "usage_limit_per_user", "is_active", "created_at", "updated_at",
+}
+
+func (s *service) ListCoupons(queryParams url.Values) ([]types.Coupon, *types.Meta, error) {
+	var coupons []types.Coupon
+
+	meta, err := s.BuildQuery(
+		&coupons,
+		"coupons",
+		[]string{},
+		couponColumns,
+		[]string{"code", "description"},
+		queryParams,
+		[]string{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list coupons: %w", err)
+	}
+
+	if coupons == nil {
+		coupons = []types.Coupon{}
+	}
+
+	return coupons, meta, nil
+}
+
+func (s *service) GetCouponByID(id string) (*types.Coupon, error) {
+	var coupon types.Coupon
+	query, args, err := QB.Select(strings.Join(couponColumns, ", ")).
+		From("coupons").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&coupon, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("coupon not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch coupon: %w", err)
+	}
+
+	if coupon.ItemIds, err = couponItemIds(s.db, coupon.ID); err != nil {
+		return nil, fmt.Errorf("failed to fetch coupon items: %w", err)
+	}
+	if coupon.CategoryIds, err = couponCategoryIds(s.db, coupon.ID); err != nil {
+		return nil, fmt.Errorf("failed to fetch coupon categories: %w", err)
+	}
+
+	return &coupon, nil
+}
+
+func (s *service) CreateCoupon(coupon types.Coupon) (*types.Coupon, error) {
+	minSpend, isActive := 0.0, true
+	setCouponDefaults(&coupon, types.Coupon{MinSpend: &minSpend, IsActive: &isActive})
+	if err := validateCoupon(coupon); err != nil {
+		return nil, err
+	}
+
+	coupon.ID = uuid.New()
+	coupon.Code = strings.ToUpper(strings.TrimSpace(coupon.Code))
+	coupon.Created_at = time.Now()
+	coupon.Updated_at = time.Now()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Insert("coupons").
+		Columns(couponColumns...).
+		Values(coupon.ID, coupon.VendorId, coupon.Code, coupon.Description, coupon.DiscountType, coupon.Value,
+			coupon.MinSpend, coupon.MaxDiscount, coupon.StartsAt, coupon.EndsAt, coupon.UsageLimit,
+			coupon.UsageLimitPerUser, coupon.IsActive, coupon.Created_at, coupon.Updated_at).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error inserting coupon: %w", err)
+	}
+
+	if err := setCouponItems(tx, coupon.ID, coupon.VendorId, coupon.ItemIds); err != nil {
+		return nil, fmt.Errorf("error inserting coupon items: %w", err)
+	}
+	if err := setCouponCategories(tx, coupon.ID, coupon.VendorId, coupon.CategoryIds); err != nil {
+		return nil, fmt.Errorf("error inserting coupon categories: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCouponByID(coupon.ID.String())
+}
+
+// UpdateCoupon changes the fields given and keeps the rest. The optional
+// limits and dates are only removed when listed in cleared.
+func (s *service) UpdateCoupon(id string, coupon types.Coupon, cleared map[string]bool) (*types.Coupon, error) {
+	existing, err := s.GetCouponByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := *existing
+	if cleared["description"] {
+		defaults.Description = nil
+	}
+	if cleared["max_discount"] {
+		defaults.MaxDiscount = nil
+	}
+	if cleared["starts_at"] {
+		defaults.StartsAt = nil
+	}
+	if cleared["ends_at"] {
+		defaults.EndsAt = nil
+	}
+	if cleared["usage_limit"] {
+		defaults.UsageLimit = nil
+	}
+	if cleared["usage_limit_per_user"] {
+		defaults.UsageLimitPerUser = nil
+	}
+	setCouponDefaults(&coupon, defaults)
+	if err := validateCoupon(coupon); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// A coupon stays with the vendor it was made for
+	query, args, err := QB.Update("coupons").
+		Set("code", strings.ToUpper(strings.TrimSpace(coupon.Code))).
+		Set("description", coupon.Description).
+		Set("discount_type", coupon.DiscountType).
+		Set("value", coupon.Value).
+		Set("min_spend", coupon.MinSpend).
+		Set("max_discount", coupon.MaxDiscount).
+		Set("starts_at", coupon.StartsAt).
+		Set("ends_at", coupon.EndsAt).
+		Set("usage_limit", coupon.UsageLimit).
+		Set("usage_limit_per_user", coupon.UsageLimitPerUser).
+		Set("is_active", coupon.IsActive).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating coupon: %w", err)
+	}
+
+	if coupon.ItemIds != nil {
+		if err := setCouponItems(tx, existing.ID, existing.VendorId, coupon.ItemIds); err != nil {
+			return nil, fmt.Errorf("error updating coupon items: %w", err)
+		}
+	}
+	if coupon.CategoryIds != nil {
+		if err := setCouponCategories(tx, existing.ID, existing.VendorId, coupon.CategoryIds); err != nil {
+			return nil, fmt.Errorf("error updating coupon categories: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCouponByID(id)
+}
+
+func (s *service) DeleteCoupon(id string) error {
+	_, err := deleteById(s, id, "coupons")
+	if err != nil {
+		return fmt.Errorf("error deleting coupon: %w", err)
+	}
+	return nil
+}
+
+// ApplyCartCoupon attaches a coupon to the user's cart. The coupon is only
+// kept if it actually applies to the cart as it stands.
+func (s *service) ApplyCartCoupon(userID, code string) (types.Cart, error) {
+	cart, err := s.GetCart(userID)
+	if err != nil {
+		return cart, err
+	}
+
+	var couponID uuid.UUID
+	query, args, err := QB.Select("id").
+		From("coupons").
+		Where("UPPER(code) = ?", strings.ToUpper(strings.TrimSpace(code))).
+		ToSql()
+	if err != nil {
+		return cart, err
+	}
+	if err := s.db.Get(&couponID, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cart, fmt.Errorf("%w: unknown code", ErrInvalidCoupon)
+		}
+		return cart, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return cart, err
+	}
+	defer tx.Rollback()
+
+	query, args, err = QB.Update("carts").Set("coupon_id", couponID).Where("id = ?", cart.ID).ToSql()
+	if err != nil {
+		return cart, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return cart, err
+	}
+
+	pricing, err := recalculateCart(tx, cart.ID)
+	if err != nil {
+		return cart, err
+	}
+	if pricing.CouponErr != nil {
+		return cart, pricing.CouponErr
+	}
+
+	if err := tx.Commit(); err != nil {
+		return cart, err
+	}
+
+	return s.GetCart(userID)
+}
+
+func (s *service) RemoveCartCoupon(userID string) (types.Cart, error) {
+	cart, err := s.GetCart(userID)
+	if err != nil {
+		return cart, err
+	}
+
+	query, args, err := QB.Update("carts").Set("coupon_id", nil).Where("id = ?", cart.ID).ToSql()
+	if err != nil {
+		return cart, err
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return cart, err
+	}
+
+	if err := s.RecalculateCart(cart.ID); err != nil {
+		return cart, err
+	}
+
+	return s.GetCart(userID)
+}
+
+// setCouponDefaults fills in the fields a coupon payload left out.
+func setCouponDefaults(coupon *types.Coupon, defaults types.Coupon) {
+	if coupon.Code == "" {
+		coupon.Code = defaults.Code
+	}
+	if coupon.Description == nil {
+		coupon.Description = defaults.Description
+	}
+	if coupon.DiscountType == "" {
+		coupon.DiscountType = defaults.DiscountType
+	}
+	if coupon.Value == 0 {
+		coupon.Value = defaults.Value
+	}
+	if coupon.MinSpend == nil {
+		coupon.MinSpend = defaults.MinSpend
+	}
+	if coupon.MaxDiscount == nil {
+		coupon.MaxDiscount = defaults.MaxDiscount
+	}
+	if coupon.StartsAt == nil {
+		coupon.StartsAt = defaults.StartsAt
+	}
+	if coupon.EndsAt == nil {
+		coupon.EndsAt = defaults.EndsAt
+	}
+	if coupon.UsageLimit == nil {
+		coupon.UsageLimit = defaults.UsageLimit
+	}
+	if coupon.UsageLimitPerUser == nil {
+		coupon.UsageLimitPerUser = defaults.UsageLimitPerUser
+	}
+	if coupon.IsActive == nil {
+		coupon.IsActive = defaults.IsActive
+	}
+}
+
+func validateCoupon(coupon types.Coupon) error {
+	if strings.TrimSpace(coupon.Code) == "" {
+		return errors.New("code is required")
+	}
+	switch coupon.DiscountType {
+	case "percentage":
+		if coupon.Value <= 0 || coupon.Value > 100 {
+			return errors.New("percentage discounts must be between 0 and 100")
+		}
+	case "fixed":
+		if coupon.Value <= 0 {
+			return errors.New("fixed discounts must be positive")
+		}
+	default:
+		return errors.New("discount_type must be percentage or fixed")
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if coupon.MinSpend != nil && *coupon.MinSpend < 0 {
+		return errors.New("min_spend cannot be negative")
+	}
+	return nil
+}
+
+func setCouponItems(tx *sqlx.Tx, couponID uuid.UUID, vendorID *uuid.UUID, itemIDs []uuid.UUID) error {
+	if err := checkCouponVendor(tx, "items", vendorID, itemIDs); err != nil {
+		return err
+	}
+
+	query, args, err := QB.Delete("coupon_items").Where("coupon_id = ?", couponID).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	if len(itemIDs) == 0 {
+		return nil
+	}
+
+	insert := QB.Insert("coupon_items").Columns("coupon_id", "item_id")
+	for _, itemID := range itemIDs {
+		insert = insert.Values(couponID, itemID)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// checkCouponVendor makes sure a vendor's coupon only targets that vendor's
+// items or categories. Platform coupons may target any.
+func checkCouponVendor(tx *sqlx.Tx, table string, vendorID *uuid.UUID, ids []uuid.UUID) error {
+	if vendorID == nil || len(ids) == 0 {
+		return nil
+	}
+
+	var count int
+	query, args, err := QB.Select("COUNT(DISTINCT id)").
+		From(table).
+		Where(squirrel.Eq{"id": ids, "vendor_id": *vendorID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := tx.Get(&count, query, args...); err != nil {
+		return err
+	}
+	distinct := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		distinct[id] = true
+	}
+	if count != len(distinct) {
+		return fmt.Errorf("%s must exist and belong to the coupon's vendor", table)
+	}
+	return nil
+}
+
+func couponItemIds(q sqlx.Queryer, couponID uuid.UUID) ([]uuid.UUID, error) {
+	var itemIDs []uuid.UUID
+	query, args, err := QB.Select("item_id").From("coupon_items").Where("coupon_id = ?", couponID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.Select(q, &itemIDs, query, args...)
+	return itemIDs, err
+}
+
+func setCouponCategories(tx *sqlx.Tx, couponID uuid.UUID, vendorID *uuid.UUID, categoryIDs []uuid.UUID) error {
+	if err := checkCouponVendor(tx, "categories", vendorID, categoryIDs); err != nil {
+		return err
+	}
+
+	query, args, err := QB.Delete("coupon_categories").Where("coupon_id = ?", couponID).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	insert := QB.Insert("coupon_categories").Columns("coupon_id", "category_id")
+	for _, categoryID := range categoryIDs {
+		insert = insert.Values(couponID, categoryID)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+func couponCategoryIds(q sqlx.Queryer, couponID uuid.UUID) ([]uuid.UUID, error) {
+	var categoryIDs []uuid.UUID
+	query, args, err := QB.Select("category_id").From("coupon_categories").Where("coupon_id = ?", couponID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	err = sqlx.Select(q, &categoryIDs, query, args...)
+	return categoryIDs, err
+}
+
+// couponTargetItems resolves the items a coupon is limited to: the items it
+// names directly plus everything filed under its categories or their
+// sub-categories. An empty result means the coupon applies to the whole cart.
+func couponTargetItems(q sqlx.Queryer, coupon *types.Coupon) (map[uuid.UUID]bool, error) {
+	targets := make(map[uuid.UUID]bool, len(coupon.ItemIds))
+	for _, itemID := range coupon.ItemIds {
+		targets[itemID] = true
+	}
+
+	if len(coupon.CategoryIds) == 0 {
+		return targets, nil
+	}
+
+	var itemIDs []uuid.UUID
+	query, args, err := QB.Select("DISTINCT item_categories.item_id").
+		From("item_categories").
+		Join("categories ON categories.id = item_categories.category_id").
+		Where(squirrel.Or{
+			squirrel.Eq{"categories.id": coupon.CategoryIds},
+			squirrel.Eq{"categories.parent_id": coupon.CategoryIds},
+		}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &itemIDs, query, args...); err != nil {
+		return nil, err
+	}
+	for _, itemID := range itemIDs {
+		targets[itemID] = true
+	}
+
+	return targets, nil
+}
+
+func loadCartCoupon(q sqlx.Queryer, cartID uuid.UUID) (*types.Coupon, error) {
+	var coupon types.Coupon
+	columns := make([]string, len(couponColumns))
+	for i, column := range couponColumns {
+		columns[i] = "coupons." + column
+	}
+
+	query, args, err := QB.Select(columns...).
+		From("carts").
+		Join("coupons ON coupons.id = carts.coupon_id").
+		Where("carts.id = ?", cartID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Get(q, &coupon, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if coupon.ItemIds, err = couponItemIds(q, coupon.ID); err != nil {
+		return nil, err
+	}
+	if coupon.CategoryIds, err = couponCategoryIds(q, coupon.ID); err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+// applyCoupon checks the coupon against the cart and spreads the discount
+// over the eligible lines in proportion to their amount.
+func applyCoupon(q sqlx.Queryer, coupon *types.Coupon, userID uuid.UUID, lines []cartLine, now time.Time) error {
+	if coupon.IsActive == nil || !*coupon.IsActive {
+		return fmt.Errorf("%w: coupon is inactive", ErrInvalidCoupon)
+	}
+	if coupon.StartsAt != nil && now.Before(*coupon.StartsAt) {
+		return fmt.Errorf("%w: coupon is not active yet", ErrInvalidCoupon)
+	}
+	if coupon.EndsAt != nil && !now.Before(*coupon.EndsAt) {
+		return fmt.Errorf("%w: coupon has expired", ErrInvalidCoupon)
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("%w: cart is empty", ErrInvalidCoupon)
+	}
+	if coupon.VendorId != nil && *coupon.VendorId != lines[0].VendorId {
+		return fmt.Errorf("%w: coupon is not valid at this vendor", ErrInvalidCoupon)
+	}
+
+	if coupon.UsageLimit != nil || coupon.UsageLimitPerUser != nil {
+		var used, usedByUser int
+		query, args, err := QB.Select("COUNT(*)").
+			Column(squirrel.Expr("COUNT(*) FILTER (WHERE user_id = ?)", userID)).
+			From("coupon_redemptions").
+			Where("coupon_id = ?", coupon.ID).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if err := q.QueryRowx(query, args...).Scan(&used, &usedByUser); err != nil {
+			return err
+		}
+		if coupon.UsageLimit != nil && used >= *coupon.UsageLimit {
+			return fmt.Errorf("%w: coupon usage limit reached", ErrInvalidCoupon)
+		}
+		if coupon.UsageLimitPerUser != nil && usedByUser >= *coupon.UsageLimitPerUser {
+			return fmt.Errorf("%w: you have already used this coupon", ErrInvalidCoupon)
+		}
+	}
+
+	targets, err := couponTargetItems(q, coupon)
+	if err != nil {
+		return err
+	}
+	targeted := len(coupon.ItemIds) > 0 || len(coupon.CategoryIds) > 0
+
+	var cartTotal, eligibleTotal float64
+	var eligible []int
+	for i, line := range lines {
+		cartTotal += line.Amount
+		if !targeted || targets[line.ItemId] {
+			eligible = append(eligible, i)
+			eligibleTotal += line.Amount
+		}
+	}
+
+	if coupon.MinSpend != nil && cartTotal < *coupon.MinSpend {
+		return fmt.Errorf("%w: minimum spend is %.2f", ErrInvalidCoupon, *coupon.MinSpend)
+	}
+	if len(eligible) == 0 || eligibleTotal <= 0 {
+		return fmt.Errorf("%w: coupon does not apply to any item in the cart", ErrInvalidCoupon)
+	}
+
+	discount := coupon.Value
+	if coupon.DiscountType == "percentage" {
+		discount = eligibleTotal * coupon.Value / 100
+	}
+	if coupon.MaxDiscount != nil && discount > *coupon.MaxDiscount {
+		discount = *coupon.MaxDiscount
+	}
+	if discount > eligibleTotal {
+		discount = eligibleTotal
+	}
+	discount = roundMoney(discount)
+
+	remaining := discount
+	for n, i := range eligible {
+		share := roundMoney(discount * lines[i].Amount / eligibleTotal)
+		if n == len(eligible)-1 || share > remaining {
+			share = remaining
+		}
+		lines[i].ListedDiscount = share
+		remaining = roundMoney(remaining - share)
+	}
+
+	return nil
+}
+
+// lockCartCoupon takes a row lock on the coupon held by the cart so usage
+// limits can't be overrun by concurrent checkouts.
+func lockCartCoupon(tx *sqlx.Tx, cartID uuid.UUID) error {
+	query, args, err := QB.Select("coupons.id").
+		From("coupons").
+		Join("carts ON carts.coupon_id = coupons.id").
+		Where("carts.id = ?", cartID).
+		Suffix("FOR UPDATE OF coupons").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+func createOrderDiscounts(tx *sqlx.Tx, order types.Order, pricing cartPricing) error {
+	if pricing.Coupon == nil || pricing.DiscountTotal == 0 {
+		return nil
+	}
+
+	query, args, err := QB.Insert("order_discounts").
+		Columns("order_id", "coupon_id", "code", "description", "amount").
+		Values(order.ID, pricing.Coupon.ID, pricing.Coupon.Code, pricing.Coupon.Description, pricing.DiscountTotal).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = QB.Insert("coupon_redemptions").
+		Columns("coupon_id", "order_id", "user_id", "amount").
+		Values(pricing.Coupon.ID, order.ID, order.CustomerId, pricing.DiscountTotal).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
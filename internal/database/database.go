@@ -40,6 +40,7 @@ type Service interface {
 	GetRoles(user *types.User) error
 
 	ListVendors(queryParams url.Values) ([]types.Vendor, *types.Meta, error)
+	NearbyVendors(queryParams url.Values) ([]types.Vendor, *types.Meta, error)
 	GetVendorByID(id string) (*types.Vendor, error)
 	CreateVendor(vendor types.Vendor) (*types.Vendor, error)
 	UpdateVendor(newVendor types.Vendor, id string) (*types.Vendor, error)
@@ -47,6 +48,7 @@ type Service interface {
 	GrantAdmin(userID, vendorID string) error
 	RevokeAdmin(userID, vendorID string) error
 	ListVendorAdmins(vendorID string) ([]types.User, error)
+	IsVendorAdmin(userID, vendorID uuid.UUID) (bool, error)
 
 	FetchRoles(queryParams map[string][]string) ([]types.Role, *types.Meta, error)
 	FetchRole(id string) (types.Role, error)
@@ -59,6 +61,112 @@ type Service interface {
 	FetchOrder(id string) (types.Order, error)
 	EnrichOrdersWithItems(orders []types.Order) error
 	FetchOrders(queryParams map[string][]string) ([]types.Order, types.Meta, error)
+	SetOrderTip(orderID string, amount float64) (types.Order, error)
+	SalesReport(vendorID string, from, to time.Time) (types.SalesReport, error)
+
+	CreatePayment(orderID uuid.UUID, provider, method string) (types.Payment, error)
+	GetPaymentByID(id string) (*types.Payment, error)
+	GetPaymentByProviderRef(provider, reference string) (*types.Payment, error)
+	ListOrderPayments(orderID string) ([]types.Payment, error)
+	UpdatePayment(payment types.Payment, transaction types.PaymentTransaction) error
+
+	CreateRefund(orderID string, request types.RefundRequest, approvedBy uuid.UUID) (types.Refund, error)
+	CompleteRefund(refundID uuid.UUID, refunded float64, failureReason *string) (types.Refund, error)
+	ListOrderRefunds(orderID string) ([]types.Refund, error)
+
+	ListServiceChargeRules(queryParams url.Values) ([]types.ServiceChargeRule, *types.Meta, error)
+	GetServiceChargeRuleByID(id string) (*types.ServiceChargeRule, error)
+	CreateServiceChargeRule(rule types.ServiceChargeRule) (*types.ServiceChargeRule, error)
+	UpdateServiceChargeRule(id string, rule types.ServiceChargeRule) (*types.ServiceChargeRule, error)
+	DeleteServiceChargeRule(id string) error
+
+	ListUserAddresses(userID uuid.UUID) ([]types.UserAddress, error)
+	GetUserAddress(userID uuid.UUID, id string) (*types.UserAddress, error)
+	CreateUserAddress(userID uuid.UUID, address types.UserAddress) (*types.UserAddress, error)
+	UpdateUserAddress(userID uuid.UUID, id string, address types.UserAddress) (*types.UserAddress, error)
+	DeleteUserAddress(userID uuid.UUID, id string) error
+
+	ListDeliveryZones(queryParams url.Values) ([]types.DeliveryZone, *types.Meta, error)
+	GetDeliveryZoneByID(id string) (*types.DeliveryZone, error)
+	CreateDeliveryZone(zone types.DeliveryZone) (*types.DeliveryZone, error)
+	UpdateDeliveryZone(id string, zone types.DeliveryZone) (*types.DeliveryZone, error)
+	DeleteDeliveryZone(id string) error
+	QuoteDelivery(vendorID uuid.UUID, point types.LatLng, orderAmount float64) (types.DeliveryQuote, error)
+
+	CreateReservation(reservation types.Reservation) (*types.Reservation, error)
+	GetReservation(id string) (*types.Reservation, error)
+	ListVendorReservations(vendorID uuid.UUID, queryParams url.Values) ([]types.Reservation, *types.Meta, error)
+	ListCustomerReservations(userID uuid.UUID, queryParams url.Values) ([]types.Reservation, *types.Meta, error)
+	UpdateReservationStatus(id string, status string) (*types.Reservation, error)
+	VendorAvailability(vendorID uuid.UUID, date string, partySize int) ([]types.AvailabilitySlot, error)
+
+	SeatParty(seat types.SeatParty) (*types.TableSession, error)
+	GetTableSession(id string) (*types.TableSession, error)
+	ListTableSessions(vendorID uuid.UUID, queryParams url.Values) ([]types.TableSession, *types.Meta, error)
+	TransferTableSession(id string, fromTableID *uuid.UUID, toTableID uuid.UUID) (*types.TableSession, error)
+	MergeTableSession(id string, tableID uuid.UUID) (*types.TableSession, error)
+	CloseTableSession(id string, force bool) (*types.TableSession, error)
+	TableTurnoverReport(vendorID string, from, to time.Time) (types.TurnoverReport, error)
+
+	CreateServiceRequest(request types.ServiceRequest) (*types.ServiceRequest, bool, error)
+	GetServiceRequest(id string) (*types.ServiceRequest, error)
+	ListServiceRequests(vendorID uuid.UUID, queryParams url.Values) ([]types.ServiceRequest, *types.Meta, error)
+	UpdateServiceRequestStatus(id string, status string, staffID *uuid.UUID) (*types.ServiceRequest, error)
+	ServiceReport(vendorID string, from, to time.Time) (types.ServiceReport, error)
+
+	ListTableSections(vendorID uuid.UUID) ([]types.TableSection, error)
+	CreateTableSection(vendorID uuid.UUID, section types.TableSection) (*types.TableSection, error)
+	UpdateTableSection(vendorID uuid.UUID, id string, section types.TableSection) (*types.TableSection, error)
+	DeleteTableSection(vendorID uuid.UUID, id string) error
+	FloorPlan(vendorID uuid.UUID) (*types.FloorPlan, error)
+	UpdateFloorPlan(vendorID uuid.UUID, layouts []types.TableLayout) (*types.FloorPlan, error)
+
+	QuoteWaitlist(vendorID uuid.UUID, partySize int) (*types.WaitlistQuote, error)
+	CreateWaitlistEntry(entry types.WaitlistEntry) (*types.WaitlistEntry, error)
+	GetWaitlistEntry(id string) (*types.WaitlistEntry, error)
+	GetWaitlistEntryByToken(token string) (*types.WaitlistEntry, error)
+	ListWaitlist(vendorID uuid.UUID, queryParams url.Values) ([]types.WaitlistEntry, *types.Meta, error)
+	UpdateWaitlistStatus(id string, status string) (*types.WaitlistEntry, error)
+	SeatWaitlistEntry(id string, seat types.SeatParty) (*types.WaitlistEntry, error)
+
+	ListKitchenStations(vendorID uuid.UUID) ([]types.KitchenStation, error)
+	CreateKitchenStation(vendorID uuid.UUID, station types.KitchenStation) (*types.KitchenStation, error)
+	UpdateKitchenStation(vendorID uuid.UUID, id string, station types.KitchenStation) (*types.KitchenStation, error)
+	DeleteKitchenStation(vendorID uuid.UUID, id string) error
+	SetKitchenStationRoutes(vendorID uuid.UUID, id string, routes types.KitchenStationRoutes) (*types.KitchenStation, error)
+	KitchenFeed(vendorID uuid.UUID, stationID string, status string) ([]types.KitchenTicket, error)
+	GetKitchenTicket(id string) (*types.KitchenTicket, error)
+	BumpKitchenTicket(id string) (*types.KitchenTicket, error)
+	RecallKitchenTicket(id string) (*types.KitchenTicket, error)
+	BumpKitchenTicketLine(id, lineID string) (*types.KitchenTicket, error)
+	RecallKitchenTicketLine(id, lineID string) (*types.KitchenTicket, error)
+	SetKitchenTicketPriority(id string, priority int) (*types.KitchenTicket, error)
+
+	OrderReceipt(orderID string) (*types.Receipt, error)
+	ListReceiptTemplates(vendorID uuid.UUID) ([]types.ReceiptTemplate, error)
+	ReceiptTemplate(vendorID uuid.UUID, kind, format string) (*types.ReceiptTemplate, error)
+	SaveReceiptTemplate(vendorID uuid.UUID, kind, format, body string) (*types.ReceiptTemplate, error)
+	DeleteReceiptTemplate(vendorID uuid.UUID, kind, format string) error
+
+	ListWebhookEndpoints(vendorID uuid.UUID) ([]types.WebhookEndpoint, error)
+	GetWebhookEndpoint(vendorID uuid.UUID, id string) (*types.WebhookEndpoint, error)
+	CreateWebhookEndpoint(vendorID uuid.UUID, endpoint types.WebhookEndpoint) (*types.WebhookEndpoint, error)
+	UpdateWebhookEndpoint(vendorID uuid.UUID, id string, endpoint types.WebhookEndpoint) (*types.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(vendorID uuid.UUID, id string) error
+	RotateWebhookSecret(vendorID uuid.UUID, id string) (*types.WebhookEndpoint, error)
+	ListWebhookDeliveries(vendorID uuid.UUID, endpointID string, queryParams url.Values) ([]types.WebhookDelivery, *types.Meta, error)
+	GetWebhookDelivery(vendorID uuid.UUID, endpointID, deliveryID string) (*types.WebhookDelivery, error)
+	RetryWebhookDelivery(vendorID uuid.UUID, endpointID, deliveryID string) (*types.WebhookDelivery, error)
+	SendTestWebhook(vendorID uuid.UUID, endpointID string) (*types.WebhookDelivery, error)
+	FanOutOutboxEvents(limit int) (int, error)
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]types.WebhookJob, error)
+	RecordWebhookAttempt(attempt types.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error
+
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
+
+	RotateTableToken(id string) (types.Table, error)
+	CreateGuestUser(tableID uuid.UUID, version int) (*types.User, *types.Table, error)
+	GuestSessionActive(user types.User) (bool, error)
 
 	ListItems(query map[string][]string) ([]types.Item, *types.Meta, error)
 	CreateItem(item types.Item, r *http.Request) (*types.Item, error)
@@ -66,6 +174,63 @@ type Service interface {
 	DeleteItem(id string) error
 	UpdateItem(id string, updates map[string]interface{}, r *http.Request) (*types.Item, error)
 
+	ListCategories(queryParams url.Values) ([]types.Category, *types.Meta, error)
+	GetCategoryByID(id string) (*types.Category, error)
+	CreateCategory(category types.Category) (*types.Category, error)
+	UpdateCategory(id string, category types.Category, cleared map[string]bool) (*types.Category, error)
+	DeleteCategory(id string) error
+	SetItemCategories(itemID string, categoryIDs []uuid.UUID) ([]types.Category, error)
+	SetCategoryItems(categoryID string, itemIDs []uuid.UUID) ([]types.Item, error)
+	GetVendorMenu(vendorID string) (*types.Menu, error)
+
+	ListOpeningHours(vendorID string) ([]types.OpeningHours, error)
+	SetOpeningHours(vendorID string, hours []types.OpeningHours) ([]types.OpeningHours, error)
+	ListHoursOverrides(vendorID string) ([]types.HoursOverride, error)
+	CreateHoursOverride(vendorID string, override types.HoursOverride) (*types.HoursOverride, error)
+	DeleteHoursOverride(vendorID, id string) error
+
+	ListItemSchedules(itemID uuid.UUID) ([]types.AvailabilitySchedule, error)
+	SetItemSchedules(itemID string, schedules []types.AvailabilitySchedule) ([]types.AvailabilitySchedule, error)
+
+	ListItemVariants(itemID uuid.UUID) ([]types.ItemVariant, error)
+	GetItemVariantByID(id string) (*types.ItemVariant, error)
+	CreateItemVariant(itemID string, variant types.ItemVariant) (*types.ItemVariant, error)
+	UpdateItemVariant(id string, variant types.ItemVariant) (*types.ItemVariant, error)
+	DeleteItemVariant(id string) error
+
+	AdjustStock(vendorID string, request types.StockAdjustmentRequest, userID uuid.UUID) (*types.InventoryAdjustment, error)
+	ListInventoryAdjustments(vendorID string, queryParams url.Values) ([]types.InventoryAdjustment, *types.Meta, error)
+	ListLowStock(vendorID string) ([]types.StockLevel, error)
+
+	ListModifierGroups(queryParams url.Values) ([]types.ModifierGroup, *types.Meta, error)
+	GetModifierGroupByID(id string) (*types.ModifierGroup, error)
+	CreateModifierGroup(group types.ModifierGroup) (*types.ModifierGroup, error)
+	UpdateModifierGroup(id string, group types.ModifierGroup) (*types.ModifierGroup, error)
+	DeleteModifierGroup(id string) error
+	CreateModifierOption(groupID string, option types.ModifierOption) (*types.ModifierOption, error)
+	GetModifierOptionByID(id string) (*types.ModifierOption, error)
+	UpdateModifierOption(id string, option types.ModifierOption) (*types.ModifierOption, error)
+	DeleteModifierOption(id string) error
+	SetItemModifierGroups(itemID string, groupIDs []uuid.UUID) ([]types.ModifierGroup, error)
+	GetItemModifierGroups(itemID uuid.UUID, includeInactive bool) ([]types.ModifierGroup, error)
+
+	ListTaxClasses(queryParams url.Values) ([]types.TaxClass, *types.Meta, error)
+	GetTaxClassByID(id string) (*types.TaxClass, error)
+	CreateTaxClass(taxClass types.TaxClass) (*types.TaxClass, error)
+	UpdateTaxClass(id string, taxClass types.TaxClass) (*types.TaxClass, error)
+	DeleteTaxClass(id string) error
+
+	ListCoupons(queryParams url.Values) ([]types.Coupon, *types.Meta, error)
+	GetCouponByID(id string) (*types.Coupon, error)
+	CreateCoupon(coupon types.Coupon) (*types.Coupon, error)
+	UpdateCoupon(id string, coupon types.Coupon, cleared map[string]bool) (*types.Coupon, error)
+	DeleteCoupon(id string) error
+	ApplyCartCoupon(userID, code string) (types.Cart, error)
+	RemoveCartCoupon(userID string) (types.Cart, error)
+	SetCartDelivery(userID string, destination types.DeliveryDestination) (types.Cart, error)
+	RemoveCartDelivery(userID string) (types.Cart, error)
+	SetCartFulfillment(userID string, fulfillment types.Fulfillment) (types.Cart, error)
+
 	DeleteTable(id string) error
 	UpdateTable(table *types.Table) error
 	UpdateTableFromForm(table *types.Table, r *http.Request) error
@@ -81,15 +246,16 @@ type Service interface {
 	CreateCart(userID string, vendorID uuid.UUID) (types.Cart, error)
 	ResetCart(cartID uuid.UUID, vendorID uuid.UUID) (types.Cart, error)
 	ClearCartItems(cartID uuid.UUID) error
-	UpdateCartItem(cartID, itemID uuid.UUID, quantity int) error
+	UpdateCartItem(cartID uuid.UUID, line types.AddCartItem) error
 	RecalculateCart(cartID uuid.UUID) error
 	EmptyCart(userID string) error
-	ProcessCheckout(cart types.Cart) error
+	ProcessCheckout(cart types.Cart, checkout types.Checkout) (types.Order, error)
 	CreateOrder(tx *sqlx.Tx, order types.Order) error
 	CreateOrderItems(tx *sqlx.Tx, orderID, cartID uuid.UUID) error
 	ResetCartAfterCheckout(cartID uuid.UUID) error
 	GetUserID(r *http.Request) string
-	ParseAddCartParams(r *http.Request) (uuid.UUID, int, error)
+	ParseAddCartParams(r *http.Request) (types.AddCartItem, error)
+	ParseCheckoutParams(r *http.Request) (types.Checkout, error)
 
 	Close() error
 }
@@ -115,8 +281,7 @@ func New() Service {
 		return dbInstance
 	}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
-	db, err := sqlx.Connect("pgx", connStr)
+	db, err := sqlx.Connect("pgx", connString())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -139,6 +304,10 @@ func New() Service {
 	return dbInstance
 }
 
+func connString() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
+}
+
 func (s *service) Close() error {
 	log.Printf("Disconnected from database: %s", database)
 	return s.db.Close()
@@ -0,0 +1,560 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var ErrDeliveryUnavailable = errors.New("delivery unavailable")
+
+var deliveryZoneColumns = []string{
+	"id", "vendor_id", "name", "zone_type", "radius_km", "polygon", "display_order", "is_active", "created_at", "updated_at",
+}
+
+func (s *service) ListDeliveryZones(queryParams url.Values) ([]types.DeliveryZone, *types.Meta, error) {
+	var zones []types.DeliveryZone
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "display_order")
+	}
+
+	meta, err := s.BuildQuery(
+		&zones,
+		"delivery_zones",
+		[]string{},
+		deliveryZoneColumns,
+		[]string{"name"},
+		queryParams,
+		[]string{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list delivery zones: %w", err)
+	}
+
+	if zones == nil {
+		zones = []types.DeliveryZone{}
+	}
+	if err := attachFeeTiers(s.db, zones); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch delivery fee tiers: %w", err)
+	}
+
+	return zones, meta, nil
+}
+
+func (s *service) GetDeliveryZoneByID(id string) (*types.DeliveryZone, error) {
+	var zone types.DeliveryZone
+	query, args, err := QB.Select(strings.Join(deliveryZoneColumns, ", ")).
+		From("delivery_zones").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&zone, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("delivery zone not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch delivery zone: %w", err)
+	}
+
+	zones := []types.DeliveryZone{zone}
+	if err := attachFeeTiers(s.db, zones); err != nil {
+		return nil, fmt.Errorf("failed to fetch delivery fee tiers: %w", err)
+	}
+	return &zones[0], nil
+}
+
+func (s *service) CreateDeliveryZone(zone types.DeliveryZone) (*types.DeliveryZone, error) {
+	if zone.VendorId == uuid.Nil {
+		return nil, errors.New("missing required parameters")
+	}
+	displayOrder, isActive := 0, true
+	setDeliveryZoneDefaults(&zone, types.DeliveryZone{DisplayOrder: &displayOrder, IsActive: &isActive})
+	if err := normalizeDeliveryZone(&zone); err != nil {
+		return nil, err
+	}
+
+	zone.ID = uuid.New()
+	zone.Created_at = time.Now()
+	zone.Updated_at = time.Now()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Insert("delivery_zones").
+		Columns(deliveryZoneColumns...).
+		Values(zone.ID, zone.VendorId, zone.Name, zone.ZoneType, zone.RadiusKm, polygonValue(zone),
+			zone.DisplayOrder, zone.IsActive, zone.Created_at, zone.Updated_at).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error inserting delivery zone: %w", err)
+	}
+
+	if err := replaceFeeTiers(tx, zone.ID, zone.FeeTiers); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeliveryZoneByID(zone.ID.String())
+}
+
+// UpdateDeliveryZone replaces a zone's shape and its fee tiers.
+func (s *service) UpdateDeliveryZone(id string, zone types.DeliveryZone) (*types.DeliveryZone, error) {
+	existing, err := s.GetDeliveryZoneByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if zone.Name == "" {
+		zone.Name = existing.Name
+	}
+	setDeliveryZoneDefaults(&zone, *existing)
+	if err := normalizeDeliveryZone(&zone); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Update("delivery_zones").
+		Set("name", zone.Name).
+		Set("zone_type", zone.ZoneType).
+		Set("radius_km", zone.RadiusKm).
+		Set("polygon", polygonValue(zone)).
+		Set("display_order", zone.DisplayOrder).
+		Set("is_active", zone.IsActive).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": existing.ID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating delivery zone: %w", err)
+	}
+
+	if err := replaceFeeTiers(tx, existing.ID, zone.FeeTiers); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeliveryZoneByID(id)
+}
+
+func (s *service) DeleteDeliveryZone(id string) error {
+	_, err := deleteById(s, id, "delivery_zones")
+	if err != nil {
+		return fmt.Errorf("error deleting delivery zone: %w", err)
+	}
+	return nil
+}
+
+// setDeliveryZoneDefaults fills in the ordering and activity a zone payload
+// left out.
+func setDeliveryZoneDefaults(zone *types.DeliveryZone, defaults types.DeliveryZone) {
+	if zone.DisplayOrder == nil {
+		zone.DisplayOrder = defaults.DisplayOrder
+	}
+	if zone.IsActive == nil {
+		zone.IsActive = defaults.IsActive
+	}
+}
+
+func normalizeDeliveryZone(zone *types.DeliveryZone) error {
+	if zone.Name == "" {
+		return errors.New("missing required parameters")
+	}
+
+	switch zone.ZoneType {
+	case "radius":
+		if zone.RadiusKm == nil || *zone.RadiusKm <= 0 {
+			return errors.New("radius zones need a positive radius_km")
+		}
+		zone.Polygon = nil
+	case "polygon":
+		if len(zone.Polygon) < 3 {
+			return errors.New("polygon zones need at least three points")
+		}
+		for _, point := range zone.Polygon {
+			if err := validateCoordinates(&point.Lat, &point.Lng); err != nil {
+				return err
+			}
+		}
+		zone.RadiusKm = nil
+	default:
+		return errors.New("zone_type must be radius or polygon")
+	}
+
+	for _, tier := range zone.FeeTiers {
+		if tier.Fee < 0 || tier.MinOrderAmount < 0 {
+			return errors.New("fee and min_order_amount cannot be negative")
+		}
+		if tier.MaxDistanceKm != nil && *tier.MaxDistanceKm <= 0 {
+			return errors.New("max_distance_km must be positive")
+		}
+	}
+	return nil
+}
+
+func polygonValue(zone types.DeliveryZone) interface{} {
+	if zone.ZoneType != "polygon" {
+		return nil
+	}
+	encoded, _ := json.Marshal(zone.Polygon)
+	return string(encoded)
+}
+
+func replaceFeeTiers(tx *sqlx.Tx, zoneID uuid.UUID, tiers []types.DeliveryFeeTier) error {
+	query, args, err := QB.Delete("delivery_fee_tiers").Where("zone_id = ?", zoneID).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	insert := QB.Insert("delivery_fee_tiers").Columns("id", "zone_id", "max_distance_km", "min_order_amount", "fee")
+	for _, tier := range tiers {
+		insert = insert.Values(uuid.New(), zoneID, tier.MaxDistanceKm, tier.MinOrderAmount, tier.Fee)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("error saving delivery fee tiers: %w", err)
+	}
+	return nil
+}
+
+// attachFeeTiers decodes each zone's polygon and loads its fee tiers.
+func attachFeeTiers(q sqlx.Queryer, zones []types.DeliveryZone) error {
+	ids := make([]uuid.UUID, len(zones))
+	for i := range zones {
+		ids[i] = zones[i].ID
+		if len(zones[i].PolygonJSON) > 0 {
+			if err := json.Unmarshal(zones[i].PolygonJSON, &zones[i].Polygon); err != nil {
+				return err
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var tiers []types.DeliveryFeeTier
+	query, args, err := QB.Select("id", "zone_id", "max_distance_km", "min_order_amount", "fee").
+		From("delivery_fee_tiers").
+		Where(squirrel.Eq{"zone_id": ids}).
+		OrderBy("max_distance_km NULLS LAST", "min_order_amount").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Select(q, &tiers, query, args...); err != nil {
+		return err
+	}
+
+	byZone := make(map[uuid.UUID][]types.DeliveryFeeTier)
+	for _, tier := range tiers {
+		byZone[tier.ZoneId] = append(byZone[tier.ZoneId], tier)
+	}
+	for i := range zones {
+		zones[i].FeeTiers = byZone[zones[i].ID]
+		if zones[i].FeeTiers == nil {
+			zones[i].FeeTiers = []types.DeliveryFeeTier{}
+		}
+	}
+	return nil
+}
+
+// QuoteDelivery works out the delivery fee from a vendor to a point for an
+// order of the given amount.
+func (s *service) QuoteDelivery(vendorID uuid.UUID, point types.LatLng, orderAmount float64) (types.DeliveryQuote, error) {
+	if err := validateCoordinates(&point.Lat, &point.Lng); err != nil {
+		return types.DeliveryQuote{}, err
+	}
+	return quoteDelivery(s.db, vendorID, point, orderAmount)
+}
+
+// quoteDelivery finds the first active zone, in display order, that contains
+// the point and charges the cheapest of its tiers that covers the distance
+// and order amount. A zone without tiers delivers for free.
+func quoteDelivery(q sqlx.Queryer, vendorID uuid.UUID, point types.LatLng, orderAmount float64) (types.DeliveryQuote, error) {
+	var vendor struct {
+		Latitude  *float64 `db:"latitude"`
+		Longitude *float64 `db:"longitude"`
+	}
+	query, args, err := QB.Select("latitude", "longitude").From("vendors").Where("id = ?", vendorID).ToSql()
+	if err != nil {
+		return types.DeliveryQuote{}, err
+	}
+	if err := sqlx.Get(q, &vendor, query, args...); err != nil {
+		return types.DeliveryQuote{}, err
+	}
+	if vendor.Latitude == nil {
+		return types.DeliveryQuote{}, fmt.Errorf("%w: vendor has no location set", ErrDeliveryUnavailable)
+	}
+
+	var zones []types.DeliveryZone
+	query, args, err = QB.Select(deliveryZoneColumns...).
+		From("delivery_zones").
+		Where(squirrel.Eq{"vendor_id": vendorID, "is_active": true}).
+		OrderBy("display_order", "created_at").
+		ToSql()
+	if err != nil {
+		return types.DeliveryQuote{}, err
+	}
+	if err := sqlx.Select(q, &zones, query, args...); err != nil {
+		return types.DeliveryQuote{}, err
+	}
+	if err := attachFeeTiers(q, zones); err != nil {
+		return types.DeliveryQuote{}, err
+	}
+
+	distance := haversineKm(*vendor.Latitude, *vendor.Longitude, point.Lat, point.Lng)
+
+	for _, zone := range zones {
+		if !zoneContains(zone, point, distance) {
+			continue
+		}
+
+		quote := types.DeliveryQuote{ZoneId: zone.ID, ZoneName: zone.Name, DistanceKm: roundDistance(distance)}
+		if len(zone.FeeTiers) == 0 {
+			return quote, nil
+		}
+
+		var best *types.DeliveryFeeTier
+		minimum := -1.0
+		for i, tier := range zone.FeeTiers {
+			if tier.MaxDistanceKm != nil && distance > *tier.MaxDistanceKm {
+				continue
+			}
+			if orderAmount < tier.MinOrderAmount {
+				if minimum < 0 || tier.MinOrderAmount < minimum {
+					minimum = tier.MinOrderAmount
+				}
+				continue
+			}
+			if best == nil || tier.Fee < best.Fee {
+				best = &zone.FeeTiers[i]
+			}
+		}
+
+		if best == nil {
+			if minimum >= 0 {
+				return types.DeliveryQuote{}, fmt.Errorf("%w: delivery to this address needs an order of at least %.2f",
+					ErrDeliveryUnavailable, minimum)
+			}
+			return types.DeliveryQuote{}, fmt.Errorf("%w: address is too far for delivery", ErrDeliveryUnavailable)
+		}
+
+		quote.Fee = roundMoney(best.Fee)
+		return quote, nil
+	}
+
+	return types.DeliveryQuote{}, fmt.Errorf("%w: address is outside the delivery area", ErrDeliveryUnavailable)
+}
+
+func zoneContains(zone types.DeliveryZone, point types.LatLng, distance float64) bool {
+	if zone.ZoneType == "radius" {
+		return zone.RadiusKm != nil && distance <= *zone.RadiusKm
+	}
+	return polygonContains(zone.Polygon, point)
+}
+
+// polygonContains is a ray casting point-in-polygon test, treating longitude
+// and latitude as plane coordinates. That's accurate enough at city scale.
+func polygonContains(polygon []types.LatLng, point types.LatLng) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lng < (b.Lng-a.Lng)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// cartDeliveryFee quotes delivery for a cart that has an address set and
+// hasn't chosen another fulfillment type. A cart
+// that no longer qualifies gets no fee here; checkout rejects it instead.
+func cartDeliveryFee(q sqlx.Queryer, cartID uuid.UUID, pricing cartPricing) (float64, error) {
+	var cart types.Cart
+	query, args, err := QB.Select(cart_columns...).From("carts").Where("id = ?", cartID).ToSql()
+	if err != nil {
+		return 0, err
+	}
+	if err := sqlx.Get(q, &cart, query, args...); err != nil {
+		return 0, err
+	}
+	if cart.DeliveryLatitude == nil || len(pricing.Lines) == 0 {
+		return 0, nil
+	}
+	if cart.FulfillmentType != nil && *cart.FulfillmentType != "delivery" {
+		return 0, nil
+	}
+
+	point := types.LatLng{Lat: *cart.DeliveryLatitude, Lng: *cart.DeliveryLongitude}
+	quote, err := quoteDelivery(q, cart.VendorId, point, pricing.ListedTotal())
+	if errors.Is(err, ErrDeliveryUnavailable) {
+		return 0, nil
+	}
+	return quote.Fee, err
+}
+
+// SetCartDelivery sets where the cart should be delivered and makes it a
+// delivery order. A saved address is looked up by id, and the user's default
+// address is used when no address is given at all. The address has to be
+// inside one of the vendor's zones.
+func (s *service) SetCartDelivery(userID string, destination types.DeliveryDestination) (types.Cart, error) {
+	cart, err := s.GetCart(userID)
+	if err != nil {
+		return cart, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return cart, err
+	}
+	defer tx.Rollback()
+
+	if destination.AddressId != nil || destination.Address == "" {
+		address, err := userAddress(tx, cart.ID, destination.AddressId)
+		if err != nil {
+			return cart, err
+		}
+		destination = addressDestination(*address)
+	}
+
+	point := destination.Point
+	if err := validateCoordinates(&point.Lat, &point.Lng); err != nil {
+		return cart, err
+	}
+
+	pricing, err := priceCart(tx, cart.ID)
+	if err != nil {
+		return cart, err
+	}
+	if _, err := quoteDelivery(tx, cart.VendorId, point, pricing.ListedTotal()); err != nil {
+		return cart, err
+	}
+
+	query, args, err := QB.Update("carts").
+		Set("delivery_address_id", destination.AddressId).
+		Set("delivery_address", destination.Address).
+		Set("delivery_latitude", point.Lat).
+		Set("delivery_longitude", point.Lng).
+		Set("delivery_instructions", destination.Instructions).
+		Set("fulfillment_type", "delivery").
+		Set("table_id", nil).
+		Set("pickup_at", nil).
+		Where("id = ?", cart.ID).
+		ToSql()
+	if err != nil {
+		return cart, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return cart, err
+	}
+
+	if _, err := recalculateCart(tx, cart.ID); err != nil {
+		return cart, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return cart, err
+	}
+
+	return s.GetCart(userID)
+}
+
+// cartDestination is where a cart is delivered at checkout. A saved address is
+// read again so edits made since it was picked are honoured.
+func cartDestination(q sqlx.Queryer, cart types.Cart) (types.DeliveryDestination, error) {
+	if cart.DeliveryAddressId != nil {
+		address, err := userAddress(q, cart.ID, cart.DeliveryAddressId)
+		if err == nil {
+			return addressDestination(*address), nil
+		}
+		if !errors.Is(err, ErrAddressNotFound) {
+			return types.DeliveryDestination{}, err
+		}
+	}
+
+	var address string
+	if cart.DeliveryAddress != nil {
+		address = *cart.DeliveryAddress
+	}
+	return types.DeliveryDestination{
+		Address:      address,
+		Point:        types.LatLng{Lat: *cart.DeliveryLatitude, Lng: *cart.DeliveryLongitude},
+		Instructions: cart.DeliveryInstructions,
+	}, nil
+}
+
+func (s *service) RemoveCartDelivery(userID string) (types.Cart, error) {
+	cart, err := s.GetCart(userID)
+	if err != nil {
+		return cart, err
+	}
+
+	query, args, err := QB.Update("carts").
+		Set("delivery_address_id", nil).
+		Set("delivery_address", nil).
+		Set("delivery_latitude", nil).
+		Set("delivery_longitude", nil).
+		Set("delivery_instructions", nil).
+		Set("fulfillment_type", squirrel.Expr("NULLIF(fulfillment_type, 'delivery')")).
+		Where("id = ?", cart.ID).
+		ToSql()
+	if err != nil {
+		return cart, err
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return cart, err
+	}
+
+	if err := s.RecalculateCart(cart.ID); err != nil {
+		return cart, err
+	}
+
+	return s.GetCart(userID)
+}
+
+func deliveryFeeAdjustment(quote types.DeliveryQuote) types.OrderAdjustment {
+	return types.OrderAdjustment{
+		ID:     uuid.New(),
+		Type:   "delivery_fee",
+		Name:   "Delivery (" + quote.ZoneName + ")",
+		Amount: quote.Fee,
+	}
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+// EventsChannel is the Postgres NOTIFY channel real-time events travel on, so
+// every API instance hears about changes made through any of them.
+const EventsChannel = "realtime_events"
+
+func vendorOrdersTopic(vendorID uuid.UUID) string {
+	return fmt.Sprintf("vendor:%s:orders", vendorID)
+}
+
+func vendorTablesTopic(vendorID uuid.UUID) string {
+	return fmt.Sprintf("vendor:%s:tables", vendorID)
+}
+
+func orderTopic(orderID uuid.UUID) string {
+	return fmt.Sprintf("order:%s", orderID)
+}
+
+// Listen delivers the payload of every notification on channel to handle
+// until ctx is done or the connection drops. It holds a connection of its
+// own, outside the pool.
+func (s *service) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	conn, err := pgx.Connect(ctx, connString())
+	if err != nil {
+		return fmt.Errorf("failed to connect listener: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
+
+// publishEvent queues an event on EventsChannel. Inside a transaction
+// Postgres only delivers it once the transaction commits, and drops it on
+// rollback.
+func publishEvent(q sqlx.Execer, eventType string, topics []string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(types.RealtimeEvent{
+		Type:   eventType,
+		Topics: topics,
+		Data:   encoded,
+		At:     time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := q.Exec("SELECT pg_notify($1, $2)", EventsChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to publish %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// publishOrderEvent tells the order's vendor and whoever follows the order
+// that it changed.
+func publishOrderEvent(q sqlx.Ext, orderID uuid.UUID, eventType string) error {
+	query, args, err := QB.Select("id", "vendor_id", "customer_id", "table_id", "table_session_id", "status",
+		"payment_status", "fulfillment_type", "total_order_cost", "updated_at").
+		From("orders").
+		Where("id = ?", orderID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var order types.OrderEvent
+	if err := sqlx.Get(q, &order, query, args...); err != nil {
+		return fmt.Errorf("failed to load order for event: %w", err)
+	}
+	return publishEvent(q, eventType, []string{vendorOrdersTopic(order.VendorId), orderTopic(order.ID)}, order)
+}
+
+// publishTableEvents sends the current state of each table to its vendor's
+// table topic.
+func publishTableEvents(q sqlx.Ext, tableIDs []uuid.UUID) error {
+	if len(tableIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := QB.Select(tableColumns...).From("tables").Where(squirrel.Eq{"id": tableIDs}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	var tables []types.Table
+	if err := sqlx.Select(q, &tables, query, args...); err != nil {
+		return fmt.Errorf("failed to load tables for event: %w", err)
+	}
+	for _, table := range tables {
+		if err := publishEvent(q, "table.updated", []string{vendorTablesTopic(table.VendorId)}, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
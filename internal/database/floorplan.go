@@ -0,0 +1,285 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidLayout   = errors.New("invalid floor plan")
+	ErrSectionNotFound = errors.New("section not found")
+)
+
+// reservedWindow is how far ahead a booking marks its tables as reserved on
+// the floor plan.
+const reservedWindow = 30 * time.Minute
+
+var sectionColumns = []string{"id", "vendor_id", "name", "sort_order", "created_at", "updated_at"}
+
+func (s *service) ListTableSections(vendorID uuid.UUID) ([]types.TableSection, error) {
+	query, args, err := QB.Select(sectionColumns...).
+		From("table_sections").
+		Where("vendor_id = ?", vendorID).
+		OrderBy("sort_order", "name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []types.TableSection{}
+	if err := s.db.Select(&sections, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list sections: %w", err)
+	}
+	return sections, nil
+}
+
+func (s *service) CreateTableSection(vendorID uuid.UUID, section types.TableSection) (*types.TableSection, error) {
+	section.Name = strings.TrimSpace(section.Name)
+	if section.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidLayout)
+	}
+
+	now := time.Now()
+	query, args, err := QB.Insert("table_sections").
+		Columns("id", "vendor_id", "name", "sort_order", "created_at", "updated_at").
+		Values(uuid.New(), vendorID, section.Name, section.SortOrder, now, now).
+		Suffix("RETURNING " + strings.Join(sectionColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var created types.TableSection
+	if err := s.db.QueryRowx(query, args...).StructScan(&created); err != nil {
+		return nil, sectionWriteError(err, section.Name)
+	}
+	return &created, nil
+}
+
+func (s *service) UpdateTableSection(vendorID uuid.UUID, id string, section types.TableSection) (*types.TableSection, error) {
+	sectionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrSectionNotFound
+	}
+	section.Name = strings.TrimSpace(section.Name)
+	if section.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidLayout)
+	}
+
+	query, args, err := QB.Update("table_sections").
+		Set("name", section.Name).
+		Set("sort_order", section.SortOrder).
+		Set("updated_at", time.Now()).
+		Where("id = ? AND vendor_id = ?", sectionID, vendorID).
+		Suffix("RETURNING " + strings.Join(sectionColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated types.TableSection
+	if err := s.db.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSectionNotFound
+		}
+		return nil, sectionWriteError(err, section.Name)
+	}
+	return &updated, nil
+}
+
+// DeleteTableSection removes a section. Its tables stay on the floor plan,
+// outside any section.
+func (s *service) DeleteTableSection(vendorID uuid.UUID, id string) error {
+	sectionID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrSectionNotFound
+	}
+
+	query, args, err := QB.Delete("table_sections").Where("id = ? AND vendor_id = ?", sectionID, vendorID).ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete section: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrSectionNotFound
+	}
+	return nil
+}
+
+// FloorPlan returns the vendor's sections with their tables and what is
+// happening at each table right now.
+func (s *service) FloorPlan(vendorID uuid.UUID) (*types.FloorPlan, error) {
+	sections, err := s.ListTableSections(vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	utc := now.UTC()
+	inner := QB.Select(tableColumns...).
+		Column(`(
+			SELECT tst.session_id FROM table_session_tables tst
+			WHERE tst.table_id = tables.id AND tst.left_at IS NULL
+		) AS table_session_id`).
+		Column(`(
+			SELECT rt.reservation_id FROM reservation_tables rt
+			WHERE rt.table_id = tables.id AND rt.is_active AND rt.during && tsrange(?, ?)
+			ORDER BY lower(rt.during) LIMIT 1
+		) AS reservation_id`, utc, utc.Add(reservedWindow)).
+		From("tables").
+		Where("vendor_id = ?", vendorID)
+
+	query, args, err := QB.Select("*", `CASE
+			WHEN table_session_id IS NOT NULL THEN 'occupied'
+			WHEN is_available IS FALSE THEN 'unavailable'
+			WHEN reservation_id IS NOT NULL THEN 'reserved'
+			ELSE 'available'
+		END AS status`).
+		FromSelect(inner, "floor").
+		OrderBy("name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []types.FloorPlanTable
+	if err := s.db.Select(&tables, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load floor plan: %w", err)
+	}
+
+	plan := &types.FloorPlan{
+		VendorId:    vendorID,
+		GeneratedAt: now,
+		Sections:    make([]types.FloorPlanSection, len(sections)),
+		Unassigned:  []types.FloorPlanTable{},
+	}
+	index := make(map[uuid.UUID]int, len(sections))
+	for i, section := range sections {
+		plan.Sections[i] = types.FloorPlanSection{TableSection: section, Tables: []types.FloorPlanTable{}}
+		index[section.ID] = i
+	}
+	for _, table := range tables {
+		if table.SectionId != nil {
+			if i, ok := index[*table.SectionId]; ok {
+				plan.Sections[i].Tables = append(plan.Sections[i].Tables, table)
+				continue
+			}
+		}
+		plan.Unassigned = append(plan.Unassigned, table)
+	}
+	return plan, nil
+}
+
+// UpdateFloorPlan moves several tables at once. Either every layout is
+// applied or, if any of them is invalid, none is.
+func (s *service) UpdateFloorPlan(vendorID uuid.UUID, layouts []types.TableLayout) (*types.FloorPlan, error) {
+	if len(layouts) == 0 {
+		return nil, fmt.Errorf("%w: tables are required", ErrInvalidLayout)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sections, err := vendorSectionIDs(tx, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool, len(layouts))
+	for _, layout := range layouts {
+		if layout.TableId == uuid.Nil {
+			return nil, fmt.Errorf("%w: table_id is required", ErrInvalidLayout)
+		}
+		if seen[layout.TableId] {
+			return nil, fmt.Errorf("%w: table %s is listed more than once", ErrInvalidLayout, layout.TableId)
+		}
+		seen[layout.TableId] = true
+
+		if layout.SectionId != nil && !sections[*layout.SectionId] {
+			return nil, fmt.Errorf("%w: section %s does not belong to this vendor", ErrInvalidLayout, *layout.SectionId)
+		}
+		if layout.Shape == "" {
+			layout.Shape = "rectangle"
+		}
+		if !tableShapes[layout.Shape] {
+			return nil, fmt.Errorf("%w: shape must be rectangle, square or round", ErrInvalidLayout)
+		}
+		if layout.Rotation < 0 || layout.Rotation >= 360 {
+			return nil, fmt.Errorf("%w: rotation must be between 0 and 359 degrees", ErrInvalidLayout)
+		}
+
+		query, args, err := QB.Update("tables").
+			Set("section_id", layout.SectionId).
+			Set("pos_x", layout.PosX).
+			Set("pos_y", layout.PosY).
+			Set("shape", layout.Shape).
+			Set("rotation", layout.Rotation).
+			Where("id = ? AND vendor_id = ?", layout.TableId, vendorID).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		result, err := tx.Exec(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update table layout: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return nil, err
+		} else if rows == 0 {
+			return nil, fmt.Errorf("%w: table %s is not on this vendor's floor", ErrInvalidLayout, layout.TableId)
+		}
+	}
+
+	tableIDs := make([]uuid.UUID, 0, len(layouts))
+	for _, layout := range layouts {
+		tableIDs = append(tableIDs, layout.TableId)
+	}
+	if err := publishTableEvents(tx, tableIDs); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.FloorPlan(vendorID)
+}
+
+// vendorSectionIDs returns the set of sections a vendor has.
+func vendorSectionIDs(q sqlx.Queryer, vendorID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query, args, err := QB.Select("id").From("table_sections").Where("vendor_id = ?", vendorID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	if err := sqlx.Select(q, &ids, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch sections: %w", err)
+	}
+
+	sections := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		sections[id] = true
+	}
+	return sections, nil
+}
+
+func sectionWriteError(err error, name string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: a section named %q already exists", ErrInvalidLayout, name)
+	}
+	return fmt.Errorf("failed to save section: %w", err)
+}
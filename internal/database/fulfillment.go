@@ -0,0 +1,185 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+var ErrInvalidFulfillment = errors.New("invalid fulfillment")
+
+var fulfillmentTypes = map[string]bool{"dine_in": true, "pickup": true, "delivery": true}
+
+// SetCartFulfillment chooses how the cart's order will be fulfilled. The
+// delivery address itself is set through SetCartDelivery.
+func (s *service) SetCartFulfillment(userID string, fulfillment types.Fulfillment) (types.Cart, error) {
+	cart, err := s.GetCart(userID)
+	if err != nil {
+		return cart, err
+	}
+
+	if err := validateFulfillment(s.db, cart.VendorId, &fulfillment, time.Now()); err != nil {
+		return cart, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return cart, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Update("carts").
+		Set("fulfillment_type", fulfillment.Type).
+		Set("table_id", fulfillment.TableId).
+		Set("pickup_at", fulfillment.PickupAt).
+		Set("updated_at", time.Now()).
+		Where("id = ?", cart.ID).
+		ToSql()
+	if err != nil {
+		return cart, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return cart, err
+	}
+
+	// The delivery fee only applies to delivery orders
+	if _, err := recalculateCart(tx, cart.ID); err != nil {
+		return cart, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return cart, err
+	}
+
+	return s.GetCart(userID)
+}
+
+// resolveFulfillment works out the fulfillment for a checkout. A table given
+// at checkout wins over the cart's; carts that never picked a type are dine-in
+// when there is a table, delivery when they have an address, pickup otherwise.
+func resolveFulfillment(q sqlx.Queryer, cart types.Cart, checkout types.Checkout, now time.Time) (types.Fulfillment, error) {
+	fulfillment := types.Fulfillment{TableId: cart.TableId, PickupAt: cart.PickupAt}
+	if checkout.TableId != nil {
+		fulfillment.TableId = checkout.TableId
+	}
+
+	switch {
+	case cart.FulfillmentType != nil:
+		fulfillment.Type = *cart.FulfillmentType
+	case fulfillment.TableId != nil:
+		fulfillment.Type = "dine_in"
+	case cart.DeliveryLatitude != nil:
+		fulfillment.Type = "delivery"
+	default:
+		fulfillment.Type = "pickup"
+	}
+
+	if fulfillment.Type == "delivery" && cart.DeliveryLatitude == nil {
+		return fulfillment, fmt.Errorf("%w: delivery orders need a delivery address", ErrInvalidFulfillment)
+	}
+
+	if err := validateFulfillment(q, cart.VendorId, &fulfillment, now); err != nil {
+		return fulfillment, err
+	}
+	return fulfillment, nil
+}
+
+// validateFulfillment checks the fulfillment against the vendor's settings
+// and clears the fields that don't apply to its type. A pickup time that is
+// left out means as soon as possible.
+func validateFulfillment(q sqlx.Queryer, vendorID uuid.UUID, fulfillment *types.Fulfillment, now time.Time) error {
+	if !fulfillmentTypes[fulfillment.Type] {
+		return fmt.Errorf("%w: fulfillment_type must be dine_in, pickup or delivery", ErrInvalidFulfillment)
+	}
+
+	var vendor types.Vendor
+	query, args, err := QB.Select("id", "dine_in_enabled", "pickup_enabled", "delivery_enabled", "pickup_lead_minutes").
+		From("vendors").
+		Where("id = ?", vendorID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Get(q, &vendor, query, args...); err != nil {
+		return err
+	}
+
+	if fulfillment.Type != "dine_in" && fulfillment.TableId != nil {
+		return fmt.Errorf("%w: a table can only be set for dine-in orders", ErrInvalidFulfillment)
+	}
+	if fulfillment.Type != "pickup" {
+		fulfillment.PickupAt = nil
+	}
+
+	switch fulfillment.Type {
+	case "dine_in":
+		if !*vendor.DineInEnabled {
+			return fmt.Errorf("%w: vendor does not offer dine-in", ErrInvalidFulfillment)
+		}
+		if fulfillment.TableId == nil {
+			return fmt.Errorf("%w: dine-in orders need a table", ErrInvalidFulfillment)
+		}
+
+		var tableVendorID uuid.UUID
+		query, args, err := QB.Select("vendor_id").From("tables").Where("id = ?", *fulfillment.TableId).ToSql()
+		if err != nil {
+			return err
+		}
+		if err := sqlx.Get(q, &tableVendorID, query, args...); err != nil || tableVendorID != vendorID {
+			return fmt.Errorf("%w: table does not belong to this vendor", ErrInvalidFulfillment)
+		}
+
+	case "pickup":
+		if !*vendor.PickupEnabled {
+			return fmt.Errorf("%w: vendor does not offer pickup", ErrInvalidFulfillment)
+		}
+
+		if fulfillment.PickupAt == nil {
+			return nil
+		}
+		earliest := now.Add(time.Duration(*vendor.PickupLeadMinutes) * time.Minute)
+		if fulfillment.PickupAt.Before(earliest) {
+			return fmt.Errorf("%w: pickup needs at least %d minutes notice", ErrInvalidFulfillment, *vendor.PickupLeadMinutes)
+		}
+
+		hours, err := loadVendorHours(q, []uuid.UUID{vendorID})
+		if err != nil {
+			return err
+		}
+		if h := hours[vendorID]; h != nil {
+			if open, _ := h.status(*fulfillment.PickupAt); !open {
+				return fmt.Errorf("%w: vendor is closed at the requested pickup time", ErrInvalidFulfillment)
+			}
+		}
+
+	case "delivery":
+		if !*vendor.DeliveryEnabled {
+			return fmt.Errorf("%w: vendor does not offer delivery", ErrInvalidFulfillment)
+		}
+	}
+
+	return nil
+}
+
+// pickupTime is when a pickup order should be ready: the requested time, or
+// the vendor's lead time from now for as-soon-as-possible orders.
+func pickupTime(q sqlx.Queryer, vendorID uuid.UUID, requested *time.Time, now time.Time) (*time.Time, error) {
+	if requested != nil {
+		return requested, nil
+	}
+
+	var leadMinutes int
+	query, args, err := QB.Select("pickup_lead_minutes").From("vendors").Where("id = ?", vendorID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Get(q, &leadMinutes, query, args...); err != nil {
+		return nil, err
+	}
+
+	ready := now.Add(time.Duration(leadMinutes) * time.Minute)
+	return &ready, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+)
+
+var ErrInvalidLocation = errors.New("invalid location")
+
+const (
+	earthRadiusKm = 6371.0
+	// kmPerDegree is the length of one degree of latitude, used to turn a
+	// radius into a bounding box.
+	kmPerDegree         = 111.045
+	defaultNearbyRadius = 5.0
+	maxNearbyRadius     = 100.0
+)
+
+// geoQuery is a point and radius read from the query string. Its values are
+// validated floats, so they're safe to format into raw SQL filters.
+type geoQuery struct {
+	lat, lng, radius float64
+}
+
+// parseGeoQuery reads lat, lng and radius (in km). It returns nil when no
+// location was given; a partial location is an error.
+func parseGeoQuery(queryParams url.Values) (*geoQuery, error) {
+	latParam, lngParam, radiusParam := queryParams.Get("lat"), queryParams.Get("lng"), queryParams.Get("radius")
+	if latParam == "" && lngParam == "" && radiusParam == "" {
+		return nil, nil
+	}
+	if latParam == "" || lngParam == "" {
+		return nil, fmt.Errorf("%w: lat and lng are both required", ErrInvalidLocation)
+	}
+
+	lat, err := strconv.ParseFloat(latParam, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: lat must be a number", ErrInvalidLocation)
+	}
+	lng, err := strconv.ParseFloat(lngParam, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: lng must be a number", ErrInvalidLocation)
+	}
+	if err := validateCoordinates(&lat, &lng); err != nil {
+		return nil, err
+	}
+
+	geo := &geoQuery{lat: lat, lng: lng, radius: defaultNearbyRadius}
+	if radiusParam != "" {
+		geo.radius, err = strconv.ParseFloat(radiusParam, 64)
+		if err != nil || geo.radius <= 0 || geo.radius > maxNearbyRadius {
+			return nil, fmt.Errorf("%w: radius must be between 0 and %g km", ErrInvalidLocation, maxNearbyRadius)
+		}
+	}
+	return geo, nil
+}
+
+// distance is the great-circle distance in km from the point to a vendor,
+// using the Haversine formula.
+func (g *geoQuery) distance() string {
+	return fmt.Sprintf(
+		"(%[1]g * 2 * asin(sqrt("+
+			"power(sin(radians(vendors.latitude - %[2]f) / 2), 2) + "+
+			"cos(radians(%[2]f)) * cos(radians(vendors.latitude)) * "+
+			"power(sin(radians(vendors.longitude - %[3]f) / 2), 2))))",
+		earthRadiusKm, g.lat, g.lng)
+}
+
+// filters limits vendors to the radius. The bounding box is checked first so
+// the coordinates index does most of the work before distances are computed.
+func (g *geoQuery) filters() []string {
+	latDelta := g.radius / kmPerDegree
+	lngDelta := 180.0
+	if cos := math.Cos(g.lat * math.Pi / 180); cos > 0.01 {
+		lngDelta = math.Min(latDelta/cos, 180)
+	}
+
+	filters := []string{
+		fmt.Sprintf("vendors.latitude BETWEEN %f AND %f", g.lat-latDelta, g.lat+latDelta),
+		fmt.Sprintf("%s <= %f", g.distance(), g.radius),
+	}
+	// Near the antimeridian the box wraps around, so longitude is left to the
+	// distance check.
+	if g.lng-lngDelta >= -180 && g.lng+lngDelta <= 180 {
+		filters = append(filters, fmt.Sprintf("vendors.longitude BETWEEN %f AND %f", g.lng-lngDelta, g.lng+lngDelta))
+	}
+	return filters
+}
+
+func validateCoordinates(lat, lng *float64) error {
+	if (lat == nil) != (lng == nil) {
+		return fmt.Errorf("%w: latitude and longitude must be set together", ErrInvalidLocation)
+	}
+	if lat == nil {
+		return nil
+	}
+	if *lat < -90 || *lat > 90 {
+		return fmt.Errorf("%w: latitude must be between -90 and 90", ErrInvalidLocation)
+	}
+	if *lng < -180 || *lng > 180 {
+		return fmt.Errorf("%w: longitude must be between -180 and 180", ErrInvalidLocation)
+	}
+	return nil
+}
+
+// NearbyVendors lists vendors within a radius of lat/lng, closest first.
+// The radius defaults to 5 km.
+func (s *service) NearbyVendors(queryParams url.Values) ([]types.Vendor, *types.Meta, error) {
+	if queryParams.Get("lat") == "" || queryParams.Get("lng") == "" {
+		return nil, nil, fmt.Errorf("%w: lat and lng are required", ErrInvalidLocation)
+	}
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "distance_km")
+	}
+	return s.ListVendors(queryParams)
+}
+
+// haversineKm is the great-circle distance between two points, matching the
+// SQL used for vendor searches.
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Pow(math.Sin(dLng/2), 2)
+	return earthRadiusKm * 2 * math.Asin(math.Sqrt(a))
+}
+
+func roundDistance(km float64) float64 {
+	return math.Round(km*1000) / 1000
+}
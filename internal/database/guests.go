@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+// guestEmailDomain is a reserved domain, so guest addresses can never clash
+// with a real sign-up.
+const guestEmailDomain = "guest.invalid"
+
+// RotateTableToken issues a new QR token version for the table. Codes printed
+// with the old version stop working, and so do the guests who scanned them.
+func (s *service) RotateTableToken(id string) (types.Table, error) {
+	var table types.Table
+	query, args, err := QB.Update("tables").
+		Set("qr_token_version", squirrel.Expr("qr_token_version + 1")).
+		Set("qr_token_rotated_at", time.Now()).
+		Where("id = ?", id).
+		Suffix("RETURNING " + strings.Join(tableColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return table, err
+	}
+	err = s.db.QueryRowx(query, args...).StructScan(&table)
+	return table, err
+}
+
+// CreateGuestUser signs a diner in from a scanned table token. The guest is a
+// customer tied to that table and token version.
+func (s *service) CreateGuestUser(tableID uuid.UUID, version int) (*types.User, *types.Table, error) {
+	table, err := s.GetTableByID(tableID.String())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, helpers.ErrInvalidTableToken
+		}
+		return nil, nil, err
+	}
+	if table.QrTokenVersion != version {
+		return nil, nil, helpers.ErrInvalidTableToken
+	}
+
+	id := uuid.New()
+	user := types.User{
+		ID:                id,
+		Name:              "Guest",
+		Email:             fmt.Sprintf("%s@%s", id, guestEmailDomain),
+		IsGuest:           true,
+		GuestTableId:      &table.ID,
+		GuestTokenVersion: &version,
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// Guests have no password, so they can never sign in through /auth/login
+	query, args, err := QB.Insert("users").
+		Columns("id", "name", "phone", "email", "password", "is_guest", "guest_table_id", "guest_token_version").
+		Values(user.ID, user.Name, "", user.Email, "", user.IsGuest, user.GuestTableId, user.GuestTokenVersion).
+		Suffix("RETURNING created_at, updated_at").
+		ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.QueryRowx(query, args...).Scan(&user.Created_at, &user.Updated_at); err != nil {
+		return nil, nil, fmt.Errorf("error creating guest: %w", err)
+	}
+
+	query, args, err = QB.Insert("user_roles").Columns("user_id", "role_id").Values(user.ID, 3).ToSql()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, nil, fmt.Errorf("error granting role: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	user.Roles = []int{3}
+	return &user, &table, nil
+}
+
+// GuestSessionActive reports whether a guest's table still exists and still
+// accepts the token they scanned. Registered users are always active.
+func (s *service) GuestSessionActive(user types.User) (bool, error) {
+	if !user.IsGuest {
+		return true, nil
+	}
+	if user.GuestTableId == nil || user.GuestTokenVersion == nil {
+		return false, nil
+	}
+
+	var version int
+	query, args, err := QB.Select("qr_token_version").From("tables").Where("id = ?", *user.GuestTableId).ToSql()
+	if err != nil {
+		return false, err
+	}
+	if err := s.db.Get(&version, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return version == *user.GuestTokenVersion, nil
+}
@@ -0,0 +1,427 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"sort"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// nextOpeningHorizon is how far ahead next_opening is looked for.
+const nextOpeningHorizon = 14
+
+var openingHoursColumns = []string{
+	"id", "vendor_id", "day_of_week", "to_char(open_time, 'HH24:MI') AS open_time", "to_char(close_time, 'HH24:MI') AS close_time",
+}
+
+var hoursOverrideColumns = []string{
+	"id", "vendor_id", "to_char(starts_on, 'YYYY-MM-DD') AS starts_on", "to_char(ends_on, 'YYYY-MM-DD') AS ends_on",
+	"to_char(open_time, 'HH24:MI') AS open_time", "to_char(close_time, 'HH24:MI') AS close_time", "note", "created_at",
+}
+
+func (s *service) ListOpeningHours(vendorID string) ([]types.OpeningHours, error) {
+	hours := []types.OpeningHours{}
+	query, args, err := QB.Select(openingHoursColumns...).
+		From("vendor_opening_hours").
+		Where("vendor_id = ?", vendorID).
+		OrderBy("day_of_week", "open_time").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&hours, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch opening hours: %w", err)
+	}
+	return hours, nil
+}
+
+// SetOpeningHours replaces a vendor's weekly hours. An empty list leaves the
+// vendor open around the clock.
+func (s *service) SetOpeningHours(vendorID string, hours []types.OpeningHours) ([]types.OpeningHours, error) {
+	vendor, err := s.GetVendorByID(vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range hours {
+		if h.DayOfWeek < 0 || h.DayOfWeek > 6 {
+			return nil, errors.New("day_of_week must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		open, err := parseClock(h.OpenTime)
+		if err != nil {
+			return nil, errors.New("open_time must be formatted as HH:MM")
+		}
+		closing, err := parseClock(h.CloseTime)
+		if err != nil {
+			return nil, errors.New("close_time must be formatted as HH:MM")
+		}
+		if open == closing {
+			return nil, errors.New("open_time and close_time must differ")
+		}
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Delete("vendor_opening_hours").Where("vendor_id = ?", vendor.ID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, err
+	}
+
+	if len(hours) > 0 {
+		insert := QB.Insert("vendor_opening_hours").Columns("vendor_id", "day_of_week", "open_time", "close_time")
+		for _, h := range hours {
+			insert = insert.Values(vendor.ID, h.DayOfWeek, h.OpenTime, h.CloseTime)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error saving opening hours: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.ListOpeningHours(vendor.ID.String())
+}
+
+// ListHoursOverrides returns the overrides that haven't ended yet.
+func (s *service) ListHoursOverrides(vendorID string) ([]types.HoursOverride, error) {
+	overrides := []types.HoursOverride{}
+	query, args, err := QB.Select(hoursOverrideColumns...).
+		From("vendor_hours_overrides").
+		Where("vendor_id = ? AND ends_on >= CURRENT_DATE - 1", vendorID).
+		OrderBy("starts_on").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&overrides, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch hours overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+func (s *service) CreateHoursOverride(vendorID string, override types.HoursOverride) (*types.HoursOverride, error) {
+	vendor, err := s.GetVendorByID(vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if override.EndsOn == "" {
+		override.EndsOn = override.StartsOn
+	}
+	startsOn, err := time.Parse(dateLayout, override.StartsOn)
+	if err != nil {
+		return nil, errors.New("starts_on must be formatted as YYYY-MM-DD")
+	}
+	endsOn, err := time.Parse(dateLayout, override.EndsOn)
+	if err != nil {
+		return nil, errors.New("ends_on must be formatted as YYYY-MM-DD")
+	}
+	if endsOn.Before(startsOn) {
+		return nil, errors.New("ends_on cannot be before starts_on")
+	}
+	if (override.OpenTime == nil) != (override.CloseTime == nil) {
+		return nil, errors.New("open_time and close_time must be given together")
+	}
+	if override.OpenTime != nil {
+		open, err := parseClock(*override.OpenTime)
+		if err != nil {
+			return nil, errors.New("open_time must be formatted as HH:MM")
+		}
+		closing, err := parseClock(*override.CloseTime)
+		if err != nil {
+			return nil, errors.New("close_time must be formatted as HH:MM")
+		}
+		if open == closing {
+			return nil, errors.New("open_time and close_time must differ")
+		}
+	}
+
+	query, args, err := QB.Insert("vendor_hours_overrides").
+		Columns("vendor_id", "starts_on", "ends_on", "open_time", "close_time", "note").
+		Values(vendor.ID, override.StartsOn, override.EndsOn, override.OpenTime, override.CloseTime, override.Note).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+	var id uuid.UUID
+	if err := s.db.Get(&id, query, args...); err != nil {
+		return nil, fmt.Errorf("error inserting hours override: %w", err)
+	}
+
+	var created types.HoursOverride
+	query, args, err = QB.Select(hoursOverrideColumns...).From("vendor_hours_overrides").Where("id = ?", id).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Get(&created, query, args...); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s *service) DeleteHoursOverride(vendorID, id string) error {
+	query, args, err := QB.Delete("vendor_hours_overrides").Where("id = ? AND vendor_id = ?", id, vendorID).ToSql()
+	if err != nil {
+		return fmt.Errorf("error building delete query: %w", err)
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("error deleting hours override: %w", err)
+	}
+	return nil
+}
+
+// vendorHours holds everything needed to tell whether a vendor is open.
+type vendorHours struct {
+	loc       *time.Location
+	weekly    []types.OpeningHours
+	overrides []types.HoursOverride
+}
+
+type openWindow struct {
+	start, end time.Time
+}
+
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// window builds the opening window for a local date. Times are set on the
+// calendar rather than added as durations so DST changes are respected.
+func (h vendorHours) window(day time.Time, open, closing string) (openWindow, bool) {
+	o, err := parseClock(open)
+	if err != nil {
+		return openWindow{}, false
+	}
+	c, err := parseClock(closing)
+	if err != nil {
+		return openWindow{}, false
+	}
+
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, int(o.Hours()), int(o.Minutes())%60, 0, 0, h.loc)
+	end := time.Date(y, m, d, int(c.Hours()), int(c.Minutes())%60, 0, 0, h.loc)
+	if c <= o {
+		end = end.AddDate(0, 0, 1)
+	}
+	return openWindow{start: start, end: end}, true
+}
+
+// windowsOn returns the opening windows that start on the given local date.
+func (h vendorHours) windowsOn(day time.Time) []openWindow {
+	date := day.Format(dateLayout)
+	for _, override := range h.overrides {
+		if date < override.StartsOn || date > override.EndsOn {
+			continue
+		}
+		if override.OpenTime == nil || override.CloseTime == nil {
+			return nil
+		}
+		if w, ok := h.window(day, *override.OpenTime, *override.CloseTime); ok {
+			return []openWindow{w}
+		}
+		return nil
+	}
+
+	var windows []openWindow
+	for _, weekly := range h.weekly {
+		if weekly.DayOfWeek != int(day.Weekday()) {
+			continue
+		}
+		if w, ok := h.window(day, weekly.OpenTime, weekly.CloseTime); ok {
+			windows = append(windows, w)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].start.Before(windows[j].start) })
+	return windows
+}
+
+// dayWindows is windowsOn for a vendor that may not have set any hours, in
+// which case it is open all day.
+func (h vendorHours) dayWindows(day time.Time) []openWindow {
+	if len(h.weekly) == 0 && len(h.overrides) == 0 {
+		y, m, d := day.Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, h.loc)
+		return []openWindow{{start: start, end: start.AddDate(0, 0, 1)}}
+	}
+	return h.windowsOn(day)
+}
+
+// covers reports whether the vendor is open for the whole of [start, end).
+func (h vendorHours) covers(start, end time.Time) bool {
+	if len(h.weekly) == 0 && len(h.overrides) == 0 {
+		return true
+	}
+
+	local := start.In(h.loc)
+	y, m, d := local.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, h.loc)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		for _, w := range h.windowsOn(day) {
+			if !start.Before(w.start) && !end.After(w.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// status reports whether the vendor is open at t and, when it isn't, when it
+// next opens.
+func (h vendorHours) status(t time.Time) (bool, *time.Time) {
+	if len(h.weekly) == 0 && len(h.overrides) == 0 {
+		return true, nil
+	}
+
+	local := t.In(h.loc)
+	y, m, d := local.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, h.loc)
+
+	// Yesterday's late window may still be running
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		for _, w := range h.windowsOn(day) {
+			if !t.Before(w.start) && t.Before(w.end) {
+				return true, nil
+			}
+		}
+	}
+
+	for i := 0; i <= nextOpeningHorizon; i++ {
+		for _, w := range h.windowsOn(today.AddDate(0, 0, i)) {
+			if w.start.After(t) {
+				next := w.start
+				return false, &next
+			}
+		}
+	}
+	return false, nil
+}
+
+func loadVendorHours(q sqlx.Queryer, vendorIDs []uuid.UUID) (map[uuid.UUID]*vendorHours, error) {
+	hours := make(map[uuid.UUID]*vendorHours, len(vendorIDs))
+	if len(vendorIDs) == 0 {
+		return hours, nil
+	}
+
+	var timezones []struct {
+		ID       uuid.UUID `db:"id"`
+		Timezone string    `db:"timezone"`
+	}
+	query, args, err := QB.Select("id", "timezone").From("vendors").Where(squirrel.Eq{"id": vendorIDs}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &timezones, query, args...); err != nil {
+		return nil, err
+	}
+	for _, tz := range timezones {
+		loc, err := time.LoadLocation(tz.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		hours[tz.ID] = &vendorHours{loc: loc}
+	}
+
+	var weekly []types.OpeningHours
+	query, args, err = QB.Select(openingHoursColumns...).
+		From("vendor_opening_hours").
+		Where(squirrel.Eq{"vendor_id": vendorIDs}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &weekly, query, args...); err != nil {
+		return nil, err
+	}
+	for _, w := range weekly {
+		if h := hours[w.VendorId]; h != nil {
+			h.weekly = append(h.weekly, w)
+		}
+	}
+
+	var overrides []types.HoursOverride
+	query, args, err = QB.Select(hoursOverrideColumns...).
+		From("vendor_hours_overrides").
+		Where(squirrel.Eq{"vendor_id": vendorIDs}).
+		Where("ends_on >= CURRENT_DATE - 1").
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &overrides, query, args...); err != nil {
+		return nil, err
+	}
+	for _, o := range overrides {
+		if h := hours[o.VendorId]; h != nil {
+			h.overrides = append(h.overrides, o)
+		}
+	}
+
+	return hours, nil
+}
+
+// attachOpenStatus fills in is_open_now and next_opening on the vendors.
+func attachOpenStatus(q sqlx.Queryer, vendors []types.Vendor) error {
+	ids := make([]uuid.UUID, len(vendors))
+	for i, vendor := range vendors {
+		ids[i] = vendor.ID
+	}
+
+	hours, err := loadVendorHours(q, ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range vendors {
+		if h := hours[vendors[i].ID]; h != nil {
+			vendors[i].IsOpenNow, vendors[i].NextOpening = h.status(now)
+		}
+	}
+	return nil
+}
+
+// checkVendorOpen rejects checkouts at vendors that are closed right now.
+func checkVendorOpen(q sqlx.Queryer, vendorID uuid.UUID) error {
+	hours, err := loadVendorHours(q, []uuid.UUID{vendorID})
+	if err != nil {
+		return err
+	}
+	h := hours[vendorID]
+	if h == nil {
+		return nil
+	}
+
+	open, next := h.status(time.Now())
+	if open {
+		return nil
+	}
+	if next != nil {
+		return fmt.Errorf("%w: vendor is closed, next opening at %s", ErrCheckoutRejected, next.Format(time.RFC3339))
+	}
+	return fmt.Errorf("%w: vendor is closed", ErrCheckoutRejected)
+}
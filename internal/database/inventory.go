@@ -0,0 +1,444 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+var ErrInvalidAdjustment = errors.New("invalid stock adjustment")
+
+// InsufficientStockError lists every line of a checkout that couldn't be
+// filled from stock.
+type InsufficientStockError struct {
+	Shortages []types.StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, len(e.Shortages))
+	for i, shortage := range e.Shortages {
+		parts[i] = fmt.Sprintf("%s: %d requested, %d available", shortage.Name, shortage.Requested, shortage.Available)
+	}
+	return fmt.Sprintf("%s: %s", ErrInsufficientStock, strings.Join(parts, "; "))
+}
+
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
+
+var inventoryAdjustmentColumns = []string{
+	"id", "vendor_id", "item_id", "variant_id", "reason", "quantity_change", "quantity_after", "order_id", "user_id", "note", "created_at",
+}
+
+// stockTarget is the row a line's stock is drawn from: the variant when it
+// tracks its own level, otherwise the item.
+type stockTarget struct {
+	Table     string
+	ID        uuid.UUID
+	ItemId    uuid.UUID
+	VariantId *uuid.UUID
+	Name      string
+	Quantity  int
+}
+
+type stockTracking struct {
+	VariantId      uuid.UUID `db:"variant_id"`
+	ItemId         uuid.UUID `db:"item_id"`
+	ItemName       string    `db:"item_name"`
+	VariantName    string    `db:"variant_name"`
+	VariantTracked bool      `db:"variant_tracked"`
+	ItemTracked    bool      `db:"item_tracked"`
+}
+
+// reserveStock takes the ordered quantities off the shelves. Each decrement
+// is conditional on enough stock being left, so concurrent checkouts can't
+// oversell; if any line falls short the whole checkout is rejected.
+func reserveStock(tx *sqlx.Tx, order types.Order, lines []cartLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	variantIDs := make([]uuid.UUID, len(lines))
+	for i, line := range lines {
+		variantIDs[i] = line.VariantId
+	}
+
+	var tracking []stockTracking
+	query, args, err := QB.Select(
+		"item_variants.id AS variant_id",
+		"items.id AS item_id",
+		"items.name AS item_name",
+		"item_variants.name AS variant_name",
+		"item_variants.stock_quantity IS NOT NULL AS variant_tracked",
+		"items.stock_quantity IS NOT NULL AS item_tracked",
+	).
+		From("item_variants").
+		Join("items ON items.id = item_variants.item_id").
+		Where(squirrel.Eq{"item_variants.id": variantIDs}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := tx.Select(&tracking, query, args...); err != nil {
+		return err
+	}
+
+	byVariant := make(map[uuid.UUID]stockTracking, len(tracking))
+	for _, t := range tracking {
+		byVariant[t.VariantId] = t
+	}
+
+	targets := make(map[uuid.UUID]*stockTarget)
+	for _, line := range lines {
+		t, ok := byVariant[line.VariantId]
+		if !ok {
+			continue
+		}
+		switch {
+		case t.VariantTracked:
+			variantID := t.VariantId
+			if targets[t.VariantId] == nil {
+				targets[t.VariantId] = &stockTarget{Table: "item_variants", ID: t.VariantId, ItemId: t.ItemId,
+					VariantId: &variantID, Name: t.ItemName + " (" + t.VariantName + ")"}
+			}
+			targets[t.VariantId].Quantity += line.Quantity
+		case t.ItemTracked:
+			if targets[t.ItemId] == nil {
+				targets[t.ItemId] = &stockTarget{Table: "items", ID: t.ItemId, ItemId: t.ItemId, Name: t.ItemName}
+			}
+			targets[t.ItemId].Quantity += line.Quantity
+		}
+	}
+
+	// Update rows in a fixed order so concurrent checkouts don't deadlock
+	ordered := make([]*stockTarget, 0, len(targets))
+	for _, target := range targets {
+		ordered = append(ordered, target)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		return bytes.Compare(ordered[i].ID[:], ordered[j].ID[:]) < 0
+	})
+
+	var shortages []types.StockShortage
+	for _, target := range ordered {
+		var after int
+		var threshold *int
+		query, args, err := QB.Update(target.Table).
+			Set("stock_quantity", squirrel.Expr("stock_quantity - ?", target.Quantity)).
+			Where("id = ? AND stock_quantity >= ?", target.ID, target.Quantity).
+			Suffix("RETURNING stock_quantity, low_stock_threshold").
+			ToSql()
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRowx(query, args...).Scan(&after, &threshold)
+		if errors.Is(err, sql.ErrNoRows) {
+			available, err := currentStock(tx, target.Table, target.ID)
+			if err != nil {
+				return err
+			}
+			shortages = append(shortages, types.StockShortage{
+				ItemId:    target.ItemId,
+				VariantId: target.VariantId,
+				Name:      target.Name,
+				Requested: target.Quantity,
+				Available: available,
+			})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		orderID := order.ID
+		if err := insertInventoryAdjustment(tx, types.InventoryAdjustment{
+			VendorId:       order.VendorId,
+			ItemId:         target.ItemId,
+			VariantId:      target.VariantId,
+			Reason:         "sale",
+			QuantityChange: -target.Quantity,
+			QuantityAfter:  after,
+			OrderId:        &orderID,
+		}); err != nil {
+			return err
+		}
+
+		if threshold != nil && after <= *threshold {
+			logger.Log.WithField("vendor_id", order.VendorId).
+				WithField("item_id", target.ItemId).
+				WithField("stock_quantity", after).
+				Warn("Item is running low on stock")
+		}
+	}
+
+	if len(shortages) > 0 {
+		return &InsufficientStockError{Shortages: shortages}
+	}
+	return nil
+}
+
+// restockOrder puts back whatever an order still holds from stock. It works
+// off the adjustments log, so running it twice doesn't restock twice.
+func restockOrder(tx *sqlx.Tx, orderID uuid.UUID) error {
+	var held []struct {
+		VendorId  uuid.UUID  `db:"vendor_id"`
+		ItemId    uuid.UUID  `db:"item_id"`
+		VariantId *uuid.UUID `db:"variant_id"`
+		Quantity  int        `db:"quantity"`
+	}
+	query, args, err := QB.Select("vendor_id", "item_id", "variant_id", "-SUM(quantity_change) AS quantity").
+		From("inventory_adjustments").
+		Where("order_id = ?", orderID).
+		GroupBy("vendor_id", "item_id", "variant_id").
+		Having("SUM(quantity_change) < 0").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := tx.Select(&held, query, args...); err != nil {
+		return err
+	}
+
+	for _, h := range held {
+		table, id := "items", h.ItemId
+		if h.VariantId != nil {
+			table, id = "item_variants", *h.VariantId
+		}
+
+		var after int
+		query, args, err := QB.Update(table).
+			Set("stock_quantity", squirrel.Expr("stock_quantity + ?", h.Quantity)).
+			Where("id = ? AND stock_quantity IS NOT NULL", id).
+			Suffix("RETURNING stock_quantity").
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if err := tx.Get(&after, query, args...); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// Tracking was switched off since the sale
+				continue
+			}
+			return err
+		}
+
+		if err := insertInventoryAdjustment(tx, types.InventoryAdjustment{
+			VendorId:       h.VendorId,
+			ItemId:         h.ItemId,
+			VariantId:      h.VariantId,
+			Reason:         "restock",
+			QuantityChange: h.Quantity,
+			QuantityAfter:  after,
+			OrderId:        &orderID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func currentStock(q sqlx.Queryer, table string, id uuid.UUID) (int, error) {
+	var stock int
+	query, args, err := QB.Select("COALESCE(stock_quantity, 0)").From(table).Where("id = ?", id).ToSql()
+	if err != nil {
+		return 0, err
+	}
+	err = sqlx.Get(q, &stock, query, args...)
+	return stock, err
+}
+
+func insertInventoryAdjustment(tx *sqlx.Tx, adjustment types.InventoryAdjustment) error {
+	query, args, err := QB.Insert("inventory_adjustments").
+		Columns("vendor_id", "item_id", "variant_id", "reason", "quantity_change", "quantity_after", "order_id", "user_id", "note").
+		Values(adjustment.VendorId, adjustment.ItemId, adjustment.VariantId, adjustment.Reason, adjustment.QuantityChange,
+			adjustment.QuantityAfter, adjustment.OrderId, adjustment.UserId, adjustment.Note).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// AdjustStock records stock received, wasted or counted for an item, or for
+// one of its variants. Receiving or counting stock on an untracked item
+// starts tracking it.
+func (s *service) AdjustStock(vendorID string, request types.StockAdjustmentRequest, userID uuid.UUID) (*types.InventoryAdjustment, error) {
+	switch request.Reason {
+	case "received", "wasted":
+		if request.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalidAdjustment)
+		}
+	case "counted":
+		if request.Quantity < 0 {
+			return nil, fmt.Errorf("%w: count cannot be negative", ErrInvalidAdjustment)
+		}
+	default:
+		return nil, fmt.Errorf("%w: reason must be received, wasted or counted", ErrInvalidAdjustment)
+	}
+
+	item, err := s.GetItemByID(request.ItemId.String())
+	if err != nil || item.VendorId.String() != vendorID {
+		return nil, fmt.Errorf("%w: item not found for this vendor", ErrInvalidAdjustment)
+	}
+
+	table, id := "items", item.ID
+	if request.VariantId != nil {
+		variant, err := s.GetItemVariantByID(request.VariantId.String())
+		if err != nil || variant.ItemId != item.ID {
+			return nil, fmt.Errorf("%w: variant not found for this item", ErrInvalidAdjustment)
+		}
+		table, id = "item_variants", variant.ID
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before *int
+	query, args, err := QB.Select("stock_quantity").From(table).Where("id = ?", id).Suffix("FOR UPDATE").ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Get(&before, query, args...); err != nil {
+		return nil, err
+	}
+
+	current := 0
+	if before != nil {
+		current = *before
+	}
+
+	after := current
+	switch request.Reason {
+	case "received":
+		after = current + request.Quantity
+	case "wasted":
+		if before == nil {
+			return nil, fmt.Errorf("%w: stock is not tracked for this item", ErrInvalidAdjustment)
+		}
+		if request.Quantity > current {
+			return nil, fmt.Errorf("%w: only %d in stock", ErrInvalidAdjustment, current)
+		}
+		after = current - request.Quantity
+	case "counted":
+		after = request.Quantity
+	}
+
+	query, args, err = QB.Update(table).Set("stock_quantity", after).Where("id = ?", id).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, err
+	}
+
+	adjustment := types.InventoryAdjustment{
+		ID:             uuid.New(),
+		VendorId:       item.VendorId,
+		ItemId:         item.ID,
+		VariantId:      request.VariantId,
+		Reason:         request.Reason,
+		QuantityChange: after - current,
+		QuantityAfter:  after,
+		UserId:         &userID,
+		Note:           request.Note,
+		Created_at:     time.Now(),
+	}
+
+	query, args, err = QB.Insert("inventory_adjustments").
+		Columns(inventoryAdjustmentColumns...).
+		Values(adjustment.ID, adjustment.VendorId, adjustment.ItemId, adjustment.VariantId, adjustment.Reason,
+			adjustment.QuantityChange, adjustment.QuantityAfter, adjustment.OrderId, adjustment.UserId, adjustment.Note,
+			adjustment.Created_at).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error recording stock adjustment: %w", err)
+	}
+
+	return &adjustment, tx.Commit()
+}
+
+func (s *service) ListInventoryAdjustments(vendorID string, queryParams url.Values) ([]types.InventoryAdjustment, *types.Meta, error) {
+	var adjustments []types.InventoryAdjustment
+
+	id, err := uuid.Parse(vendorID)
+	if err != nil {
+		return nil, nil, errors.New("invalid vendor id")
+	}
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "-created_at")
+	}
+
+	meta, err := s.BuildQuery(
+		&adjustments,
+		"inventory_adjustments",
+		[]string{},
+		inventoryAdjustmentColumns,
+		[]string{"note"},
+		queryParams,
+		[]string{fmt.Sprintf("vendor_id = '%s'", id)},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list inventory adjustments: %w", err)
+	}
+
+	if adjustments == nil {
+		adjustments = []types.InventoryAdjustment{}
+	}
+
+	return adjustments, meta, nil
+}
+
+// ListLowStock returns the tracked items and variants of a vendor that are
+// at or below their low-stock threshold.
+func (s *service) ListLowStock(vendorID string) ([]types.StockLevel, error) {
+	levels := []types.StockLevel{}
+
+	query, args, err := QB.Select(
+		"items.id AS item_id",
+		"NULL::uuid AS variant_id",
+		"items.name AS item_name",
+		"NULL AS variant_name",
+		"items.stock_quantity",
+		"items.low_stock_threshold",
+	).
+		From("items").
+		Where("items.vendor_id = ?", vendorID).
+		Where("items.stock_quantity IS NOT NULL AND items.stock_quantity <= items.low_stock_threshold").
+		Suffix("UNION ALL "+
+			"SELECT items.id, item_variants.id, items.name, item_variants.name, "+
+			"item_variants.stock_quantity, item_variants.low_stock_threshold "+
+			"FROM item_variants JOIN items ON items.id = item_variants.item_id "+
+			"WHERE items.vendor_id = ? AND item_variants.stock_quantity IS NOT NULL "+
+			"AND item_variants.stock_quantity <= item_variants.low_stock_threshold "+
+			"ORDER BY stock_quantity, item_name", vendorID).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&levels, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch low stock levels: %w", err)
+	}
+
+	return levels, nil
+}
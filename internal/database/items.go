@@ -20,8 +20,12 @@ var itemColumns = []string{
 	"vendor_id",
 	"name",
 	"price",
+	"tax_class_id",
 	"created_at",
 	"updated_at",
+	"stock_quantity",
+	"low_stock_threshold",
+	"is_available",
 	helpers.ImageFormat,
 }
 
@@ -40,13 +44,29 @@ func (s *service) ListItems(query map[string][]string) ([]types.Item, *types.Met
 		"vendor_id",
 		"name",
 		"price",
+		"tax_class_id",
 		"created_at",
 		"updated_at",
+		"stock_quantity",
+		"low_stock_threshold",
+		"is_available",
 		helpers.ImageFormat,
 	}
 
 	searchColumns := []string{"name", "price"}
 
+	var additionalFilters []string
+	if categoryID := urlValues.Get("category_id"); categoryID != "" {
+		filter, err := categoryItemFilter(categoryID)
+		if err != nil {
+			return nil, nil, err
+		}
+		additionalFilters = append(additionalFilters, filter)
+	}
+	if urlValues.Get("available") == "true" {
+		additionalFilters = append(additionalFilters, itemAvailableCondition)
+	}
+
 	meta, err := s.BuildQuery(
 		&items,
 		"items",
@@ -54,7 +74,7 @@ func (s *service) ListItems(query map[string][]string) ([]types.Item, *types.Met
 		columns,
 		searchColumns,
 		urlValues,
-		[]string{},
+		additionalFilters,
 	)
 
 	if err != nil {
@@ -65,6 +85,10 @@ func (s *service) ListItems(query map[string][]string) ([]types.Item, *types.Met
 		items = []types.Item{}
 	}
 
+	if err := s.attachItemVariants(items); err != nil {
+		return nil, nil, fmt.Errorf("failed to list item variants: %w", err)
+	}
+
 	return items, meta, nil
 }
 
@@ -76,6 +100,9 @@ func (s *service) CreateItem(item types.Item, r *http.Request) (*types.Item, err
 	if item.VendorId == uuid.Nil || item.Price == 0 || item.Name == "" {
 		return nil, errors.New("missing required parameters")
 	}
+	if err := checkTaxClassVendor(s.db, item.TaxClassId, item.VendorId); err != nil {
+		return nil, err
+	}
 
 	img, err := helpers.HandleFileUpload(r, "items")
 	if err != nil {
@@ -85,8 +112,8 @@ func (s *service) CreateItem(item types.Item, r *http.Request) (*types.Item, err
 
 	query, args, err := QB.
 		Insert("items").
-		Columns("id", "vendor_id", "name", "price", "created_at", "updated_at", "img").
-		Values(item.ID, item.VendorId, item.Name, item.Price, item.Created_at, item.Updated_at, item.Img).
+		Columns("id", "vendor_id", "name", "price", "tax_class_id", "created_at", "updated_at", "img").
+		Values(item.ID, item.VendorId, item.Name, item.Price, item.TaxClassId, item.Created_at, item.Updated_at, item.Img).
 		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(itemColumns, ", "))).
 		ToSql()
 	if err != nil {
@@ -96,7 +123,32 @@ func (s *service) CreateItem(item types.Item, r *http.Request) (*types.Item, err
 		return nil, fmt.Errorf("error generating query: %w", err)
 	}
 
-	err = s.db.QueryRowx(query, args...).StructScan(&item)
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowx(query, args...).StructScan(&item)
+	if err == nil {
+		// Every item is sold through at least one variant
+		isAvailable, displayOrder := true, 0
+		item.Variants = []types.ItemVariant{{
+			ID:           uuid.New(),
+			ItemId:       item.ID,
+			Name:         "Regular",
+			Price:        item.Price,
+			IsDefault:    true,
+			IsAvailable:  &isAvailable,
+			DisplayOrder: &displayOrder,
+			Created_at:   item.Created_at,
+			Updated_at:   item.Updated_at,
+		}}
+		err = insertItemVariant(tx, &item.Variants[0])
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
 		if item.Img != nil {
 			helpers.DeleteFile(*item.Img)
@@ -157,12 +209,36 @@ func (s *service) UpdateItem(id string, updates map[string]interface{}, r *http.
 		oldImg = item.Img
 	}
 
+	taxClassID := item.TaxClassId
+	if raw, ok := updates["tax_class_id"]; ok {
+		taxClassID = nil
+		if raw != nil {
+			value, _ := raw.(string)
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: tax_class_id must be a UUID", ErrInvalidTaxClass)
+			}
+			taxClassID = &parsed
+		}
+	}
+	vendorID := item.VendorId
+	if raw, ok := updates["vendor_id"].(string); ok {
+		if parsed, err := uuid.Parse(raw); err == nil {
+			vendorID = parsed
+		}
+	}
+	if err := checkTaxClassVendor(s.db, taxClassID, vendorID); err != nil {
+		return nil, err
+	}
+
 	img, err := helpers.HandleFileUpload(r, "items")
 	if err != nil {
 		return nil, err
 	}
 	updates["img"] = img
 
+	// Stock levels only change through inventory adjustments so they stay audited
+	delete(updates, "stock_quantity")
 	updates["updated_at"] = time.Now()
 
 	query, args, err := QB.Update("items").
@@ -174,12 +250,37 @@ func (s *service) UpdateItem(id string, updates map[string]interface{}, r *http.
 		return nil, fmt.Errorf("error building update query: %w", err)
 	}
 
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var updatedItem types.Item
-	err = s.db.QueryRowx(query, args...).StructScan(&updatedItem)
+	err = tx.QueryRowx(query, args...).StructScan(&updatedItem)
 	if err != nil {
 		return nil, fmt.Errorf("error updating item: %w", err)
 	}
 
+	// The item price is the default variant's price, so keep the two together
+	if _, ok := updates["price"]; ok {
+		query, args, err := QB.Update("item_variants").
+			Set("price", updatedItem.Price).
+			Set("updated_at", time.Now()).
+			Where(squirrel.Eq{"item_id": updatedItem.ID, "is_default": true}).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("error building update query: %w", err)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error updating default variant: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error updating item: %w", err)
+	}
+
 	if oldImg != nil && img != nil {
 		if err := helpers.DeleteFile(*oldImg); err != nil {
 			logger.Log.WithError(err).Error("Failed to delete old item image")
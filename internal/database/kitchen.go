@@ -0,0 +1,745 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidKitchenStation  = errors.New("invalid kitchen station")
+	ErrKitchenStationNotFound = errors.New("kitchen station not found")
+	ErrKitchenTicketNotFound  = errors.New("kitchen ticket not found")
+)
+
+// kitchenRecallWindow is how long bumped tickets stay on a station's feed so
+// the cooks can recall them.
+const kitchenRecallWindow = 2 * time.Hour
+
+const ticketHasPendingLines = `EXISTS (
+	SELECT 1 FROM kitchen_ticket_lines WHERE ticket_id = kitchen_tickets.id AND status = 'pending'
+)`
+
+// stationRoute describes one kind of thing that can be routed to a station.
+type stationRoute struct {
+	owner  string
+	table  string
+	column string
+	noun   string
+}
+
+var (
+	itemRoutes     = stationRoute{owner: "items", table: "kitchen_station_items", column: "item_id", noun: "item"}
+	categoryRoutes = stationRoute{owner: "categories", table: "kitchen_station_categories", column: "category_id", noun: "category"}
+)
+
+var kitchenStationColumns = []string{"id", "vendor_id", "name", "is_default", "sort_order", "created_at", "updated_at"}
+
+func (s *service) ListKitchenStations(vendorID uuid.UUID) ([]types.KitchenStation, error) {
+	query, args, err := QB.Select(kitchenStationColumns...).
+		From("kitchen_stations").
+		Where("vendor_id = ?", vendorID).
+		OrderBy("sort_order", "name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	stations := []types.KitchenStation{}
+	if err := s.db.Select(&stations, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list kitchen stations: %w", err)
+	}
+	if err := attachStationRoutes(s.db, stations); err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// CreateKitchenStation adds a station. Making it the default takes that
+// over from the vendor's current default station.
+func (s *service) CreateKitchenStation(vendorID uuid.UUID, station types.KitchenStation) (*types.KitchenStation, error) {
+	station.Name = strings.TrimSpace(station.Name)
+	if station.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidKitchenStation)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if station.IsDefault {
+		if err := clearDefaultStation(tx, vendorID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	query, args, err := QB.Insert("kitchen_stations").
+		Columns("id", "vendor_id", "name", "is_default", "sort_order", "created_at", "updated_at").
+		Values(uuid.New(), vendorID, station.Name, station.IsDefault, station.SortOrder, now, now).
+		Suffix("RETURNING " + strings.Join(kitchenStationColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var created types.KitchenStation
+	if err := tx.QueryRowx(query, args...).StructScan(&created); err != nil {
+		return nil, kitchenStationWriteError(err, station.Name)
+	}
+	created.ItemIds, created.CategoryIds = []uuid.UUID{}, []uuid.UUID{}
+	return &created, tx.Commit()
+}
+
+func (s *service) UpdateKitchenStation(vendorID uuid.UUID, id string, station types.KitchenStation) (*types.KitchenStation, error) {
+	stationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrKitchenStationNotFound
+	}
+	station.Name = strings.TrimSpace(station.Name)
+	if station.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidKitchenStation)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if station.IsDefault {
+		if err := clearDefaultStation(tx, vendorID); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err := QB.Update("kitchen_stations").
+		Set("name", station.Name).
+		Set("is_default", station.IsDefault).
+		Set("sort_order", station.SortOrder).
+		Set("updated_at", time.Now()).
+		Where("id = ? AND vendor_id = ?", stationID, vendorID).
+		Suffix("RETURNING " + strings.Join(kitchenStationColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([]types.KitchenStation, 1)
+	if err := tx.QueryRowx(query, args...).StructScan(&updated[0]); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrKitchenStationNotFound
+		}
+		return nil, kitchenStationWriteError(err, station.Name)
+	}
+	if err := attachStationRoutes(tx, updated); err != nil {
+		return nil, err
+	}
+	return &updated[0], tx.Commit()
+}
+
+// DeleteKitchenStation removes a station along with its routes and tickets.
+// New orders go to the remaining stations.
+func (s *service) DeleteKitchenStation(vendorID uuid.UUID, id string) error {
+	stationID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrKitchenStationNotFound
+	}
+
+	query, args, err := QB.Delete("kitchen_stations").Where("id = ? AND vendor_id = ?", stationID, vendorID).ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete kitchen station: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrKitchenStationNotFound
+	}
+	return nil
+}
+
+// SetKitchenStationRoutes replaces the items and categories the station
+// makes. An item or category routed elsewhere moves to this station.
+func (s *service) SetKitchenStationRoutes(vendorID uuid.UUID, id string, routes types.KitchenStationRoutes) (*types.KitchenStation, error) {
+	stationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrKitchenStationNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := QB.Select(kitchenStationColumns...).
+		From("kitchen_stations").
+		Where("id = ? AND vendor_id = ?", stationID, vendorID).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	stations := make([]types.KitchenStation, 1)
+	if err := tx.Get(&stations[0], query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrKitchenStationNotFound
+		}
+		return nil, err
+	}
+
+	if err := replaceStationRoutes(tx, vendorID, stationID, itemRoutes, routes.ItemIds); err != nil {
+		return nil, err
+	}
+	if err := replaceStationRoutes(tx, vendorID, stationID, categoryRoutes, routes.CategoryIds); err != nil {
+		return nil, err
+	}
+
+	if err := attachStationRoutes(tx, stations); err != nil {
+		return nil, err
+	}
+	return &stations[0], tx.Commit()
+}
+
+// KitchenFeed lists a station's tickets, most urgent first. status is open
+// (the default), bumped or all; bumped tickets stay listed for
+// kitchenRecallWindow.
+func (s *service) KitchenFeed(vendorID uuid.UUID, id string, status string) ([]types.KitchenTicket, error) {
+	stationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrKitchenStationNotFound
+	}
+	if status == "" {
+		status = "open"
+	}
+
+	var exists bool
+	if err := s.db.Get(&exists, "SELECT EXISTS (SELECT 1 FROM kitchen_stations WHERE id = $1 AND vendor_id = $2)", stationID, vendorID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrKitchenStationNotFound
+	}
+
+	now := time.Now()
+	open := squirrel.And{
+		squirrel.Eq{"kitchen_tickets.status": "pending"},
+		squirrel.Eq{"orders.status": "preparing"},
+	}
+	recent := squirrel.And{
+		squirrel.Eq{"kitchen_tickets.status": "bumped"},
+		squirrel.NotEq{"orders.status": []string{"cancelled", "pending_payment"}},
+		squirrel.GtOrEq{"kitchen_tickets.bumped_at": now.Add(-kitchenRecallWindow)},
+	}
+
+	builder := kitchenTicketQuery(now).Where("kitchen_tickets.station_id = ?", stationID)
+	switch status {
+	case "open":
+		builder = builder.Where(open).OrderBy("kitchen_tickets.priority DESC", "kitchen_tickets.created_at")
+	case "bumped":
+		builder = builder.Where(recent).OrderBy("kitchen_tickets.bumped_at DESC")
+	case "all":
+		builder = builder.Where(squirrel.Or{open, recent}).
+			OrderBy("kitchen_tickets.status", "kitchen_tickets.priority DESC", "kitchen_tickets.created_at")
+	default:
+		return nil, fmt.Errorf("%w: status must be open, bumped or all", ErrInvalidKitchenStation)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	tickets := []types.KitchenTicket{}
+	if err := s.db.Select(&tickets, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load kitchen feed: %w", err)
+	}
+	if err := attachTicketLines(s.db, tickets); err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (s *service) GetKitchenTicket(id string) (*types.KitchenTicket, error) {
+	ticketID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrKitchenTicketNotFound
+	}
+	return getKitchenTicket(s.db, ticketID)
+}
+
+// BumpKitchenTicket marks every line on the ticket as done.
+func (s *service) BumpKitchenTicket(id string) (*types.KitchenTicket, error) {
+	return s.updateKitchenTicket(id, func(tx *sqlx.Tx, ticket *types.KitchenTicket) error {
+		return setTicketLines(tx, ticket.ID, nil, "bumped")
+	})
+}
+
+// RecallKitchenTicket puts a bumped ticket back on the station's screen with
+// all of its lines to make again.
+func (s *service) RecallKitchenTicket(id string) (*types.KitchenTicket, error) {
+	return s.updateKitchenTicket(id, func(tx *sqlx.Tx, ticket *types.KitchenTicket) error {
+		return setTicketLines(tx, ticket.ID, nil, "pending")
+	})
+}
+
+func (s *service) BumpKitchenTicketLine(id, lineID string) (*types.KitchenTicket, error) {
+	return s.updateKitchenTicketLine(id, lineID, "bumped")
+}
+
+func (s *service) RecallKitchenTicketLine(id, lineID string) (*types.KitchenTicket, error) {
+	return s.updateKitchenTicketLine(id, lineID, "pending")
+}
+
+// SetKitchenTicketPriority moves a ticket up or down its station's feed.
+// Higher priorities are shown first.
+func (s *service) SetKitchenTicketPriority(id string, priority int) (*types.KitchenTicket, error) {
+	return s.updateKitchenTicket(id, func(tx *sqlx.Tx, ticket *types.KitchenTicket) error {
+		query, args, err := QB.Update("kitchen_tickets").
+			Set("priority", priority).
+			Set("updated_at", time.Now()).
+			Where("id = ?", ticket.ID).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(query, args...)
+		return err
+	})
+}
+
+func (s *service) updateKitchenTicketLine(id, lineID, status string) (*types.KitchenTicket, error) {
+	parsedLineID, err := uuid.Parse(lineID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: line not found", ErrKitchenTicketNotFound)
+	}
+	return s.updateKitchenTicket(id, func(tx *sqlx.Tx, ticket *types.KitchenTicket) error {
+		return setTicketLines(tx, ticket.ID, &parsedLineID, status)
+	})
+}
+
+// updateKitchenTicket applies change to the ticket, then brings the ticket
+// and its order in line with the ticket's lines: a ticket is bumped once all
+// its lines are, and an order is ready once all its tickets are.
+func (s *service) updateKitchenTicket(id string, change func(tx *sqlx.Tx, ticket *types.KitchenTicket) error) (*types.KitchenTicket, error) {
+	ticketID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrKitchenTicketNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ticket, err := getKitchenTicket(tx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	// Stations bump the tickets of one order one at a time, so the last one
+	// to finish sees everyone else's
+	if _, err := tx.Exec("SELECT 1 FROM orders WHERE id = $1 FOR UPDATE", ticket.OrderId); err != nil {
+		return nil, err
+	}
+
+	if err := change(tx, ticket); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query, args, err := QB.Update("kitchen_tickets").
+		Set("status", squirrel.Expr("CASE WHEN "+ticketHasPendingLines+" THEN 'pending'::kitchen_status ELSE 'bumped'::kitchen_status END")).
+		Set("bumped_at", squirrel.Expr("CASE WHEN "+ticketHasPendingLines+" THEN NULL ELSE COALESCE(bumped_at, ?) END", now)).
+		Set("updated_at", now).
+		Where("id = ?", ticket.ID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update kitchen ticket: %w", err)
+	}
+
+	if err := advanceOrderFromKitchen(tx, ticket.OrderId); err != nil {
+		return nil, err
+	}
+
+	updated, err := getKitchenTicket(tx, ticket.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := publishKitchenTicketEvent(tx, "kitchen_ticket.updated", updated); err != nil {
+		return nil, err
+	}
+	return updated, tx.Commit()
+}
+
+// setTicketLines moves one line of the ticket, or every line when lineID is
+// nil, to status.
+func setTicketLines(tx *sqlx.Tx, ticketID uuid.UUID, lineID *uuid.UUID, status string) error {
+	builder := QB.Update("kitchen_ticket_lines").Set("status", status).Where("ticket_id = ?", ticketID)
+	if status == "bumped" {
+		builder = builder.Set("bumped_at", squirrel.Expr("COALESCE(bumped_at, ?)", time.Now()))
+	} else {
+		builder = builder.Set("bumped_at", nil)
+	}
+	if lineID != nil {
+		builder = builder.Where("id = ?", *lineID)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket lines: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 && lineID != nil {
+		return fmt.Errorf("%w: line not found", ErrKitchenTicketNotFound)
+	}
+	return nil
+}
+
+// advanceOrderFromKitchen marks a preparing order ready once every station
+// has bumped its ticket, and takes a ready order back to preparing when a
+// ticket is recalled.
+func advanceOrderFromKitchen(tx *sqlx.Tx, orderID uuid.UUID) error {
+	var pending bool
+	if err := tx.Get(&pending, "SELECT EXISTS (SELECT 1 FROM kitchen_tickets WHERE order_id = $1 AND status = 'pending')", orderID); err != nil {
+		return err
+	}
+
+	from, to := "preparing", "ready"
+	if pending {
+		from, to = "ready", "preparing"
+	}
+	query, args, err := QB.Update("orders").
+		Set("status", to).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": orderID, "status": from}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to advance order: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+		return err
+	}
+	if err := enqueueOrderEvent(tx, orderID, OrderStatusChangedEvent, from); err != nil {
+		return err
+	}
+	return publishOrderEvent(tx, orderID, "order.updated")
+}
+
+// createKitchenTickets splits a new order into one ticket per station. Each
+// line goes to the station its item is routed to, else the station one of
+// its categories is routed to, else the vendor's default station. Vendors
+// without stations get no tickets.
+func createKitchenTickets(tx *sqlx.Tx, orderID, vendorID uuid.UUID, createdAt time.Time) error {
+	query, args, err := QB.Select("order_items.id").
+		Column(`COALESCE(
+			(SELECT ksi.station_id FROM kitchen_station_items ksi WHERE ksi.item_id = order_items.item_id),
+			(
+				SELECT ksc.station_id FROM item_categories ic
+				JOIN kitchen_station_categories ksc ON ksc.category_id = ic.category_id
+				JOIN kitchen_stations ks ON ks.id = ksc.station_id
+				WHERE ic.item_id = order_items.item_id
+				ORDER BY ks.sort_order, ks.name LIMIT 1
+			),
+			(
+				SELECT ks.id FROM kitchen_stations ks WHERE ks.vendor_id = ?
+				ORDER BY ks.is_default DESC, ks.sort_order, ks.name LIMIT 1
+			)
+		) AS station_id`, vendorID).
+		From("order_items").
+		Where("order_items.order_id = ?", orderID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var routed []struct {
+		ID        uuid.UUID  `db:"id"`
+		StationId *uuid.UUID `db:"station_id"`
+	}
+	if err := tx.Select(&routed, query, args...); err != nil {
+		return fmt.Errorf("failed to route order to kitchen stations: %w", err)
+	}
+
+	tickets := make(map[uuid.UUID]uuid.UUID)
+	for _, line := range routed {
+		if line.StationId == nil {
+			continue
+		}
+		ticketID, ok := tickets[*line.StationId]
+		if !ok {
+			ticketID = uuid.New()
+			tickets[*line.StationId] = ticketID
+			query, args, err := QB.Insert("kitchen_tickets").
+				Columns("id", "order_id", "vendor_id", "station_id", "created_at", "updated_at").
+				Values(ticketID, orderID, vendorID, *line.StationId, createdAt, createdAt).
+				ToSql()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("failed to create kitchen ticket: %w", err)
+			}
+		}
+
+		query, args, err := QB.Insert("kitchen_ticket_lines").
+			Columns("ticket_id", "order_item_id").
+			Values(ticketID, line.ID).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to create kitchen ticket line: %w", err)
+		}
+	}
+
+	for _, ticketID := range tickets {
+		ticket, err := getKitchenTicket(tx, ticketID)
+		if err != nil {
+			return err
+		}
+		if err := publishKitchenTicketEvent(tx, "kitchen_ticket.created", ticket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishKitchenTicketEvent tells the vendor's screens which ticket changed
+// and how, leaving out the lines.
+func publishKitchenTicketEvent(tx *sqlx.Tx, eventType string, ticket *types.KitchenTicket) error {
+	return publishEvent(tx, eventType, []string{vendorOrdersTopic(ticket.VendorId)}, types.KitchenTicketEvent{
+		ID:         ticket.ID,
+		OrderId:    ticket.OrderId,
+		VendorId:   ticket.VendorId,
+		StationId:  ticket.StationId,
+		Status:     ticket.Status,
+		Priority:   ticket.Priority,
+		Updated_at: ticket.Updated_at,
+	})
+}
+
+// kitchenTicketQuery selects tickets with what a cook needs to know about
+// their order. Age counts up to now, or to the bump for bumped tickets.
+func kitchenTicketQuery(now time.Time) squirrel.SelectBuilder {
+	return QB.Select("kitchen_tickets.id", "kitchen_tickets.order_id", "kitchen_tickets.vendor_id",
+		"kitchen_tickets.station_id", "kitchen_tickets.status", "kitchen_tickets.priority", "kitchen_tickets.bumped_at",
+		"kitchen_tickets.created_at", "kitchen_tickets.updated_at", "orders.status AS order_status",
+		"orders.fulfillment_type", "orders.table_id", "tables.name AS table_name", "orders.pickup_at").
+		Column("EXTRACT(EPOCH FROM (COALESCE(kitchen_tickets.bumped_at, ?) - kitchen_tickets.created_at))::int AS age_seconds", now).
+		From("kitchen_tickets").
+		Join("orders ON orders.id = kitchen_tickets.order_id").
+		LeftJoin("tables ON tables.id = orders.table_id")
+}
+
+func getKitchenTicket(q sqlx.Queryer, ticketID uuid.UUID) (*types.KitchenTicket, error) {
+	query, args, err := kitchenTicketQuery(time.Now()).Where("kitchen_tickets.id = ?", ticketID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := make([]types.KitchenTicket, 1)
+	if err := sqlx.Get(q, &tickets[0], query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrKitchenTicketNotFound
+		}
+		return nil, err
+	}
+	if err := attachTicketLines(q, tickets); err != nil {
+		return nil, err
+	}
+	return &tickets[0], nil
+}
+
+// attachTicketLines loads the lines of every ticket, with their modifiers,
+// in two queries.
+func attachTicketLines(q sqlx.Queryer, tickets []types.KitchenTicket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+	ticketIDs := make([]uuid.UUID, len(tickets))
+	for i := range tickets {
+		ticketIDs[i] = tickets[i].ID
+		tickets[i].Lines = []types.KitchenTicketLine{}
+	}
+
+	query, args, err := QB.Select("kitchen_ticket_lines.id", "kitchen_ticket_lines.ticket_id",
+		"kitchen_ticket_lines.order_item_id", "kitchen_ticket_lines.status", "kitchen_ticket_lines.bumped_at",
+		"items.name AS item_name", "order_items.variant_name", "order_items.quantity").
+		From("kitchen_ticket_lines").
+		Join("order_items ON order_items.id = kitchen_ticket_lines.order_item_id").
+		Join("items ON items.id = order_items.item_id").
+		Where(squirrel.Eq{"kitchen_ticket_lines.ticket_id": ticketIDs}).
+		OrderBy("items.name", "kitchen_ticket_lines.id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	var lines []types.KitchenTicketLine
+	if err := sqlx.Select(q, &lines, query, args...); err != nil {
+		return fmt.Errorf("failed to load ticket lines: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	orderItemIDs := make([]uuid.UUID, len(lines))
+	for i, line := range lines {
+		orderItemIDs[i] = line.OrderItemId
+	}
+	query, args, err = QB.Select("*").
+		From("order_item_modifiers").
+		Where(squirrel.Eq{"order_item_id": orderItemIDs}).
+		OrderBy("group_name", "name").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	var modifiers []types.OrderItemModifier
+	if err := sqlx.Select(q, &modifiers, query, args...); err != nil {
+		return fmt.Errorf("failed to load ticket modifiers: %w", err)
+	}
+	byOrderItem := make(map[uuid.UUID][]types.OrderItemModifier)
+	for _, modifier := range modifiers {
+		byOrderItem[modifier.OrderItemId] = append(byOrderItem[modifier.OrderItemId], modifier)
+	}
+
+	index := make(map[uuid.UUID]int, len(tickets))
+	for i, ticket := range tickets {
+		index[ticket.ID] = i
+	}
+	for _, line := range lines {
+		line.Modifiers = byOrderItem[line.OrderItemId]
+		i := index[line.TicketId]
+		tickets[i].Lines = append(tickets[i].Lines, line)
+	}
+	return nil
+}
+
+// attachStationRoutes fills in the items and categories routed to each
+// station.
+func attachStationRoutes(q sqlx.Queryer, stations []types.KitchenStation) error {
+	if len(stations) == 0 {
+		return nil
+	}
+	stationIDs := make([]uuid.UUID, len(stations))
+	index := make(map[uuid.UUID]int, len(stations))
+	for i := range stations {
+		stationIDs[i] = stations[i].ID
+		index[stations[i].ID] = i
+		stations[i].ItemIds = []uuid.UUID{}
+		stations[i].CategoryIds = []uuid.UUID{}
+	}
+
+	for _, route := range []stationRoute{itemRoutes, categoryRoutes} {
+		query, args, err := QB.Select("station_id", route.column+" AS route_id").
+			From(route.table).
+			Where(squirrel.Eq{"station_id": stationIDs}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		var routed []struct {
+			StationId uuid.UUID `db:"station_id"`
+			RouteId   uuid.UUID `db:"route_id"`
+		}
+		if err := sqlx.Select(q, &routed, query, args...); err != nil {
+			return fmt.Errorf("failed to load station routes: %w", err)
+		}
+		for _, row := range routed {
+			station := &stations[index[row.StationId]]
+			if route == itemRoutes {
+				station.ItemIds = append(station.ItemIds, row.RouteId)
+			} else {
+				station.CategoryIds = append(station.CategoryIds, row.RouteId)
+			}
+		}
+	}
+	return nil
+}
+
+// replaceStationRoutes routes ids, which must all be the vendor's, to the
+// station in place of whatever it had before.
+func replaceStationRoutes(tx *sqlx.Tx, vendorID, stationID uuid.UUID, route stationRoute, ids []uuid.UUID) error {
+	query, args, err := QB.Delete(route.table).Where("station_id = ?", stationID).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to clear station routes: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	unique := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	query, args, err = QB.Select("COUNT(*)").From(route.owner).Where(squirrel.Eq{"id": ids, "vendor_id": vendorID}).ToSql()
+	if err != nil {
+		return err
+	}
+	var found int
+	if err := tx.Get(&found, query, args...); err != nil {
+		return err
+	}
+	if found != len(unique) {
+		return fmt.Errorf("%w: every %s must belong to this vendor", ErrInvalidKitchenStation, route.noun)
+	}
+
+	insert := QB.Insert(route.table).Columns(route.column, "station_id")
+	for id := range unique {
+		insert = insert.Values(id, stationID)
+	}
+	query, args, err = insert.Suffix(fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET station_id = EXCLUDED.station_id", route.column)).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to save station routes: %w", err)
+	}
+	return nil
+}
+
+func clearDefaultStation(tx *sqlx.Tx, vendorID uuid.UUID) error {
+	query, args, err := QB.Update("kitchen_stations").
+		Set("is_default", false).
+		Where("vendor_id = ? AND is_default", vendorID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+func kitchenStationWriteError(err error, name string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: a station named %q already exists", ErrInvalidKitchenStation, name)
+	}
+	return fmt.Errorf("failed to save kitchen station: %w", err)
+}
@@ -0,0 +1,17 @@
+DROP TABLE order_item_taxes;
+
+ALTER TABLE order_items DROP COLUMN tax_amount;
+
+ALTER TABLE orders
+    DROP COLUMN subtotal,
+    DROP COLUMN tax_total;
+
+ALTER TABLE carts
+    DROP COLUMN subtotal,
+    DROP COLUMN tax_total;
+
+ALTER TABLE items DROP COLUMN tax_class_id;
+
+ALTER TABLE vendors DROP COLUMN prices_include_tax;
+
+DROP TABLE tax_classes;
@@ -0,0 +1,59 @@
+CREATE TABLE tax_classes (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id     uuid NOT NULL,
+    name          VARCHAR(255) NOT NULL,
+    rate          DECIMAL(6,4) NOT NULL DEFAULT 0,
+    is_default    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_rate
+        CHECK (rate >= 0 AND rate < 1)
+);
+
+-- Only one default tax class per vendor
+CREATE UNIQUE INDEX idx_tax_classes_vendor_default ON tax_classes (vendor_id) WHERE is_default;
+
+ALTER TABLE vendors ADD COLUMN prices_include_tax BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE items ADD COLUMN tax_class_id uuid DEFAULT NULL
+    CONSTRAINT fk_tax_class_id
+        REFERENCES tax_classes (id)
+        ON DELETE SET NULL;
+
+ALTER TABLE carts
+    ADD COLUMN subtotal   DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN tax_total  DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN subtotal   DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN tax_total  DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+    ADD COLUMN tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE order_item_taxes (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_item_id  uuid NOT NULL,
+    tax_class_id   uuid DEFAULT NULL,
+    name           VARCHAR(255) NOT NULL,
+    rate           DECIMAL(6,4) NOT NULL,
+    is_inclusive   BOOLEAN NOT NULL DEFAULT FALSE,
+    taxable_amount DECIMAL(10,2) NOT NULL,
+    amount         DECIMAL(10,2) NOT NULL,
+
+    CONSTRAINT fk_order_item_id
+    FOREIGN KEY (order_item_id)
+        REFERENCES order_items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_tax_class_id
+    FOREIGN KEY (tax_class_id)
+        REFERENCES tax_classes (id)
+        ON DELETE SET NULL
+);
@@ -0,0 +1,17 @@
+DROP TABLE coupon_redemptions;
+
+DROP TABLE order_discounts;
+
+ALTER TABLE order_items DROP COLUMN discount_amount;
+
+ALTER TABLE orders DROP COLUMN discount_total;
+
+ALTER TABLE carts
+    DROP COLUMN coupon_id,
+    DROP COLUMN discount_total;
+
+DROP TABLE coupon_items;
+
+DROP TABLE coupons;
+
+DROP TYPE discount_type;
@@ -0,0 +1,103 @@
+CREATE TYPE discount_type AS ENUM ('percentage', 'fixed');
+
+CREATE TABLE coupons (
+    id                    uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id             uuid DEFAULT NULL,
+    code                  VARCHAR(64) NOT NULL,
+    description           TEXT,
+    discount_type         discount_type NOT NULL,
+    value                 DECIMAL(10,2) NOT NULL,
+    min_spend             DECIMAL(10,2) NOT NULL DEFAULT 0,
+    max_discount          DECIMAL(10,2) DEFAULT NULL,
+    starts_at             TIMESTAMP DEFAULT NULL,
+    ends_at               TIMESTAMP DEFAULT NULL,
+    usage_limit           INT DEFAULT NULL,
+    usage_limit_per_user  INT DEFAULT NULL,
+    is_active             BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_value
+        CHECK (value > 0 AND (discount_type <> 'percentage' OR value <= 100))
+);
+
+CREATE UNIQUE INDEX idx_coupons_code ON coupons (UPPER(code));
+
+-- Coupons without rows here apply to every item in scope
+CREATE TABLE coupon_items (
+    coupon_id  uuid NOT NULL,
+    item_id    uuid NOT NULL,
+
+    PRIMARY KEY (coupon_id, item_id),
+
+    CONSTRAINT fk_coupon_id
+    FOREIGN KEY (coupon_id)
+        REFERENCES coupons (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE
+);
+
+ALTER TABLE carts
+    ADD COLUMN coupon_id       uuid DEFAULT NULL
+        CONSTRAINT fk_coupon_id
+            REFERENCES coupons (id)
+            ON DELETE SET NULL,
+    ADD COLUMN discount_total  DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders ADD COLUMN discount_total DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items ADD COLUMN discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TABLE order_discounts (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id     uuid NOT NULL,
+    coupon_id    uuid DEFAULT NULL,
+    code         VARCHAR(64) NOT NULL,
+    description  TEXT,
+    amount       DECIMAL(10,2) NOT NULL,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_coupon_id
+    FOREIGN KEY (coupon_id)
+        REFERENCES coupons (id)
+        ON DELETE SET NULL
+);
+
+CREATE TABLE coupon_redemptions (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id   uuid NOT NULL,
+    order_id    uuid NOT NULL,
+    user_id     uuid NOT NULL,
+    amount      DECIMAL(10,2) NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_coupon_id
+    FOREIGN KEY (coupon_id)
+        REFERENCES coupons (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_user_id
+    FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions (coupon_id, user_id);
@@ -0,0 +1,11 @@
+DROP TABLE order_adjustments;
+
+DROP TYPE order_adjustment_type;
+
+ALTER TABLE orders
+    DROP COLUMN table_id,
+    DROP COLUMN party_size,
+    DROP COLUMN service_charge_total,
+    DROP COLUMN tip_total;
+
+DROP TABLE service_charge_rules;
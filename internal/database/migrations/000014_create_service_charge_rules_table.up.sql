@@ -0,0 +1,50 @@
+CREATE TABLE service_charge_rules (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id       uuid NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    rate            DECIMAL(6,4) NOT NULL,
+    min_party_size  INT NOT NULL DEFAULT 1,
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_rate
+        CHECK (rate > 0 AND rate < 1)
+);
+
+ALTER TABLE orders
+    ADD COLUMN table_id              uuid DEFAULT NULL
+        CONSTRAINT fk_table_id
+            REFERENCES tables (id)
+            ON DELETE SET NULL,
+    ADD COLUMN party_size            INT DEFAULT NULL,
+    ADD COLUMN service_charge_total  DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN tip_total             DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+CREATE TYPE order_adjustment_type AS ENUM ('service_charge', 'tip');
+
+CREATE TABLE order_adjustments (
+    id                      uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id                uuid NOT NULL,
+    type                    order_adjustment_type NOT NULL,
+    service_charge_rule_id  uuid DEFAULT NULL,
+    name                    VARCHAR(255) NOT NULL,
+    rate                    DECIMAL(6,4) DEFAULT NULL,
+    amount                  DECIMAL(10,2) NOT NULL,
+    created_at              TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_service_charge_rule_id
+    FOREIGN KEY (service_charge_rule_id)
+        REFERENCES service_charge_rules (id)
+        ON DELETE SET NULL
+);
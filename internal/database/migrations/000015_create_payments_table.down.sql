@@ -0,0 +1,12 @@
+DROP TABLE payment_transactions;
+
+DROP TABLE payments;
+
+ALTER TABLE orders DROP COLUMN payment_status;
+
+DROP TYPE order_payment_status;
+DROP TYPE payment_method;
+DROP TYPE payment_status;
+
+-- Postgres can't drop enum values, so order_status keeps 'pending_payment'
+UPDATE orders SET status = 'preparing' WHERE status = 'pending_payment';
@@ -0,0 +1,48 @@
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'pending_payment';
+
+CREATE TYPE payment_status AS ENUM ('pending', 'authorized', 'captured', 'partially_refunded', 'refunded', 'voided', 'failed');
+CREATE TYPE payment_method AS ENUM ('pay_now', 'pay_at_table');
+CREATE TYPE order_payment_status AS ENUM ('unpaid', 'authorized', 'paid', 'partially_refunded', 'refunded');
+
+ALTER TABLE orders ADD COLUMN payment_status order_payment_status NOT NULL DEFAULT 'unpaid';
+
+CREATE TABLE payments (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id         uuid NOT NULL,
+    provider         VARCHAR(64) NOT NULL,
+    provider_ref     VARCHAR(255) DEFAULT NULL,
+    method           payment_method NOT NULL,
+    status           payment_status NOT NULL DEFAULT 'pending',
+    amount           DECIMAL(10,2) NOT NULL,
+    captured_amount  DECIMAL(10,2) NOT NULL DEFAULT 0,
+    refunded_amount  DECIMAL(10,2) NOT NULL DEFAULT 0,
+    failure_reason   TEXT,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_payments_provider_ref ON payments (provider, provider_ref);
+CREATE INDEX idx_payments_order_id ON payments (order_id);
+
+-- Append-only ledger of everything that happened to a payment
+CREATE TABLE payment_transactions (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id    uuid NOT NULL,
+    type          VARCHAR(32) NOT NULL,
+    amount        DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status        payment_status NOT NULL,
+    provider_ref  VARCHAR(255) DEFAULT NULL,
+    event_id      VARCHAR(255) DEFAULT NULL UNIQUE,
+    payload       JSONB DEFAULT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_payment_id
+    FOREIGN KEY (payment_id)
+        REFERENCES payments (id)
+        ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+DROP TABLE refund_items;
+
+DROP TABLE refunds;
+
+ALTER TABLE order_items DROP CONSTRAINT chk_refunded_quantity;
+ALTER TABLE order_items
+    DROP COLUMN refunded_quantity,
+    DROP COLUMN total;
+
+ALTER TABLE orders
+    DROP COLUMN net_total,
+    DROP COLUMN refunded_total;
+
+DROP TYPE refund_status;
@@ -0,0 +1,63 @@
+CREATE TYPE refund_status AS ENUM ('pending', 'completed', 'failed');
+
+ALTER TABLE orders
+    ADD COLUMN refunded_total DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN net_total      DECIMAL(10,2) GENERATED ALWAYS AS (total_order_cost - refunded_total) STORED;
+
+ALTER TABLE order_items
+    ADD COLUMN total             DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN refunded_quantity INT NOT NULL DEFAULT 0;
+
+-- What the customer paid for each line, needed to refund lines individually
+UPDATE order_items SET total = price * quantity - discount_amount + tax_amount;
+
+ALTER TABLE order_items ADD CONSTRAINT chk_refunded_quantity
+    CHECK (refunded_quantity >= 0 AND refunded_quantity <= quantity);
+
+CREATE TABLE refunds (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id        uuid NOT NULL,
+    amount          DECIMAL(10,2) NOT NULL,
+    reason          TEXT NOT NULL,
+    status          refund_status NOT NULL DEFAULT 'pending',
+    approved_by     uuid DEFAULT NULL,
+    failure_reason  TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_approved_by
+    FOREIGN KEY (approved_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT chk_amount
+        CHECK (amount > 0)
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds (order_id);
+
+CREATE TABLE refund_items (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    refund_id      uuid NOT NULL,
+    order_item_id  uuid NOT NULL,
+    quantity       INT NOT NULL,
+    amount         DECIMAL(10,2) NOT NULL,
+
+    CONSTRAINT fk_refund_id
+    FOREIGN KEY (refund_id)
+        REFERENCES refunds (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_item_id
+    FOREIGN KEY (order_item_id)
+        REFERENCES order_items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_quantity
+        CHECK (quantity > 0)
+);
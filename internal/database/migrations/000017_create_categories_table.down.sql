@@ -0,0 +1,5 @@
+DROP TABLE coupon_categories;
+
+DROP TABLE item_categories;
+
+DROP TABLE categories;
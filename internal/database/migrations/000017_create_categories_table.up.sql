@@ -0,0 +1,63 @@
+CREATE TABLE categories (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id      uuid NOT NULL,
+    parent_id      uuid DEFAULT NULL,
+    name           VARCHAR(255) NOT NULL,
+    description    TEXT,
+    display_order  INT NOT NULL DEFAULT 0,
+    is_active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_parent_id
+    FOREIGN KEY (parent_id)
+        REFERENCES categories (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_not_own_parent
+        CHECK (parent_id IS NULL OR parent_id <> id)
+);
+
+CREATE INDEX idx_categories_vendor_id ON categories (vendor_id, display_order);
+
+CREATE TABLE item_categories (
+    item_id        uuid NOT NULL,
+    category_id    uuid NOT NULL,
+    display_order  INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (item_id, category_id),
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_category_id
+    FOREIGN KEY (category_id)
+        REFERENCES categories (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_item_categories_category_id ON item_categories (category_id);
+
+CREATE TABLE coupon_categories (
+    coupon_id    uuid NOT NULL,
+    category_id  uuid NOT NULL,
+
+    PRIMARY KEY (coupon_id, category_id),
+
+    CONSTRAINT fk_coupon_id
+    FOREIGN KEY (coupon_id)
+        REFERENCES coupons (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_category_id
+    FOREIGN KEY (category_id)
+        REFERENCES categories (id)
+        ON DELETE CASCADE
+);
@@ -0,0 +1,23 @@
+DROP TABLE order_item_modifiers;
+
+DROP TABLE cart_item_modifiers;
+
+-- Collapse lines that only differed by their modifiers back into one.
+DELETE FROM cart_items a
+    USING cart_items b
+    WHERE a.cart_id = b.cart_id AND a.item_id = b.item_id AND a.id > b.id;
+
+DROP INDEX idx_cart_items_line;
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_pkey;
+ALTER TABLE cart_items
+    DROP COLUMN modifier_key,
+    DROP COLUMN id;
+ALTER TABLE cart_items ADD PRIMARY KEY (cart_id, item_id);
+
+DROP TABLE item_modifier_groups;
+
+DROP TABLE modifier_options;
+
+DROP TABLE modifier_groups;
+
+DROP TYPE modifier_selection_type;
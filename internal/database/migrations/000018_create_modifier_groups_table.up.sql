@@ -0,0 +1,106 @@
+CREATE TYPE modifier_selection_type AS ENUM ('single', 'multiple');
+
+CREATE TABLE modifier_groups (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id       uuid NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    selection_type  modifier_selection_type NOT NULL DEFAULT 'multiple',
+    min_selections  INT NOT NULL DEFAULT 0,
+    max_selections  INT DEFAULT NULL,
+    is_required     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_selections
+        CHECK (min_selections >= 0 AND (max_selections IS NULL OR max_selections >= min_selections))
+);
+
+CREATE TABLE modifier_options (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id       uuid NOT NULL,
+    name           VARCHAR(255) NOT NULL,
+    price_delta    DECIMAL(10,2) NOT NULL DEFAULT 0,
+    display_order  INT NOT NULL DEFAULT 0,
+    is_active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_group_id
+    FOREIGN KEY (group_id)
+        REFERENCES modifier_groups (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_modifier_options_group_id ON modifier_options (group_id);
+
+CREATE TABLE item_modifier_groups (
+    item_id        uuid NOT NULL,
+    group_id       uuid NOT NULL,
+    display_order  INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (item_id, group_id),
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_group_id
+    FOREIGN KEY (group_id)
+        REFERENCES modifier_groups (id)
+        ON DELETE CASCADE
+);
+
+-- The same item with different modifiers is a separate cart line, so lines
+-- get their own id and are unique per item and modifier selection.
+ALTER TABLE cart_items DROP CONSTRAINT cart_items_pkey;
+ALTER TABLE cart_items
+    ADD COLUMN id uuid NOT NULL DEFAULT gen_random_uuid(),
+    ADD COLUMN modifier_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE cart_items ADD PRIMARY KEY (id);
+CREATE UNIQUE INDEX idx_cart_items_line ON cart_items (cart_id, item_id, modifier_key);
+
+CREATE TABLE cart_item_modifiers (
+    cart_item_id        uuid NOT NULL,
+    modifier_option_id  uuid NOT NULL,
+
+    PRIMARY KEY (cart_item_id, modifier_option_id),
+
+    CONSTRAINT fk_cart_item_id
+    FOREIGN KEY (cart_item_id)
+        REFERENCES cart_items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_modifier_option_id
+    FOREIGN KEY (modifier_option_id)
+        REFERENCES modifier_options (id)
+        ON DELETE CASCADE
+);
+
+-- Order lines keep a copy of the chosen modifiers so later menu edits don't
+-- rewrite past orders.
+CREATE TABLE order_item_modifiers (
+    id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_item_id       uuid NOT NULL,
+    modifier_option_id  uuid DEFAULT NULL,
+    group_name          VARCHAR(255) NOT NULL,
+    name                VARCHAR(255) NOT NULL,
+    price_delta         DECIMAL(10,2) NOT NULL DEFAULT 0,
+
+    CONSTRAINT fk_order_item_id
+    FOREIGN KEY (order_item_id)
+        REFERENCES order_items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_modifier_option_id
+    FOREIGN KEY (modifier_option_id)
+        REFERENCES modifier_options (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_order_item_modifiers_order_item_id ON order_item_modifiers (order_item_id);
@@ -0,0 +1,17 @@
+ALTER TABLE order_items DROP CONSTRAINT fk_variant_id;
+ALTER TABLE order_items
+    DROP COLUMN sku,
+    DROP COLUMN variant_name,
+    DROP COLUMN variant_id;
+
+-- Lines for different variants of the same item fold back into one.
+DELETE FROM cart_items a
+    USING cart_items b
+    WHERE a.cart_id = b.cart_id AND a.item_id = b.item_id AND a.modifier_key = b.modifier_key AND a.id > b.id;
+
+DROP INDEX idx_cart_items_line;
+ALTER TABLE cart_items DROP CONSTRAINT fk_variant_id;
+ALTER TABLE cart_items DROP COLUMN variant_id;
+CREATE UNIQUE INDEX idx_cart_items_line ON cart_items (cart_id, item_id, modifier_key);
+
+DROP TABLE item_variants;
@@ -0,0 +1,49 @@
+CREATE TABLE item_variants (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id        uuid NOT NULL,
+    name           VARCHAR(255) NOT NULL,
+    sku            VARCHAR(64) DEFAULT NULL,
+    price          DECIMAL(10,2) NOT NULL,
+    is_default     BOOLEAN NOT NULL DEFAULT FALSE,
+    is_available   BOOLEAN NOT NULL DEFAULT TRUE,
+    display_order  INT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_item_variants_item_id ON item_variants (item_id);
+CREATE UNIQUE INDEX idx_item_variants_sku ON item_variants (sku) WHERE sku IS NOT NULL;
+CREATE UNIQUE INDEX idx_item_variants_default ON item_variants (item_id) WHERE is_default;
+
+-- Every existing item becomes a single default variant at its current price.
+INSERT INTO item_variants (item_id, name, price, is_default)
+SELECT id, 'Regular', price, TRUE FROM items;
+
+ALTER TABLE cart_items ADD COLUMN variant_id uuid;
+UPDATE cart_items SET variant_id = item_variants.id
+    FROM item_variants
+    WHERE item_variants.item_id = cart_items.item_id AND item_variants.is_default;
+ALTER TABLE cart_items ALTER COLUMN variant_id SET NOT NULL;
+ALTER TABLE cart_items
+    ADD CONSTRAINT fk_variant_id
+    FOREIGN KEY (variant_id)
+        REFERENCES item_variants (id)
+        ON DELETE CASCADE;
+
+DROP INDEX idx_cart_items_line;
+CREATE UNIQUE INDEX idx_cart_items_line ON cart_items (cart_id, variant_id, modifier_key);
+
+ALTER TABLE order_items
+    ADD COLUMN variant_id uuid DEFAULT NULL,
+    ADD COLUMN variant_name VARCHAR(255) DEFAULT NULL,
+    ADD COLUMN sku VARCHAR(64) DEFAULT NULL;
+ALTER TABLE order_items
+    ADD CONSTRAINT fk_variant_id
+    FOREIGN KEY (variant_id)
+        REFERENCES item_variants (id)
+        ON DELETE SET NULL;
@@ -0,0 +1,13 @@
+DROP TABLE inventory_adjustments;
+
+DROP TYPE inventory_adjustment_reason;
+
+ALTER TABLE item_variants
+    DROP CONSTRAINT chk_stock_quantity,
+    DROP COLUMN low_stock_threshold,
+    DROP COLUMN stock_quantity;
+
+ALTER TABLE items
+    DROP CONSTRAINT chk_stock_quantity,
+    DROP COLUMN low_stock_threshold,
+    DROP COLUMN stock_quantity;
@@ -0,0 +1,57 @@
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'cancelled';
+
+-- Stock is optional: NULL means the item or variant isn't tracked. A variant
+-- with its own level uses it, otherwise it draws on the item's shared stock.
+ALTER TABLE items
+    ADD COLUMN stock_quantity INT DEFAULT NULL,
+    ADD COLUMN low_stock_threshold INT DEFAULT NULL,
+    ADD CONSTRAINT chk_stock_quantity CHECK (stock_quantity IS NULL OR stock_quantity >= 0);
+
+ALTER TABLE item_variants
+    ADD COLUMN stock_quantity INT DEFAULT NULL,
+    ADD COLUMN low_stock_threshold INT DEFAULT NULL,
+    ADD CONSTRAINT chk_stock_quantity CHECK (stock_quantity IS NULL OR stock_quantity >= 0);
+
+CREATE TYPE inventory_adjustment_reason AS ENUM ('received', 'wasted', 'counted', 'sale', 'restock');
+
+CREATE TABLE inventory_adjustments (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id        uuid NOT NULL,
+    item_id          uuid NOT NULL,
+    variant_id       uuid DEFAULT NULL,
+    reason           inventory_adjustment_reason NOT NULL,
+    quantity_change  INT NOT NULL,
+    quantity_after   INT NOT NULL,
+    order_id         uuid DEFAULT NULL,
+    user_id          uuid DEFAULT NULL,
+    note             TEXT,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_variant_id
+    FOREIGN KEY (variant_id)
+        REFERENCES item_variants (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_user_id
+    FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_inventory_adjustments_vendor_id ON inventory_adjustments (vendor_id, created_at);
+CREATE INDEX idx_inventory_adjustments_order_id ON inventory_adjustments (order_id);
@@ -0,0 +1,5 @@
+DROP TABLE item_availability_schedules;
+
+ALTER TABLE items DROP COLUMN is_available;
+
+ALTER TABLE vendors DROP COLUMN timezone;
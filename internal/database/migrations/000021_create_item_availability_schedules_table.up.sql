@@ -0,0 +1,24 @@
+ALTER TABLE vendors ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+ALTER TABLE items ADD COLUMN is_available BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- An item with no schedules can be ordered whenever it is available. With
+-- schedules, only inside one of the windows, in the vendor's local time. A
+-- window ending before it starts runs past midnight into the next day.
+CREATE TABLE item_availability_schedules (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    item_id      uuid NOT NULL,
+    day_of_week  SMALLINT NOT NULL,
+    start_time   TIME NOT NULL,
+    end_time     TIME NOT NULL,
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_day_of_week CHECK (day_of_week BETWEEN 0 AND 6),
+    CONSTRAINT chk_window CHECK (start_time <> end_time)
+);
+
+CREATE INDEX idx_item_availability_schedules_item_id ON item_availability_schedules (item_id);
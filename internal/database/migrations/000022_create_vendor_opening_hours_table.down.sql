@@ -0,0 +1,3 @@
+DROP TABLE vendor_hours_overrides;
+
+DROP TABLE vendor_opening_hours;
@@ -0,0 +1,43 @@
+-- Weekly opening hours in the vendor's timezone. A vendor can open more than
+-- once a day, and a window closing before it opens runs past midnight.
+-- Vendors without any hours are treated as always open.
+CREATE TABLE vendor_opening_hours (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id    uuid NOT NULL,
+    day_of_week  SMALLINT NOT NULL,
+    open_time    TIME NOT NULL,
+    close_time   TIME NOT NULL,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_day_of_week CHECK (day_of_week BETWEEN 0 AND 6),
+    CONSTRAINT chk_window CHECK (open_time <> close_time)
+);
+
+CREATE INDEX idx_vendor_opening_hours_vendor_id ON vendor_opening_hours (vendor_id);
+
+-- Holidays and other exceptions. Without times the vendor is closed on those
+-- days, with times the weekly hours are replaced by the given window.
+CREATE TABLE vendor_hours_overrides (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    starts_on   DATE NOT NULL,
+    ends_on     DATE NOT NULL,
+    open_time   TIME DEFAULT NULL,
+    close_time  TIME DEFAULT NULL,
+    note        TEXT,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_dates CHECK (ends_on >= starts_on),
+    CONSTRAINT chk_times CHECK ((open_time IS NULL) = (close_time IS NULL))
+);
+
+CREATE INDEX idx_vendor_hours_overrides_vendor_id ON vendor_hours_overrides (vendor_id, ends_on);
@@ -0,0 +1,11 @@
+DROP INDEX idx_vendors_coordinates;
+
+ALTER TABLE vendors
+    DROP CONSTRAINT chk_coordinates,
+    DROP COLUMN longitude,
+    DROP COLUMN latitude,
+    DROP COLUMN country,
+    DROP COLUMN postal_code,
+    DROP COLUMN city,
+    DROP COLUMN address_line2,
+    DROP COLUMN address_line1;
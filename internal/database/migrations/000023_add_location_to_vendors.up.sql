@@ -0,0 +1,17 @@
+ALTER TABLE vendors
+    ADD COLUMN address_line1 VARCHAR(255) DEFAULT NULL,
+    ADD COLUMN address_line2 VARCHAR(255) DEFAULT NULL,
+    ADD COLUMN city VARCHAR(128) DEFAULT NULL,
+    ADD COLUMN postal_code VARCHAR(32) DEFAULT NULL,
+    ADD COLUMN country VARCHAR(2) DEFAULT NULL,
+    ADD COLUMN latitude DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN longitude DOUBLE PRECISION DEFAULT NULL,
+    ADD CONSTRAINT chk_coordinates CHECK (
+        (latitude IS NULL) = (longitude IS NULL)
+        AND (latitude IS NULL OR latitude BETWEEN -90 AND 90)
+        AND (longitude IS NULL OR longitude BETWEEN -180 AND 180)
+    );
+
+-- Nearby searches narrow down on a bounding box before computing distances,
+-- which this index serves without needing PostGIS.
+CREATE INDEX idx_vendors_coordinates ON vendors (latitude, longitude);
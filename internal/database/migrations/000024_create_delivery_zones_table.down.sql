@@ -0,0 +1,25 @@
+DELETE FROM order_adjustments WHERE type = 'delivery_fee';
+
+ALTER TYPE order_adjustment_type RENAME TO order_adjustment_type_old;
+CREATE TYPE order_adjustment_type AS ENUM ('service_charge', 'tip');
+ALTER TABLE order_adjustments
+    ALTER COLUMN type TYPE order_adjustment_type USING type::text::order_adjustment_type;
+DROP TYPE order_adjustment_type_old;
+
+ALTER TABLE orders
+    DROP COLUMN delivery_fee,
+    DROP COLUMN delivery_distance_km,
+    DROP COLUMN delivery_zone_id,
+    DROP COLUMN delivery_longitude,
+    DROP COLUMN delivery_latitude,
+    DROP COLUMN delivery_address;
+
+ALTER TABLE carts
+    DROP COLUMN delivery_fee,
+    DROP COLUMN delivery_longitude,
+    DROP COLUMN delivery_latitude,
+    DROP COLUMN delivery_address;
+
+DROP TABLE delivery_fee_tiers;
+DROP TABLE delivery_zones;
+DROP TYPE delivery_zone_type;
@@ -0,0 +1,63 @@
+CREATE TYPE delivery_zone_type AS ENUM ('radius', 'polygon');
+
+CREATE TABLE delivery_zones (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id      uuid NOT NULL,
+    name           VARCHAR(255) NOT NULL,
+    zone_type      delivery_zone_type NOT NULL,
+    radius_km      DECIMAL(8,3) DEFAULT NULL,
+    -- Polygon zones are stored as a JSON array of {"lat", "lng"} vertices.
+    polygon        JSONB DEFAULT NULL,
+    display_order  INT NOT NULL DEFAULT 0,
+    is_active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_zone_shape
+        CHECK ((zone_type = 'radius' AND radius_km > 0 AND polygon IS NULL)
+            OR (zone_type = 'polygon' AND polygon IS NOT NULL AND radius_km IS NULL))
+);
+
+CREATE INDEX idx_delivery_zones_vendor_id ON delivery_zones (vendor_id);
+
+CREATE TABLE delivery_fee_tiers (
+    id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    zone_id           uuid NOT NULL,
+    max_distance_km   DECIMAL(8,3) DEFAULT NULL,
+    min_order_amount  DECIMAL(10,2) NOT NULL DEFAULT 0,
+    fee               DECIMAL(10,2) NOT NULL,
+
+    CONSTRAINT fk_zone_id
+    FOREIGN KEY (zone_id)
+        REFERENCES delivery_zones (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_tier_values
+        CHECK ((max_distance_km IS NULL OR max_distance_km > 0) AND min_order_amount >= 0 AND fee >= 0)
+);
+
+CREATE INDEX idx_delivery_fee_tiers_zone_id ON delivery_fee_tiers (zone_id);
+
+ALTER TABLE carts
+    ADD COLUMN delivery_address    TEXT DEFAULT NULL,
+    ADD COLUMN delivery_latitude   DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN delivery_longitude  DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN delivery_fee        DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN delivery_address      TEXT DEFAULT NULL,
+    ADD COLUMN delivery_latitude     DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN delivery_longitude    DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN delivery_zone_id      uuid DEFAULT NULL
+        CONSTRAINT fk_delivery_zone_id
+            REFERENCES delivery_zones (id)
+            ON DELETE SET NULL,
+    ADD COLUMN delivery_distance_km  DECIMAL(8,3) DEFAULT NULL,
+    ADD COLUMN delivery_fee          DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TYPE order_adjustment_type ADD VALUE 'delivery_fee';
@@ -0,0 +1,19 @@
+DROP INDEX idx_orders_vendor_fulfillment;
+
+ALTER TABLE orders
+    DROP COLUMN pickup_at,
+    DROP COLUMN fulfillment_type;
+
+ALTER TABLE carts
+    DROP COLUMN pickup_at,
+    DROP COLUMN table_id,
+    DROP COLUMN fulfillment_type;
+
+ALTER TABLE vendors
+    DROP CONSTRAINT chk_pickup_lead_minutes,
+    DROP COLUMN pickup_lead_minutes,
+    DROP COLUMN delivery_enabled,
+    DROP COLUMN pickup_enabled,
+    DROP COLUMN dine_in_enabled;
+
+DROP TYPE fulfillment_type;
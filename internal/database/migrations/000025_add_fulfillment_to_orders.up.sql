@@ -0,0 +1,29 @@
+CREATE TYPE fulfillment_type AS ENUM ('dine_in', 'pickup', 'delivery');
+
+ALTER TABLE vendors
+    ADD COLUMN dine_in_enabled      BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN pickup_enabled       BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN delivery_enabled     BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN pickup_lead_minutes  INT NOT NULL DEFAULT 15,
+    ADD CONSTRAINT chk_pickup_lead_minutes CHECK (pickup_lead_minutes >= 0);
+
+-- Vendors that already set up delivery zones keep delivering
+UPDATE vendors SET delivery_enabled = TRUE
+WHERE EXISTS (SELECT 1 FROM delivery_zones WHERE delivery_zones.vendor_id = vendors.id);
+
+ALTER TABLE carts
+    ADD COLUMN fulfillment_type  fulfillment_type DEFAULT NULL,
+    ADD COLUMN table_id          uuid DEFAULT NULL
+        CONSTRAINT fk_cart_table_id
+            REFERENCES tables (id)
+            ON DELETE SET NULL,
+    ADD COLUMN pickup_at         TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE orders
+    ADD COLUMN fulfillment_type  fulfillment_type NOT NULL DEFAULT 'dine_in',
+    ADD COLUMN pickup_at         TIMESTAMP DEFAULT NULL;
+
+UPDATE orders SET fulfillment_type = 'delivery' WHERE delivery_latitude IS NOT NULL;
+UPDATE orders SET fulfillment_type = 'pickup' WHERE delivery_latitude IS NULL AND table_id IS NULL;
+
+CREATE INDEX idx_orders_vendor_fulfillment ON orders (vendor_id, fulfillment_type, status);
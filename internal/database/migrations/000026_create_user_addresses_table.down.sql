@@ -0,0 +1,9 @@
+ALTER TABLE orders
+    DROP COLUMN delivery_instructions,
+    DROP COLUMN delivery_address_id;
+
+ALTER TABLE carts
+    DROP COLUMN delivery_instructions,
+    DROP COLUMN delivery_address_id;
+
+DROP TABLE user_addresses;
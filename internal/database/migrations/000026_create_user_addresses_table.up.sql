@@ -0,0 +1,43 @@
+CREATE TABLE user_addresses (
+    id                     uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id                uuid NOT NULL,
+    label                  VARCHAR(64) DEFAULT NULL,
+    address_line1          VARCHAR(255) NOT NULL,
+    address_line2          VARCHAR(255) DEFAULT NULL,
+    city                   VARCHAR(128) DEFAULT NULL,
+    postal_code            VARCHAR(32) DEFAULT NULL,
+    country                VARCHAR(2) DEFAULT NULL,
+    latitude               DOUBLE PRECISION NOT NULL,
+    longitude              DOUBLE PRECISION NOT NULL,
+    delivery_instructions  TEXT DEFAULT NULL,
+    is_default             BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at             TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_user_id
+    FOREIGN KEY (user_id)
+        REFERENCES users (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT chk_coordinates
+        CHECK (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX idx_user_addresses_user_id ON user_addresses (user_id);
+CREATE UNIQUE INDEX idx_user_addresses_default ON user_addresses (user_id) WHERE is_default;
+
+ALTER TABLE carts
+    ADD COLUMN delivery_address_id    uuid DEFAULT NULL
+        CONSTRAINT fk_cart_delivery_address_id
+            REFERENCES user_addresses (id)
+            ON DELETE SET NULL,
+    ADD COLUMN delivery_instructions  TEXT DEFAULT NULL;
+
+-- Orders keep their own copy of the address; the id only records where it
+-- came from.
+ALTER TABLE orders
+    ADD COLUMN delivery_address_id    uuid DEFAULT NULL
+        CONSTRAINT fk_order_delivery_address_id
+            REFERENCES user_addresses (id)
+            ON DELETE SET NULL,
+    ADD COLUMN delivery_instructions  TEXT DEFAULT NULL;
@@ -0,0 +1,15 @@
+DROP TABLE reservation_tables;
+DROP TABLE reservations;
+DROP TYPE reservation_status;
+
+ALTER TABLE vendors
+    DROP CONSTRAINT chk_reservation_minutes,
+    DROP COLUMN reservation_interval_minutes,
+    DROP COLUMN reservation_turn_minutes;
+
+ALTER TABLE tables
+    DROP CONSTRAINT chk_capacity,
+    DROP COLUMN is_combinable,
+    DROP COLUMN is_reservable,
+    DROP COLUMN min_capacity,
+    DROP COLUMN capacity;
@@ -0,0 +1,73 @@
+-- Needed to mix uuid equality with range overlap in exclusion constraints
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE tables
+    ADD COLUMN capacity       INT NOT NULL DEFAULT 4,
+    ADD COLUMN min_capacity   INT NOT NULL DEFAULT 1,
+    ADD COLUMN is_reservable  BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN is_combinable  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD CONSTRAINT chk_capacity CHECK (min_capacity >= 1 AND capacity >= min_capacity);
+
+ALTER TABLE vendors
+    ADD COLUMN reservation_turn_minutes      INT NOT NULL DEFAULT 90,
+    ADD COLUMN reservation_interval_minutes  INT NOT NULL DEFAULT 15,
+    ADD CONSTRAINT chk_reservation_minutes
+        CHECK (reservation_turn_minutes > 0 AND reservation_interval_minutes > 0);
+
+CREATE TYPE reservation_status AS ENUM ('pending', 'confirmed', 'declined', 'cancelled', 'seated', 'completed', 'no_show');
+
+CREATE TABLE reservations (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id    uuid NOT NULL,
+    customer_id  uuid DEFAULT NULL,
+    party_size   INT NOT NULL,
+    starts_at    TIMESTAMP NOT NULL,
+    ends_at      TIMESTAMP NOT NULL,
+    status       reservation_status NOT NULL DEFAULT 'pending',
+    name         VARCHAR(255) DEFAULT NULL,
+    phone        VARCHAR(32) DEFAULT NULL,
+    notes        TEXT DEFAULT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_customer_id
+    FOREIGN KEY (customer_id)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT chk_party_size CHECK (party_size > 0),
+    CONSTRAINT chk_period CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_reservations_vendor_starts_at ON reservations (vendor_id, starts_at);
+CREATE INDEX idx_reservations_customer_id ON reservations (customer_id);
+
+-- The tables held by a reservation. Rows stay active while the booking
+-- holds its tables, and the exclusion constraint keeps two active bookings
+-- from overlapping on the same table.
+CREATE TABLE reservation_tables (
+    reservation_id  uuid NOT NULL,
+    table_id        uuid NOT NULL,
+    during          tsrange NOT NULL,
+    is_active       BOOLEAN NOT NULL DEFAULT TRUE,
+
+    PRIMARY KEY (reservation_id, table_id),
+
+    CONSTRAINT fk_reservation_id
+    FOREIGN KEY (reservation_id)
+        REFERENCES reservations (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_table_id
+    FOREIGN KEY (table_id)
+        REFERENCES tables (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT excl_table_booking
+        EXCLUDE USING gist (table_id WITH =, during WITH &&) WHERE (is_active)
+);
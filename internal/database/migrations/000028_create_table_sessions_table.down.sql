@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_table_session_id;
+
+ALTER TABLE orders
+    DROP COLUMN table_session_id;
+
+DROP TABLE table_session_tables;
+DROP TABLE table_sessions;
@@ -0,0 +1,74 @@
+CREATE TABLE table_sessions (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id       uuid NOT NULL,
+    party_size      INT NOT NULL,
+    server_id       uuid DEFAULT NULL,
+    customer_id     uuid DEFAULT NULL,
+    reservation_id  uuid DEFAULT NULL,
+    merged_into_id  uuid DEFAULT NULL,
+    opened_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at       TIMESTAMP DEFAULT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_server_id
+    FOREIGN KEY (server_id)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_customer_id
+    FOREIGN KEY (customer_id)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_reservation_id
+    FOREIGN KEY (reservation_id)
+        REFERENCES reservations (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_merged_into_id
+    FOREIGN KEY (merged_into_id)
+        REFERENCES table_sessions (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT chk_party_size CHECK (party_size > 0),
+    CONSTRAINT chk_closed_at CHECK (closed_at IS NULL OR closed_at >= opened_at)
+);
+
+CREATE INDEX idx_table_sessions_vendor_opened_at ON table_sessions (vendor_id, opened_at);
+
+-- Where a session has sat. Transfers and merges end one row and start
+-- another, and a table can only be held by one session at a time.
+CREATE TABLE table_session_tables (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id  uuid NOT NULL,
+    table_id    uuid NOT NULL,
+    seated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    left_at     TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT fk_session_id
+    FOREIGN KEY (session_id)
+        REFERENCES table_sessions (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_table_id
+    FOREIGN KEY (table_id)
+        REFERENCES tables (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_table_session_tables_session_id ON table_session_tables (session_id);
+CREATE UNIQUE INDEX idx_table_session_tables_occupied ON table_session_tables (table_id) WHERE left_at IS NULL;
+
+ALTER TABLE orders
+    ADD COLUMN table_session_id  uuid DEFAULT NULL
+        CONSTRAINT fk_order_table_session_id
+            REFERENCES table_sessions (id)
+            ON DELETE SET NULL;
+
+CREATE INDEX idx_orders_table_session_id ON orders (table_session_id);
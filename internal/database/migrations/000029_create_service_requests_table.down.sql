@@ -0,0 +1,8 @@
+ALTER TABLE tables ADD COLUMN is_needs_service BOOLEAN DEFAULT FALSE;
+
+UPDATE tables SET is_needs_service = TRUE
+WHERE id IN (SELECT table_id FROM service_requests WHERE status IN ('open', 'acknowledged'));
+
+DROP TABLE service_requests;
+DROP TYPE service_request_status;
+DROP TYPE service_request_type;
@@ -0,0 +1,64 @@
+CREATE TYPE service_request_type AS ENUM ('water', 'bill', 'help');
+CREATE TYPE service_request_status AS ENUM ('open', 'acknowledged', 'resolved', 'cancelled');
+
+CREATE TABLE service_requests (
+    id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id         uuid NOT NULL,
+    table_id          uuid NOT NULL,
+    table_session_id  uuid DEFAULT NULL,
+    customer_id       uuid DEFAULT NULL,
+    type              service_request_type NOT NULL,
+    status            service_request_status NOT NULL DEFAULT 'open',
+    note              TEXT DEFAULT NULL,
+    acknowledged_at   TIMESTAMP DEFAULT NULL,
+    acknowledged_by   uuid DEFAULT NULL,
+    resolved_at       TIMESTAMP DEFAULT NULL,
+    resolved_by       uuid DEFAULT NULL,
+    created_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at        TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_table_id
+    FOREIGN KEY (table_id)
+        REFERENCES tables (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_table_session_id
+    FOREIGN KEY (table_session_id)
+        REFERENCES table_sessions (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_customer_id
+    FOREIGN KEY (customer_id)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_acknowledged_by
+    FOREIGN KEY (acknowledged_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_resolved_by
+    FOREIGN KEY (resolved_by)
+        REFERENCES users (id)
+        ON DELETE SET NULL
+);
+
+-- The live queue only looks at requests nobody has dealt with yet
+CREATE INDEX idx_service_requests_queue ON service_requests (vendor_id, created_at)
+    WHERE status IN ('open', 'acknowledged');
+CREATE INDEX idx_service_requests_vendor_created_at ON service_requests (vendor_id, created_at);
+
+-- Pressing the same button twice shouldn't queue the table twice
+CREATE UNIQUE INDEX idx_service_requests_pending ON service_requests (table_id, type)
+    WHERE status IN ('open', 'acknowledged');
+
+-- Tables that were flagged keep a request so staff still see them
+INSERT INTO service_requests (vendor_id, table_id, type)
+SELECT vendor_id, id, 'help' FROM tables WHERE is_needs_service;
+
+ALTER TABLE tables DROP COLUMN is_needs_service;
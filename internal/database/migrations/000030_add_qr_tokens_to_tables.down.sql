@@ -0,0 +1,8 @@
+ALTER TABLE users
+    DROP COLUMN guest_token_version,
+    DROP COLUMN guest_table_id,
+    DROP COLUMN is_guest;
+
+ALTER TABLE tables
+    DROP COLUMN qr_token_rotated_at,
+    DROP COLUMN qr_token_version;
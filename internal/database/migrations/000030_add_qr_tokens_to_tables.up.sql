@@ -0,0 +1,15 @@
+-- Table QR tokens are signed with the table's current version; bumping the
+-- version invalidates every token printed before.
+ALTER TABLE tables
+    ADD COLUMN qr_token_version     INT NOT NULL DEFAULT 1,
+    ADD COLUMN qr_token_rotated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+-- Guests are users created from a table's QR code. They only stay signed in
+-- while the token they scanned is still valid.
+ALTER TABLE users
+    ADD COLUMN is_guest             BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN guest_table_id       uuid DEFAULT NULL
+        CONSTRAINT fk_guest_table_id
+            REFERENCES tables (id)
+            ON DELETE SET NULL,
+    ADD COLUMN guest_token_version  INT DEFAULT NULL;
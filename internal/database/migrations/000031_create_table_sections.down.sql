@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_tables_section_id;
+
+ALTER TABLE tables
+    DROP CONSTRAINT IF EXISTS chk_rotation,
+    DROP COLUMN IF EXISTS rotation,
+    DROP COLUMN IF EXISTS shape,
+    DROP COLUMN IF EXISTS pos_y,
+    DROP COLUMN IF EXISTS pos_x,
+    DROP COLUMN IF EXISTS section_id;
+
+DROP TABLE IF EXISTS table_sections;
+
+DROP TYPE IF EXISTS table_shape;
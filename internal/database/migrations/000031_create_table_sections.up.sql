@@ -0,0 +1,33 @@
+CREATE TYPE table_shape AS ENUM ('rectangle', 'square', 'round');
+
+-- Sections group a vendor's tables into areas of the floor (patio, bar,
+-- upstairs).
+CREATE TABLE table_sections (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    sort_order  INT NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_table_sections_vendor_name UNIQUE (vendor_id, name)
+);
+
+-- Layout positions are in floor plan units, with the origin at the top left.
+ALTER TABLE tables
+    ADD COLUMN section_id  uuid DEFAULT NULL
+        CONSTRAINT fk_section_id
+            REFERENCES table_sections (id)
+            ON DELETE SET NULL,
+    ADD COLUMN pos_x       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN pos_y       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN shape       table_shape NOT NULL DEFAULT 'rectangle',
+    ADD COLUMN rotation    INT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_rotation CHECK (rotation >= 0 AND rotation < 360);
+
+CREATE INDEX idx_tables_section_id ON tables (section_id);
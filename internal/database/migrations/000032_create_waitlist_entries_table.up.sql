@@ -0,0 +1,42 @@
+CREATE TYPE waitlist_status AS ENUM ('waiting', 'notified', 'seated', 'cancelled', 'no_show');
+
+-- Walk-in parties waiting for a table. The token lets the guest look up
+-- their place in line without signing in.
+CREATE TABLE waitlist_entries (
+    id                   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id            uuid NOT NULL,
+    customer_id          uuid DEFAULT NULL,
+    party_name           VARCHAR(255) NOT NULL,
+    party_size           INT NOT NULL,
+    phone                VARCHAR(50) DEFAULT NULL,
+    notes                TEXT DEFAULT NULL,
+    quoted_wait_minutes  INT NOT NULL DEFAULT 0,
+    status               waitlist_status NOT NULL DEFAULT 'waiting',
+    token                VARCHAR(64) NOT NULL UNIQUE,
+    notified_at          TIMESTAMP DEFAULT NULL,
+    seated_at            TIMESTAMP DEFAULT NULL,
+    table_session_id     uuid DEFAULT NULL,
+    created_at           TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at           TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_customer_id
+    FOREIGN KEY (customer_id)
+        REFERENCES users (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT fk_table_session_id
+    FOREIGN KEY (table_session_id)
+        REFERENCES table_sessions (id)
+        ON DELETE SET NULL,
+
+    CONSTRAINT chk_party_size CHECK (party_size > 0),
+    CONSTRAINT chk_quoted_wait_minutes CHECK (quoted_wait_minutes >= 0)
+);
+
+CREATE INDEX idx_waitlist_entries_queue ON waitlist_entries (vendor_id, created_at)
+    WHERE status IN ('waiting', 'notified');
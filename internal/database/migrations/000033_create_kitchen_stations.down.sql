@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS kitchen_ticket_lines;
+DROP TABLE IF EXISTS kitchen_tickets;
+DROP TYPE IF EXISTS kitchen_status;
+DROP TABLE IF EXISTS kitchen_station_categories;
+DROP TABLE IF EXISTS kitchen_station_items;
+DROP TABLE IF EXISTS kitchen_stations;
+
+-- Postgres cannot drop an enum value; ready orders fall back to preparing.
+UPDATE orders SET status = 'preparing' WHERE status = 'ready';
@@ -0,0 +1,111 @@
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'ready';
+
+-- Kitchen stations (grill, bar, pass) each see only the order lines routed
+-- to them. Lines nothing routes go to the vendor's default station.
+CREATE TABLE kitchen_stations (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    is_default  BOOLEAN NOT NULL DEFAULT FALSE,
+    sort_order  INT NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_kitchen_stations_vendor_name UNIQUE (vendor_id, name)
+);
+
+CREATE UNIQUE INDEX idx_kitchen_stations_default ON kitchen_stations (vendor_id) WHERE is_default;
+
+-- An item goes to one station. Items routed directly win over their
+-- categories.
+CREATE TABLE kitchen_station_items (
+    item_id     uuid PRIMARY KEY,
+    station_id  uuid NOT NULL,
+
+    CONSTRAINT fk_item_id
+    FOREIGN KEY (item_id)
+        REFERENCES items (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_station_id
+    FOREIGN KEY (station_id)
+        REFERENCES kitchen_stations (id)
+        ON DELETE CASCADE
+);
+
+CREATE TABLE kitchen_station_categories (
+    category_id  uuid PRIMARY KEY,
+    station_id   uuid NOT NULL,
+
+    CONSTRAINT fk_category_id
+    FOREIGN KEY (category_id)
+        REFERENCES categories (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_station_id
+    FOREIGN KEY (station_id)
+        REFERENCES kitchen_stations (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_kitchen_station_items_station_id ON kitchen_station_items (station_id);
+CREATE INDEX idx_kitchen_station_categories_station_id ON kitchen_station_categories (station_id);
+
+CREATE TYPE kitchen_status AS ENUM ('pending', 'bumped');
+
+-- A ticket is the part of an order one station has to make.
+CREATE TABLE kitchen_tickets (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id    uuid NOT NULL,
+    vendor_id   uuid NOT NULL,
+    station_id  uuid NOT NULL,
+    status      kitchen_status NOT NULL DEFAULT 'pending',
+    priority    INT NOT NULL DEFAULT 0,
+    bumped_at   TIMESTAMP DEFAULT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_order_id
+    FOREIGN KEY (order_id)
+        REFERENCES orders (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_station_id
+    FOREIGN KEY (station_id)
+        REFERENCES kitchen_stations (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_kitchen_tickets_order_station UNIQUE (order_id, station_id)
+);
+
+CREATE INDEX idx_kitchen_tickets_station_status ON kitchen_tickets (station_id, status, created_at);
+
+CREATE TABLE kitchen_ticket_lines (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticket_id      uuid NOT NULL,
+    order_item_id  uuid NOT NULL,
+    status         kitchen_status NOT NULL DEFAULT 'pending',
+    bumped_at      TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT fk_ticket_id
+    FOREIGN KEY (ticket_id)
+        REFERENCES kitchen_tickets (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_order_item_id
+    FOREIGN KEY (order_item_id)
+        REFERENCES order_items (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_kitchen_ticket_lines_ticket_id ON kitchen_ticket_lines (ticket_id);
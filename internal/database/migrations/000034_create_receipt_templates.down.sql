@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS receipt_templates;
+
+DROP TYPE IF EXISTS receipt_format;
+DROP TYPE IF EXISTS receipt_kind;
@@ -0,0 +1,21 @@
+CREATE TYPE receipt_kind AS ENUM ('receipt', 'kitchen');
+CREATE TYPE receipt_format AS ENUM ('text', 'html');
+
+-- A vendor's own layout for a receipt or kitchen ticket. Without one the
+-- built-in layout is used.
+CREATE TABLE receipt_templates (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id   uuid NOT NULL,
+    kind        receipt_kind NOT NULL,
+    format      receipt_format NOT NULL,
+    body        TEXT NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_receipt_templates_vendor_kind_format UNIQUE (vendor_id, kind, format)
+);
@@ -0,0 +1,98 @@
+-- Events are written to the outbox in the same transaction as the change
+-- they describe, then fanned out to the vendor's webhook endpoints.
+CREATE TABLE outbox_events (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id      uuid NOT NULL,
+    type           VARCHAR(100) NOT NULL,
+    payload        JSONB NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at  TIMESTAMP DEFAULT NULL,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_outbox_events_undispatched ON outbox_events (created_at) WHERE dispatched_at IS NULL;
+
+CREATE TABLE webhook_endpoints (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    vendor_id    uuid NOT NULL,
+    url          TEXT NOT NULL,
+    secret       VARCHAR(100) NOT NULL,
+    description  TEXT DEFAULT NULL,
+    is_active    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_vendor_id
+    FOREIGN KEY (vendor_id)
+        REFERENCES vendors (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_endpoints_vendor_id ON webhook_endpoints (vendor_id);
+
+-- The event types an endpoint subscribes to. An endpoint without any gets
+-- every event.
+CREATE TABLE webhook_endpoint_events (
+    endpoint_id  uuid NOT NULL,
+    event_type   VARCHAR(100) NOT NULL,
+
+    PRIMARY KEY (endpoint_id, event_type),
+
+    CONSTRAINT fk_endpoint_id
+    FOREIGN KEY (endpoint_id)
+        REFERENCES webhook_endpoints (id)
+        ON DELETE CASCADE
+);
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'succeeded', 'dead');
+
+CREATE TABLE webhook_deliveries (
+    id                    uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id              uuid NOT NULL,
+    endpoint_id           uuid NOT NULL,
+    status                webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts              INT NOT NULL DEFAULT 0,
+    next_attempt_at       TIMESTAMP NOT NULL,
+    last_attempt_at       TIMESTAMP DEFAULT NULL,
+    last_response_status  INT DEFAULT NULL,
+    last_error            TEXT DEFAULT NULL,
+    created_at            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_event_id
+    FOREIGN KEY (event_id)
+        REFERENCES outbox_events (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT fk_endpoint_id
+    FOREIGN KEY (endpoint_id)
+        REFERENCES webhook_endpoints (id)
+        ON DELETE CASCADE,
+
+    CONSTRAINT uq_webhook_deliveries_event_endpoint UNIQUE (event_id, endpoint_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_endpoint_id ON webhook_deliveries (endpoint_id, created_at);
+
+CREATE TABLE webhook_delivery_attempts (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    delivery_id      uuid NOT NULL,
+    attempt          INT NOT NULL,
+    response_status  INT DEFAULT NULL,
+    response_body    TEXT DEFAULT NULL,
+    error            TEXT DEFAULT NULL,
+    duration_ms      INT NOT NULL DEFAULT 0,
+    created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_delivery_id
+    FOREIGN KEY (delivery_id)
+        REFERENCES webhook_deliveries (id)
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts (delivery_id, created_at);
@@ -0,0 +1,576 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidModifiers = errors.New("invalid modifier selection")
+
+var modifierGroupColumns = []string{
+	"id", "vendor_id", "name", "selection_type", "min_selections", "max_selections", "is_required", "created_at", "updated_at",
+}
+
+var modifierOptionColumns = []string{
+	"id", "group_id", "name", "price_delta", "display_order", "is_active", "created_at", "updated_at",
+}
+
+func (s *service) ListModifierGroups(queryParams url.Values) ([]types.ModifierGroup, *types.Meta, error) {
+	var groups []types.ModifierGroup
+
+	meta, err := s.BuildQuery(
+		&groups,
+		"modifier_groups",
+		[]string{},
+		modifierGroupColumns,
+		[]string{"name"},
+		queryParams,
+		[]string{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list modifier groups: %w", err)
+	}
+
+	if groups == nil {
+		groups = []types.ModifierGroup{}
+	}
+
+	return groups, meta, nil
+}
+
+func (s *service) GetModifierGroupByID(id string) (*types.ModifierGroup, error) {
+	var group types.ModifierGroup
+	query, args, err := QB.Select(strings.Join(modifierGroupColumns, ", ")).
+		From("modifier_groups").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&group, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("modifier group not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch modifier group: %w", err)
+	}
+
+	if err := attachModifierOptions(s.db, []*types.ModifierGroup{&group}); err != nil {
+		return nil, fmt.Errorf("failed to fetch modifier options: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (s *service) CreateModifierGroup(group types.ModifierGroup) (*types.ModifierGroup, error) {
+	if group.VendorId == uuid.Nil || group.Name == "" {
+		return nil, errors.New("missing required parameters")
+	}
+	minSelections, isRequired := 0, false
+	setModifierGroupDefaults(&group, types.ModifierGroup{MinSelections: &minSelections, IsRequired: &isRequired})
+	if err := normalizeModifierGroup(&group); err != nil {
+		return nil, err
+	}
+
+	group.ID = uuid.New()
+	group.Created_at = time.Now()
+	group.Updated_at = time.Now()
+
+	query, args, err := QB.Insert("modifier_groups").
+		Columns(modifierGroupColumns...).
+		Values(group.ID, group.VendorId, group.Name, group.SelectionType, group.MinSelections, group.MaxSelections,
+			group.IsRequired, group.Created_at, group.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(modifierGroupColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	if err := s.db.QueryRowx(query, args...).StructScan(&group); err != nil {
+		return nil, fmt.Errorf("error inserting modifier group: %w", err)
+	}
+
+	return &group, nil
+}
+
+func (s *service) UpdateModifierGroup(id string, group types.ModifierGroup) (*types.ModifierGroup, error) {
+	existing, err := s.GetModifierGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Turning a single choice group into a multiple one lifts its limit of one
+	// unless a new one is given
+	if group.MaxSelections == nil && existing.SelectionType == "single" && group.SelectionType == "multiple" {
+		existing.MaxSelections = nil
+	}
+	setModifierGroupDefaults(&group, *existing)
+	if err := normalizeModifierGroup(&group); err != nil {
+		return nil, err
+	}
+
+	query, args, err := QB.Update("modifier_groups").
+		Set("name", group.Name).
+		Set("selection_type", group.SelectionType).
+		Set("min_selections", group.MinSelections).
+		Set("max_selections", group.MaxSelections).
+		Set("is_required", group.IsRequired).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating modifier group: %w", err)
+	}
+
+	return s.GetModifierGroupByID(id)
+}
+
+func (s *service) DeleteModifierGroup(id string) error {
+	_, err := deleteById(s, id, "modifier_groups")
+	if err != nil {
+		return fmt.Errorf("error deleting modifier group: %w", err)
+	}
+	return nil
+}
+
+// setModifierGroupDefaults fills in the fields a modifier group payload left
+// out.
+func setModifierGroupDefaults(group *types.ModifierGroup, defaults types.ModifierGroup) {
+	if group.Name == "" {
+		group.Name = defaults.Name
+	}
+	if group.SelectionType == "" {
+		group.SelectionType = defaults.SelectionType
+	}
+	if group.MinSelections == nil {
+		group.MinSelections = defaults.MinSelections
+	}
+	if group.MaxSelections == nil {
+		group.MaxSelections = defaults.MaxSelections
+	}
+	if group.IsRequired == nil {
+		group.IsRequired = defaults.IsRequired
+	}
+}
+
+// normalizeModifierGroup makes the selection bounds agree with the group's
+// type and required flag.
+func normalizeModifierGroup(group *types.ModifierGroup) error {
+	switch group.SelectionType {
+	case "":
+		group.SelectionType = "multiple"
+	case "single":
+		one := 1
+		group.MaxSelections = &one
+	case "multiple":
+	default:
+		return errors.New("selection_type must be single or multiple")
+	}
+
+	if *group.IsRequired && *group.MinSelections < 1 {
+		one := 1
+		group.MinSelections = &one
+	}
+	if *group.MinSelections < 0 {
+		return errors.New("min_selections cannot be negative")
+	}
+	if group.MaxSelections != nil && *group.MaxSelections < *group.MinSelections {
+		return errors.New("max_selections must be at least min_selections")
+	}
+	return nil
+}
+
+func (s *service) CreateModifierOption(groupID string, option types.ModifierOption) (*types.ModifierOption, error) {
+	group, err := s.GetModifierGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if option.Name == "" {
+		return nil, errors.New("missing required parameters")
+	}
+	priceDelta, displayOrder, isActive := 0.0, 0, true
+	setModifierOptionDefaults(&option, types.ModifierOption{PriceDelta: &priceDelta, DisplayOrder: &displayOrder, IsActive: &isActive})
+
+	option.ID = uuid.New()
+	option.GroupId = group.ID
+	option.Created_at = time.Now()
+	option.Updated_at = time.Now()
+
+	query, args, err := QB.Insert("modifier_options").
+		Columns(modifierOptionColumns...).
+		Values(option.ID, option.GroupId, option.Name, option.PriceDelta, option.DisplayOrder, option.IsActive,
+			option.Created_at, option.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(modifierOptionColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	if err := s.db.QueryRowx(query, args...).StructScan(&option); err != nil {
+		return nil, fmt.Errorf("error inserting modifier option: %w", err)
+	}
+
+	return &option, nil
+}
+
+func (s *service) GetModifierOptionByID(id string) (*types.ModifierOption, error) {
+	var option types.ModifierOption
+	query, args, err := QB.Select(strings.Join(modifierOptionColumns, ", ")).
+		From("modifier_options").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&option, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("modifier option not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch modifier option: %w", err)
+	}
+	return &option, nil
+}
+
+func (s *service) UpdateModifierOption(id string, option types.ModifierOption) (*types.ModifierOption, error) {
+	existing, err := s.GetModifierOptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	setModifierOptionDefaults(&option, *existing)
+
+	query, args, err := QB.Update("modifier_options").
+		Set("name", option.Name).
+		Set("price_delta", option.PriceDelta).
+		Set("display_order", option.DisplayOrder).
+		Set("is_active", option.IsActive).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(modifierOptionColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	var updated types.ModifierOption
+	if err := s.db.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		return nil, fmt.Errorf("error updating modifier option: %w", err)
+	}
+
+	return &updated, nil
+}
+
+// setModifierOptionDefaults fills in the fields a modifier option payload
+// left out.
+func setModifierOptionDefaults(option *types.ModifierOption, defaults types.ModifierOption) {
+	if option.Name == "" {
+		option.Name = defaults.Name
+	}
+	if option.PriceDelta == nil {
+		option.PriceDelta = defaults.PriceDelta
+	}
+	if option.DisplayOrder == nil {
+		option.DisplayOrder = defaults.DisplayOrder
+	}
+	if option.IsActive == nil {
+		option.IsActive = defaults.IsActive
+	}
+}
+
+func (s *service) DeleteModifierOption(id string) error {
+	_, err := deleteById(s, id, "modifier_options")
+	if err != nil {
+		return fmt.Errorf("error deleting modifier option: %w", err)
+	}
+	return nil
+}
+
+// SetItemModifierGroups replaces the modifier groups offered with an item,
+// in the order given.
+func (s *service) SetItemModifierGroups(itemID string, groupIDs []uuid.UUID) ([]types.ModifierGroup, error) {
+	item, err := s.GetItemByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if len(groupIDs) > 0 {
+		var count int
+		query, args, err := QB.Select("COUNT(*)").
+			From("modifier_groups").
+			Where(squirrel.Eq{"id": groupIDs, "vendor_id": item.VendorId}).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Get(&count, query, args...); err != nil {
+			return nil, err
+		}
+		if count != len(groupIDs) {
+			return nil, errors.New("modifier groups must exist and belong to the item's vendor")
+		}
+	}
+
+	query, args, err := QB.Delete("item_modifier_groups").Where("item_id = ?", item.ID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, err
+	}
+
+	if len(groupIDs) > 0 {
+		insert := QB.Insert("item_modifier_groups").Columns("item_id", "group_id", "display_order")
+		for i, groupID := range groupIDs {
+			insert = insert.Values(item.ID, groupID, i)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error assigning modifier groups: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetItemModifierGroups(item.ID, true)
+}
+
+// GetItemModifierGroups returns the groups offered with an item along with
+// their options. Customers only get the active ones, admins can ask for all.
+func (s *service) GetItemModifierGroups(itemID uuid.UUID, includeInactive bool) ([]types.ModifierGroup, error) {
+	return itemModifierGroups(s.db, itemID, includeInactive)
+}
+
+func itemModifierGroups(q sqlx.Queryer, itemID uuid.UUID, includeInactive bool) ([]types.ModifierGroup, error) {
+	columns := make([]string, len(modifierGroupColumns))
+	for i, column := range modifierGroupColumns {
+		columns[i] = "modifier_groups." + column
+	}
+
+	groups := []types.ModifierGroup{}
+	query, args, err := QB.Select(columns...).
+		From("modifier_groups").
+		Join("item_modifier_groups ON item_modifier_groups.group_id = modifier_groups.id").
+		Where("item_modifier_groups.item_id = ?", itemID).
+		OrderBy("item_modifier_groups.display_order", "modifier_groups.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &groups, query, args...); err != nil {
+		return nil, err
+	}
+
+	refs := make([]*types.ModifierGroup, len(groups))
+	for i := range groups {
+		refs[i] = &groups[i]
+	}
+	if err := attachModifierOptions(q, refs); err != nil {
+		return nil, err
+	}
+	if includeInactive {
+		return groups, nil
+	}
+
+	for i := range groups {
+		active := groups[i].Options[:0]
+		for _, option := range groups[i].Options {
+			if *option.IsActive {
+				active = append(active, option)
+			}
+		}
+		groups[i].Options = active
+	}
+
+	return groups, nil
+}
+
+func attachModifierOptions(q sqlx.Queryer, groups []*types.ModifierGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(groups))
+	byID := make(map[uuid.UUID]*types.ModifierGroup, len(groups))
+	for i, group := range groups {
+		ids[i] = group.ID
+		byID[group.ID] = group
+		group.Options = []types.ModifierOption{}
+	}
+
+	var options []types.ModifierOption
+	query, args, err := QB.Select(modifierOptionColumns...).
+		From("modifier_options").
+		Where(squirrel.Eq{"group_id": ids}).
+		OrderBy("display_order", "name").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Select(q, &options, query, args...); err != nil {
+		return err
+	}
+
+	for _, option := range options {
+		group := byID[option.GroupId]
+		group.Options = append(group.Options, option)
+	}
+	return nil
+}
+
+// validateModifierSelection checks the chosen options against the groups
+// offered with the item and returns the key that identifies the selection
+// on a cart line.
+func validateModifierSelection(q sqlx.Queryer, itemID uuid.UUID, optionIDs []uuid.UUID) (string, error) {
+	groups, err := itemModifierGroups(q, itemID, false)
+	if err != nil {
+		return "", err
+	}
+
+	groupOf := make(map[uuid.UUID]uuid.UUID)
+	for _, group := range groups {
+		for _, option := range group.Options {
+			groupOf[option.ID] = group.ID
+		}
+	}
+
+	chosen := make(map[uuid.UUID]int)
+	seen := make(map[uuid.UUID]bool)
+	keys := make([]string, 0, len(optionIDs))
+	for _, optionID := range optionIDs {
+		groupID, ok := groupOf[optionID]
+		if !ok {
+			return "", fmt.Errorf("%w: option %s is not available for this item", ErrInvalidModifiers, optionID)
+		}
+		if seen[optionID] {
+			continue
+		}
+		seen[optionID] = true
+		chosen[groupID]++
+		keys = append(keys, optionID.String())
+	}
+
+	for _, group := range groups {
+		count := chosen[group.ID]
+		if count < *group.MinSelections {
+			return "", fmt.Errorf("%w: choose at least %d from %s", ErrInvalidModifiers, *group.MinSelections, group.Name)
+		}
+		if group.MaxSelections != nil && count > *group.MaxSelections {
+			return "", fmt.Errorf("%w: choose at most %d from %s", ErrInvalidModifiers, *group.MaxSelections, group.Name)
+		}
+	}
+
+	sort.Strings(keys)
+	return strings.Join(keys, ","), nil
+}
+
+// checkCartModifiers validates the modifiers on every line of a cart again,
+// since options and groups may have changed since they were chosen.
+func checkCartModifiers(q sqlx.Queryer, cartID uuid.UUID) error {
+	var lines []struct {
+		ItemId      uuid.UUID `db:"item_id"`
+		ItemName    string    `db:"name"`
+		ModifierKey string    `db:"modifier_key"`
+	}
+	query, args, err := QB.Select("cart_items.item_id", "items.name", "cart_items.modifier_key").
+		From("cart_items").
+		Join("items ON items.id = cart_items.item_id").
+		Where("cart_items.cart_id = ?", cartID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Select(q, &lines, query, args...); err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		var optionIDs []uuid.UUID
+		if line.ModifierKey != "" {
+			for _, optionID := range strings.Split(line.ModifierKey, ",") {
+				id, err := uuid.Parse(optionID)
+				if err != nil {
+					return fmt.Errorf("%w: %s has an unreadable selection", ErrInvalidModifiers, line.ItemName)
+				}
+				optionIDs = append(optionIDs, id)
+			}
+		}
+		if _, err := validateModifierSelection(q, line.ItemId, optionIDs); err != nil {
+			return fmt.Errorf("%s: %w", line.ItemName, err)
+		}
+	}
+	return nil
+}
+
+// cartItemModifiers loads the modifiers chosen on the given cart lines,
+// keyed by cart line.
+func cartItemModifiers(q sqlx.Queryer, cartItemIDs []uuid.UUID) (map[uuid.UUID][]types.CartItemModifier, error) {
+	modifiers := make(map[uuid.UUID][]types.CartItemModifier)
+	if len(cartItemIDs) == 0 {
+		return modifiers, nil
+	}
+
+	var rows []types.CartItemModifier
+	query, args, err := QB.Select(
+		"cart_item_modifiers.cart_item_id",
+		"cart_item_modifiers.modifier_option_id",
+		"modifier_groups.name AS group_name",
+		"modifier_options.name",
+		"modifier_options.price_delta",
+	).
+		From("cart_item_modifiers").
+		Join("modifier_options ON modifier_options.id = cart_item_modifiers.modifier_option_id").
+		Join("modifier_groups ON modifier_groups.id = modifier_options.group_id").
+		Where(squirrel.Eq{"cart_item_modifiers.cart_item_id": cartItemIDs}).
+		OrderBy("modifier_groups.name", "modifier_options.display_order").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		modifiers[row.CartItemId] = append(modifiers[row.CartItemId], row)
+	}
+	return modifiers, nil
+}
+
+func createOrderItemModifiers(tx *sqlx.Tx, orderItemID uuid.UUID, modifiers []types.CartItemModifier) error {
+	if len(modifiers) == 0 {
+		return nil
+	}
+
+	insert := QB.Insert("order_item_modifiers").
+		Columns("order_item_id", "modifier_option_id", "group_name", "name", "price_delta")
+	for _, modifier := range modifiers {
+		insert = insert.Values(orderItemID, modifier.ModifierOptionId, modifier.GroupName, modifier.Name, modifier.PriceDelta)
+	}
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
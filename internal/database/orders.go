@@ -1,10 +1,25 @@
 package database
 
 import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
 	"net/url"
 	"restaurant-management-backend/internal/types"
 )
 
+// ErrInvalidOrderStatus is returned for unknown statuses and for orders that
+// can no longer change status.
+var ErrInvalidOrderStatus = errors.New("invalid order status")
+
+var orderStatuses = map[string]bool{
+	"pending_payment": true,
+	"preparing":       true,
+	"ready":           true,
+	"completed":       true,
+	"cancelled":       true,
+}
+
 func (s *service) FetchOrders(queryParams map[string][]string) ([]types.Order, types.Meta, error) {
 	var orders []types.Order
 
@@ -16,11 +31,23 @@ func (s *service) FetchOrders(queryParams map[string][]string) ([]types.Order, t
 	}
 
 	columns := []string{
-		"id", "total_order_cost", "vendor_id", "customer_id", "status", "created_at", "updated_at",
+		"id", "subtotal", "discount_total", "tax_total", "service_charge_total", "tip_total", "total_order_cost",
+		"refunded_total", "net_total", "vendor_id", "customer_id", "table_id", "party_size", "status", "payment_status", "created_at", "updated_at",
+		"delivery_address", "delivery_latitude", "delivery_longitude", "delivery_zone_id", "delivery_distance_km", "delivery_fee",
+		"fulfillment_type", "pickup_at", "delivery_address_id", "delivery_instructions", "table_session_id",
 	}
 
 	searchColumns := []string{"id", "status"}
 
+	// Kitchens route tickets by how the order leaves the building
+	var additionalFilters []string
+	if fulfillmentType := urlValues.Get("fulfillment_type"); fulfillmentType != "" {
+		if !fulfillmentTypes[fulfillmentType] {
+			return nil, types.Meta{}, fmt.Errorf("%w: fulfillment_type must be dine_in, pickup or delivery", ErrInvalidFulfillment)
+		}
+		additionalFilters = append(additionalFilters, fmt.Sprintf("fulfillment_type = '%s'", fulfillmentType))
+	}
+
 	meta, err := s.BuildQuery(
 		&orders,
 		"orders",
@@ -28,7 +55,7 @@ func (s *service) FetchOrders(queryParams map[string][]string) ([]types.Order, t
 		columns,
 		searchColumns,
 		urlValues,
-		[]string{},
+		additionalFilters,
 	)
 
 	if err != nil {
@@ -72,15 +99,109 @@ func (s *service) AttachOrderItems(order *types.Order) error {
 	if err != nil {
 		return err
 	}
+
+	for i := range orderItems {
+		query, args, err := QB.Select("*").From("order_item_taxes").Where("order_item_id = ?", orderItems[i].ID).ToSql()
+		if err != nil {
+			return err
+		}
+		if err := s.db.Select(&orderItems[i].Taxes, query, args...); err != nil {
+			return err
+		}
+
+		query, args, err = QB.Select("*").From("order_item_modifiers").Where("order_item_id = ?", orderItems[i].ID).ToSql()
+		if err != nil {
+			return err
+		}
+		if err := s.db.Select(&orderItems[i].Modifiers, query, args...); err != nil {
+			return err
+		}
+	}
+
 	order.OrderItems = orderItems
+	if err := s.attachOrderDiscounts(order); err != nil {
+		return err
+	}
+	if err := s.attachOrderAdjustments(order); err != nil {
+		return err
+	}
+	if err := s.attachOrderPayments(order); err != nil {
+		return err
+	}
+	return s.attachOrderRefunds(order)
+}
+
+func (s *service) attachOrderDiscounts(order *types.Order) error {
+	var discounts []types.OrderDiscount
+	query, args, err := QB.Select("*").From("order_discounts").Where("order_id = ?", order.ID).ToSql()
+	if err != nil {
+		return err
+	}
+	if err := s.db.Select(&discounts, query, args...); err != nil {
+		return err
+	}
+	order.Discounts = discounts
 	return nil
 }
 
+// UpdateOrderStatus moves an order to a new status. Cancelling an order puts
+// the stock it took back on the shelves, so a cancelled order stays cancelled.
 func (s *service) UpdateOrderStatus(id, status string) error {
-	query, args, err := QB.Update("orders").Set("status", status).Where("id = ?", id).ToSql()
+	if !orderStatuses[status] {
+		return fmt.Errorf("%w: %q", ErrInvalidOrderStatus, status)
+	}
+
+	tx, err := s.db.Beginx()
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec(query, args...)
-	return err
+	defer tx.Rollback()
+
+	var previous string
+	if err := tx.Get(&previous, "SELECT status FROM orders WHERE id = $1 FOR UPDATE", id); err != nil {
+		return err
+	}
+	if previous == "cancelled" && status != "cancelled" {
+		return fmt.Errorf("%w: a cancelled order cannot be reopened", ErrInvalidOrderStatus)
+	}
+
+	query, args, err := QB.Update("orders").Set("status", status).Where("id = ?", id).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return err
+	}
+	var orderID uuid.UUID
+	if err := tx.Get(&orderID, query, args...); err != nil {
+		return err
+	}
+
+	if status == "cancelled" {
+		if err := restockOrder(tx, orderID); err != nil {
+			return err
+		}
+	}
+
+	if err := publishOrderEvent(tx, orderID, "order.updated"); err != nil {
+		return err
+	}
+
+	if previous != status {
+		if err := enqueueOrderEvent(tx, orderID, OrderStatusChangedEvent, previous); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *service) attachOrderAdjustments(order *types.Order) error {
+	var adjustments []types.OrderAdjustment
+	query, args, err := QB.Select("*").From("order_adjustments").Where("order_id = ?", order.ID).OrderBy("created_at").ToSql()
+	if err != nil {
+		return err
+	}
+	if err := s.db.Select(&adjustments, query, args...); err != nil {
+		return err
+	}
+	order.Adjustments = adjustments
+	return nil
 }
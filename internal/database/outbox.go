@@ -0,0 +1,84 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+// Event types vendors can subscribe their webhooks to.
+const (
+	OrderCreatedEvent       = "order.created"
+	OrderStatusChangedEvent = "order.status_changed"
+	WebhookTestEvent        = "webhook.test"
+)
+
+var webhookEventTypes = map[string]bool{
+	OrderCreatedEvent:       true,
+	OrderStatusChangedEvent: true,
+}
+
+// orderWebhookData is the payload of the order events.
+type orderWebhookData struct {
+	Order          types.Order `json:"order"`
+	PreviousStatus string      `json:"previous_status,omitempty"`
+}
+
+// enqueueEvent writes an event to the outbox. Called inside the transaction
+// making the change, the event exists exactly when the change does, and the
+// webhook dispatcher picks it up after the commit.
+func enqueueEvent(q sqlx.Execer, vendorID uuid.UUID, eventType string, data interface{}) (uuid.UUID, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	id := uuid.New()
+	query, args, err := QB.Insert("outbox_events").
+		Columns("id", "vendor_id", "type", "payload", "created_at").
+		Values(id, vendorID, eventType, string(payload), time.Now()).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := q.Exec(query, args...); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to write %s event: %w", eventType, err)
+	}
+	return id, nil
+}
+
+// enqueueOrderEvent writes the order as it now stands, items included, to
+// the outbox. previousStatus is left out of the payload when empty.
+func enqueueOrderEvent(q sqlx.Ext, orderID uuid.UUID, eventType, previousStatus string) error {
+	query, args, err := QB.Select("*").From("orders").Where("id = ?", orderID).ToSql()
+	if err != nil {
+		return err
+	}
+	var order types.Order
+	if err := sqlx.Get(q, &order, query, args...); err != nil {
+		return fmt.Errorf("failed to load order for event: %w", err)
+	}
+
+	query, args, err = QB.Select("*").From("order_items").Where("order_id = ?", orderID).OrderBy("id").ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Select(q, &order.OrderItems, query, args...); err != nil {
+		return fmt.Errorf("failed to load order items for event: %w", err)
+	}
+	for i := range order.OrderItems {
+		query, args, err := QB.Select("*").From("order_item_modifiers").Where("order_item_id = ?", order.OrderItems[i].ID).ToSql()
+		if err != nil {
+			return err
+		}
+		if err := sqlx.Select(q, &order.OrderItems[i].Modifiers, query, args...); err != nil {
+			return fmt.Errorf("failed to load order item modifiers for event: %w", err)
+		}
+	}
+
+	_, err = enqueueEvent(q, order.VendorId, eventType, orderWebhookData{Order: order, PreviousStatus: previousStatus})
+	return err
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDuplicatePaymentEvent = errors.New("payment event already processed")
+	ErrOrderAlreadyPaid      = errors.New("order is already paid")
+)
+
+var paymentColumns = []string{
+	"id", "order_id", "provider", "provider_ref", "method", "status", "amount",
+	"captured_amount", "refunded_amount", "failure_reason", "created_at", "updated_at",
+}
+
+// CreatePayment opens a pending payment for whatever is still owed on an
+// order. The order row stays locked until the payment is written, and pending
+// payments count as owed money, so two checkouts racing each other cannot
+// both charge the full balance.
+func (s *service) CreatePayment(orderID uuid.UUID, provider, method string) (types.Payment, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return types.Payment{}, err
+	}
+	defer tx.Rollback()
+
+	var total float64
+	if err := tx.Get(&total, `SELECT total_order_cost FROM orders WHERE id = $1 FOR UPDATE`, orderID); err != nil {
+		return types.Payment{}, fmt.Errorf("error locking order: %w", err)
+	}
+
+	var covered float64
+	query, args, err := QB.Select(`COALESCE(SUM(CASE
+			WHEN status IN ('pending', 'authorized') THEN amount
+			WHEN status IN ('captured', 'partially_refunded') THEN captured_amount
+			ELSE 0
+		END), 0)`).
+		From("payments").
+		Where(squirrel.Eq{"order_id": orderID}).
+		ToSql()
+	if err != nil {
+		return types.Payment{}, fmt.Errorf("error building payments query: %w", err)
+	}
+	if err := tx.Get(&covered, query, args...); err != nil {
+		return types.Payment{}, fmt.Errorf("error summing payments: %w", err)
+	}
+
+	outstanding := roundMoney(total - covered)
+	if outstanding <= 0 {
+		return types.Payment{}, ErrOrderAlreadyPaid
+	}
+
+	payment := types.Payment{
+		ID:         uuid.New(),
+		OrderId:    orderID,
+		Provider:   provider,
+		Method:     method,
+		Status:     "pending",
+		Amount:     outstanding,
+		Created_at: time.Now(),
+		Updated_at: time.Now(),
+	}
+
+	query, args, err = QB.Insert("payments").
+		Columns("id", "order_id", "provider", "method", "status", "amount", "created_at", "updated_at").
+		Values(payment.ID, payment.OrderId, payment.Provider, payment.Method, payment.Status, payment.Amount, payment.Created_at, payment.Updated_at).
+		ToSql()
+	if err != nil {
+		return payment, fmt.Errorf("error building insert query: %w", err)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return payment, fmt.Errorf("error inserting payment: %w", err)
+	}
+
+	return payment, tx.Commit()
+}
+
+func (s *service) GetPaymentByID(id string) (*types.Payment, error) {
+	return s.getPayment(squirrel.Eq{"id": id})
+}
+
+func (s *service) GetPaymentByProviderRef(provider, reference string) (*types.Payment, error) {
+	return s.getPayment(squirrel.Eq{"provider": provider, "provider_ref": reference})
+}
+
+func (s *service) getPayment(where squirrel.Eq) (*types.Payment, error) {
+	var payment types.Payment
+	query, args, err := QB.Select(strings.Join(paymentColumns, ", ")).
+		From("payments").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&payment, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("payment not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch payment: %w", err)
+	}
+	return &payment, nil
+}
+
+func (s *service) ListOrderPayments(orderID string) ([]types.Payment, error) {
+	payments := []types.Payment{}
+	query, args, err := QB.Select(paymentColumns...).
+		From("payments").
+		Where(squirrel.Eq{"order_id": orderID}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Select(&payments, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	return payments, nil
+}
+
+// UpdatePayment saves the new state of a payment together with the ledger
+// entry that explains it, and refreshes the payment status of its order.
+// Transactions carrying an event id that was already recorded are ignored
+// and reported as ErrDuplicatePaymentEvent.
+func (s *service) UpdatePayment(payment types.Payment, transaction types.PaymentTransaction) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	transaction.ID = uuid.New()
+	transaction.PaymentId = payment.ID
+	if transaction.Status == "" {
+		transaction.Status = payment.Status
+	}
+	if transaction.ProviderRef == nil {
+		transaction.ProviderRef = payment.ProviderRef
+	}
+
+	var payload interface{}
+	if len(transaction.Payload) > 0 {
+		payload = string(transaction.Payload)
+	}
+
+	query, args, err := QB.Insert("payment_transactions").
+		Columns("id", "payment_id", "type", "amount", "status", "provider_ref", "event_id", "payload").
+		Values(transaction.ID, transaction.PaymentId, transaction.Type, roundMoney(transaction.Amount), transaction.Status,
+			transaction.ProviderRef, transaction.EventId, payload).
+		Suffix("ON CONFLICT (event_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("error recording payment transaction: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrDuplicatePaymentEvent
+	}
+
+	query, args, err = QB.Update("payments").
+		Set("provider_ref", payment.ProviderRef).
+		Set("status", payment.Status).
+		Set("captured_amount", roundMoney(payment.CapturedAmount)).
+		Set("refunded_amount", roundMoney(payment.RefundedAmount)).
+		Set("failure_reason", payment.FailureReason).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": payment.ID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("error updating payment: %w", err)
+	}
+
+	if err := refreshOrderPaymentStatus(tx, payment.OrderId); err != nil {
+		return fmt.Errorf("error updating order payment status: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// refreshOrderPaymentStatus derives the order's payment status from its
+// payments. Orders waiting on payment go to the kitchen once money is secured.
+func refreshOrderPaymentStatus(tx *sqlx.Tx, orderID uuid.UUID) error {
+	query, args, err := QB.Update("orders").
+		Set("payment_status", squirrel.Expr(`(
+			SELECT CASE
+				WHEN SUM(p.refunded_amount) > 0 AND SUM(p.refunded_amount) >= SUM(p.captured_amount) THEN 'refunded'
+				WHEN SUM(p.refunded_amount) > 0 THEN 'partially_refunded'
+				WHEN SUM(p.captured_amount) > 0 AND SUM(p.captured_amount) >= orders.total_order_cost THEN 'paid'
+				WHEN COUNT(*) FILTER (WHERE p.status IN ('authorized', 'captured')) > 0 THEN 'authorized'
+				ELSE 'unpaid'
+			END::order_payment_status
+			FROM payments p WHERE p.order_id = orders.id)`)).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": orderID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+
+	query, args, err = QB.Update("orders").
+		Set("status", "preparing").
+		Where(squirrel.Eq{"id": orderID, "status": "pending_payment"}).
+		Where(squirrel.Eq{"payment_status": []string{"authorized", "paid"}}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		if err := enqueueOrderEvent(tx, orderID, OrderStatusChangedEvent, "pending_payment"); err != nil {
+			return err
+		}
+	}
+	return publishOrderEvent(tx, orderID, "order.updated")
+}
+
+func (s *service) attachOrderPayments(order *types.Order) error {
+	payments, err := s.ListOrderPayments(order.ID.String())
+	if err != nil {
+		return err
+	}
+	order.Payments = payments
+	return nil
+}
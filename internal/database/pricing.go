@@ -0,0 +1,173 @@
+package database
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+// cartLine is a single cart row joined with everything needed to price it.
+type cartLine struct {
+	CartItemId  uuid.UUID  `db:"cart_item_id"`
+	ItemId      uuid.UUID  `db:"item_id"`
+	VariantId   uuid.UUID  `db:"variant_id"`
+	VariantName string     `db:"variant_name"`
+	Sku         *string    `db:"sku"`
+	VendorId    uuid.UUID  `db:"vendor_id"`
+	Quantity    int        `db:"quantity"`
+	Price       float64    `db:"price"`
+	TaxClassId  *uuid.UUID `db:"tax_class_id"`
+	TaxName     *string    `db:"tax_name"`
+	TaxRate     float64    `db:"tax_rate"`
+	IsInclusive bool       `db:"prices_include_tax"`
+
+	// Amount and ListedDiscount are in menu prices, Net, Discount and Tax
+	// are the accounting figures derived from them.
+	Amount         float64 `db:"-"`
+	ListedDiscount float64 `db:"-"`
+	Net            float64 `db:"-"`
+	Discount       float64 `db:"-"`
+	Tax            float64 `db:"-"`
+	Total          float64 `db:"-"`
+
+	Modifiers []types.CartItemModifier `db:"-"`
+}
+
+type cartPricing struct {
+	Lines         []cartLine
+	Quantity      int
+	Subtotal      float64
+	DiscountTotal float64
+	TaxTotal      float64
+	Total         float64
+
+	Coupon *types.Coupon
+	// CouponErr is set when the cart holds a coupon that no longer applies.
+	CouponErr error
+}
+
+// priceCart loads the lines of a cart and works out discounts and tax for
+// each of them. A line's unit price is the variant price plus its modifiers.
+// Items without a tax class fall back to the vendor's default
+// class. Subtotal is before discounts, Total is what the customer pays.
+func priceCart(q sqlx.Queryer, cartID uuid.UUID) (cartPricing, error) {
+	var pricing cartPricing
+
+	query, args, err := QB.
+		Select(
+			"cart_items.id AS cart_item_id",
+			"cart_items.item_id",
+			"cart_items.variant_id",
+			"item_variants.name AS variant_name",
+			"item_variants.sku",
+			"items.vendor_id",
+			"cart_items.quantity",
+			"item_variants.price + COALESCE((SELECT SUM(modifier_options.price_delta) FROM cart_item_modifiers "+
+				"JOIN modifier_options ON modifier_options.id = cart_item_modifiers.modifier_option_id "+
+				"WHERE cart_item_modifiers.cart_item_id = cart_items.id), 0) AS price",
+			"tax_classes.id AS tax_class_id",
+			"tax_classes.name AS tax_name",
+			"COALESCE(tax_classes.rate, 0) AS tax_rate",
+			"vendors.prices_include_tax",
+		).
+		From("cart_items").
+		Join("items ON cart_items.item_id = items.id").
+		Join("item_variants ON cart_items.variant_id = item_variants.id").
+		Join("vendors ON items.vendor_id = vendors.id").
+		LeftJoin("tax_classes ON tax_classes.id = COALESCE(items.tax_class_id, "+
+			"(SELECT d.id FROM tax_classes d WHERE d.vendor_id = items.vendor_id AND d.is_default))").
+		Where("cart_items.cart_id = ?", cartID).
+		ToSql()
+	if err != nil {
+		return pricing, err
+	}
+
+	if err := sqlx.Select(q, &pricing.Lines, query, args...); err != nil {
+		return pricing, err
+	}
+
+	cartItemIDs := make([]uuid.UUID, len(pricing.Lines))
+	for i, line := range pricing.Lines {
+		cartItemIDs[i] = line.CartItemId
+	}
+	modifiers, err := cartItemModifiers(q, cartItemIDs)
+	if err != nil {
+		return pricing, err
+	}
+
+	for i := range pricing.Lines {
+		line := &pricing.Lines[i]
+		line.Amount = roundMoney(line.Price * float64(line.Quantity))
+		line.Modifiers = modifiers[line.CartItemId]
+	}
+
+	pricing.Coupon, err = loadCartCoupon(q, cartID)
+	if err != nil {
+		return pricing, err
+	}
+	if pricing.Coupon != nil {
+		// carts are keyed by their owner's user id
+		pricing.CouponErr = applyCoupon(q, pricing.Coupon, cartID, pricing.Lines, time.Now())
+	}
+
+	for i := range pricing.Lines {
+		line := &pricing.Lines[i]
+		grossNet, _ := calculateTax(line.Amount, line.TaxRate, line.IsInclusive)
+		line.Net, line.Tax = calculateTax(line.Amount-line.ListedDiscount, line.TaxRate, line.IsInclusive)
+		line.Discount = roundMoney(grossNet - line.Net)
+		line.Total = roundMoney(line.Net + line.Tax)
+
+		pricing.Quantity += line.Quantity
+		pricing.Subtotal += grossNet
+		pricing.DiscountTotal += line.Discount
+		pricing.TaxTotal += line.Tax
+		pricing.Total += line.Total
+	}
+	pricing.Subtotal = roundMoney(pricing.Subtotal)
+	pricing.DiscountTotal = roundMoney(pricing.DiscountTotal)
+	pricing.TaxTotal = roundMoney(pricing.TaxTotal)
+	pricing.Total = roundMoney(pricing.Total)
+
+	return pricing, nil
+}
+
+// ListedTotal is what the lines come to at menu prices after discounts, the
+// amount customers see and minimum order values are checked against.
+func (p cartPricing) ListedTotal() float64 {
+	var total float64
+	for _, line := range p.Lines {
+		total += line.Amount - line.ListedDiscount
+	}
+	return roundMoney(total)
+}
+
+// recalculateCart stores the cart totals, including the delivery fee when the
+// cart has a delivery address.
+func recalculateCart(db sqlx.Ext, cartID uuid.UUID) (cartPricing, error) {
+	pricing, err := priceCart(db, cartID)
+	if err != nil {
+		return pricing, err
+	}
+
+	deliveryFee, err := cartDeliveryFee(db, cartID, pricing)
+	if err != nil {
+		return pricing, err
+	}
+
+	query, args, err := QB.Update("carts").
+		Set("subtotal", pricing.Subtotal).
+		Set("discount_total", pricing.DiscountTotal).
+		Set("tax_total", pricing.TaxTotal).
+		Set("delivery_fee", deliveryFee).
+		Set("total_price", roundMoney(pricing.Total+deliveryFee)).
+		Set("quantity", pricing.Quantity).
+		Set("updated_at", time.Now()).
+		Where("id = ?", cartID).
+		ToSql()
+	if err != nil {
+		return pricing, err
+	}
+	_, err = db.Exec(query, args...)
+	return pricing, err
+}
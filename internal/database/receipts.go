@@ -0,0 +1,332 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"restaurant-management-backend/internal/receipts"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReceiptTemplate  = receipts.ErrInvalidTemplate
+	ErrReceiptTemplateNotFound = errors.New("receipt template not found")
+)
+
+var receiptTemplateColumns = []string{"id", "vendor_id", "kind", "format", "body", "created_at", "updated_at"}
+
+// OrderReceipt gathers what is printed on an order's receipt and kitchen
+// ticket. The paper width is left for the caller to fill in.
+func (s *service) OrderReceipt(orderID string) (*types.Receipt, error) {
+	order, err := s.FetchOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var vendor struct {
+		Name             string  `db:"name"`
+		AddressLine1     *string `db:"address_line1"`
+		AddressLine2     *string `db:"address_line2"`
+		City             *string `db:"city"`
+		PostalCode       *string `db:"postal_code"`
+		Timezone         string  `db:"timezone"`
+		PricesIncludeTax bool    `db:"prices_include_tax"`
+	}
+	query, args, err := QB.Select("name", "address_line1", "address_line2", "city", "postal_code", "timezone", "prices_include_tax").
+		From("vendors").
+		Where("id = ?", order.VendorId).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Get(&vendor, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load vendor for receipt: %w", err)
+	}
+	loc, err := time.LoadLocation(vendor.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	receipt := &types.Receipt{
+		Vendor:           types.ReceiptVendor{Name: vendor.Name, AddressLines: []string{}},
+		OrderId:          order.ID,
+		Number:           strings.ToUpper(order.ID.String()[:8]),
+		Status:           order.Status,
+		PaymentStatus:    order.PaymentStatus,
+		FulfillmentType:  order.FulfillmentType,
+		PlacedAt:         order.Created_at.In(loc),
+		PrintedAt:        time.Now().In(loc),
+		Subtotal:         order.Subtotal,
+		DiscountTotal:    order.DiscountTotal,
+		TaxTotal:         order.TaxTotal,
+		Total:            order.TotalOrderCost,
+		RefundedTotal:    order.RefundedTotal,
+		NetTotal:         order.NetTotal,
+		PricesIncludeTax: vendor.PricesIncludeTax,
+	}
+	for _, line := range []*string{vendor.AddressLine1, vendor.AddressLine2} {
+		if line != nil && *line != "" {
+			receipt.Vendor.AddressLines = append(receipt.Vendor.AddressLines, *line)
+		}
+	}
+	var cityLine []string
+	for _, part := range []*string{vendor.City, vendor.PostalCode} {
+		if part != nil && *part != "" {
+			cityLine = append(cityLine, *part)
+		}
+	}
+	if len(cityLine) > 0 {
+		receipt.Vendor.AddressLines = append(receipt.Vendor.AddressLines, strings.Join(cityLine, " "))
+	}
+
+	if order.PartySize != nil {
+		receipt.PartySize = *order.PartySize
+	}
+	if order.PickupAt != nil {
+		pickupAt := order.PickupAt.In(loc)
+		receipt.PickupAt = &pickupAt
+	}
+	if order.DeliveryAddress != nil {
+		receipt.DeliveryAddress = *order.DeliveryAddress
+	}
+	if order.DeliveryInstructions != nil {
+		receipt.DeliveryInstructions = *order.DeliveryInstructions
+	}
+	if order.TableId != nil {
+		if err := s.db.Get(&receipt.TableName, "SELECT name FROM tables WHERE id = $1", *order.TableId); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	if receipt.Lines, err = s.receiptLines(order.ID); err != nil {
+		return nil, err
+	}
+
+	receipt.Discounts = []types.ReceiptAmount{}
+	query, args, err = QB.Select("COALESCE(description, code) AS label", "amount").
+		From("order_discounts").
+		Where("order_id = ?", order.ID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&receipt.Discounts, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load receipt discounts: %w", err)
+	}
+
+	receipt.Taxes = []types.ReceiptTax{}
+	query, args, err = QB.Select("order_item_taxes.name", "order_item_taxes.rate", "order_item_taxes.is_inclusive",
+		"SUM(order_item_taxes.amount) AS amount").
+		From("order_item_taxes").
+		Join("order_items ON order_items.id = order_item_taxes.order_item_id").
+		Where("order_items.order_id = ?", order.ID).
+		GroupBy("order_item_taxes.name", "order_item_taxes.rate", "order_item_taxes.is_inclusive").
+		OrderBy("order_item_taxes.name", "order_item_taxes.rate").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&receipt.Taxes, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load receipt taxes: %w", err)
+	}
+
+	receipt.Adjustments = []types.ReceiptAmount{}
+	query, args, err = QB.Select("name AS label", "amount").
+		From("order_adjustments").
+		Where("order_id = ?", order.ID).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&receipt.Adjustments, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load receipt adjustments: %w", err)
+	}
+
+	return receipt, nil
+}
+
+func (s *service) receiptLines(orderID uuid.UUID) ([]types.ReceiptLine, error) {
+	var rows []struct {
+		ID          uuid.UUID `db:"id"`
+		Name        string    `db:"name"`
+		VariantName *string   `db:"variant_name"`
+		Quantity    int       `db:"quantity"`
+		Price       float64   `db:"price"`
+	}
+	query, args, err := QB.Select("order_items.id", "items.name", "order_items.variant_name", "order_items.quantity", "order_items.price").
+		From("order_items").
+		Join("items ON items.id = order_items.item_id").
+		Where("order_items.order_id = ?", orderID).
+		OrderBy("items.name", "order_items.id").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load receipt lines: %w", err)
+	}
+
+	var modifiers []types.OrderItemModifier
+	query, args, err = QB.Select("order_item_modifiers.*").
+		From("order_item_modifiers").
+		Join("order_items ON order_items.id = order_item_modifiers.order_item_id").
+		Where("order_items.order_id = ?", orderID).
+		OrderBy("order_item_modifiers.group_name", "order_item_modifiers.name").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&modifiers, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load receipt modifiers: %w", err)
+	}
+	byOrderItem := make(map[uuid.UUID][]types.ReceiptModifier)
+	for _, modifier := range modifiers {
+		byOrderItem[modifier.OrderItemId] = append(byOrderItem[modifier.OrderItemId], types.ReceiptModifier{
+			GroupName:  modifier.GroupName,
+			Name:       modifier.Name,
+			PriceDelta: modifier.PriceDelta,
+		})
+	}
+
+	lines := make([]types.ReceiptLine, len(rows))
+	for i, row := range rows {
+		lines[i] = types.ReceiptLine{
+			Name:      row.Name,
+			Quantity:  row.Quantity,
+			UnitPrice: row.Price,
+			Amount:    roundMoney(row.Price * float64(row.Quantity)),
+			Modifiers: byOrderItem[row.ID],
+		}
+		if row.VariantName != nil {
+			lines[i].VariantName = *row.VariantName
+		}
+		if lines[i].Modifiers == nil {
+			lines[i].Modifiers = []types.ReceiptModifier{}
+		}
+	}
+	return lines, nil
+}
+
+// ListReceiptTemplates returns a template for every kind and format: the
+// vendor's own where they have one, the built-in layout otherwise.
+func (s *service) ListReceiptTemplates(vendorID uuid.UUID) ([]types.ReceiptTemplate, error) {
+	query, args, err := QB.Select(receiptTemplateColumns...).
+		From("receipt_templates").
+		Where("vendor_id = ?", vendorID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var custom []types.ReceiptTemplate
+	if err := s.db.Select(&custom, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list receipt templates: %w", err)
+	}
+	byKey := make(map[string]types.ReceiptTemplate, len(custom))
+	for _, template := range custom {
+		template.IsCustom = true
+		byKey[template.Kind+"/"+template.Format] = template
+	}
+
+	templates := make([]types.ReceiptTemplate, 0, len(receipts.Kinds)*len(receipts.Formats))
+	for _, kind := range receipts.Kinds {
+		for _, format := range receipts.Formats {
+			if template, ok := byKey[kind+"/"+format]; ok {
+				templates = append(templates, template)
+				continue
+			}
+			body, _ := receipts.DefaultTemplate(kind, format)
+			templates = append(templates, types.ReceiptTemplate{VendorId: vendorID, Kind: kind, Format: format, Body: body})
+		}
+	}
+	return templates, nil
+}
+
+// ReceiptTemplate returns the template the vendor prints kind in format
+// with.
+func (s *service) ReceiptTemplate(vendorID uuid.UUID, kind, format string) (*types.ReceiptTemplate, error) {
+	if err := checkReceiptTemplateKey(kind, format); err != nil {
+		return nil, err
+	}
+
+	query, args, err := QB.Select(receiptTemplateColumns...).
+		From("receipt_templates").
+		Where("vendor_id = ? AND kind = ? AND format = ?", vendorID, kind, format).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var template types.ReceiptTemplate
+	if err := s.db.Get(&template, query, args...); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		body, _ := receipts.DefaultTemplate(kind, format)
+		return &types.ReceiptTemplate{VendorId: vendorID, Kind: kind, Format: format, Body: body}, nil
+	}
+	template.IsCustom = true
+	return &template, nil
+}
+
+// SaveReceiptTemplate replaces the vendor's layout for kind in format. The
+// template must render a sample order without errors.
+func (s *service) SaveReceiptTemplate(vendorID uuid.UUID, kind, format, body string) (*types.ReceiptTemplate, error) {
+	if err := checkReceiptTemplateKey(kind, format); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("%w: body is required", ErrInvalidReceiptTemplate)
+	}
+	if err := receipts.Validate(format, body); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query, args, err := QB.Insert("receipt_templates").
+		Columns("id", "vendor_id", "kind", "format", "body", "created_at", "updated_at").
+		Values(uuid.New(), vendorID, kind, format, body, now, now).
+		Suffix("ON CONFLICT (vendor_id, kind, format) DO UPDATE SET body = EXCLUDED.body, updated_at = EXCLUDED.updated_at " +
+			"RETURNING " + strings.Join(receiptTemplateColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var saved types.ReceiptTemplate
+	if err := s.db.QueryRowx(query, args...).StructScan(&saved); err != nil {
+		return nil, fmt.Errorf("failed to save receipt template: %w", err)
+	}
+	saved.IsCustom = true
+	return &saved, nil
+}
+
+// DeleteReceiptTemplate goes back to the built-in layout for kind in format.
+func (s *service) DeleteReceiptTemplate(vendorID uuid.UUID, kind, format string) error {
+	if err := checkReceiptTemplateKey(kind, format); err != nil {
+		return err
+	}
+
+	query, args, err := QB.Delete("receipt_templates").
+		Where("vendor_id = ? AND kind = ? AND format = ?", vendorID, kind, format).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete receipt template: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrReceiptTemplateNotFound
+	}
+	return nil
+}
+
+func checkReceiptTemplateKey(kind, format string) error {
+	if _, ok := receipts.DefaultTemplate(kind, format); !ok {
+		return fmt.Errorf("%w: kind must be receipt or kitchen and format text or html", ErrInvalidReceiptTemplate)
+	}
+	return nil
+}
@@ -0,0 +1,331 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"math"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+var ErrInvalidRefund = errors.New("invalid refund")
+
+type refundableLine struct {
+	ID               uuid.UUID `db:"id"`
+	Quantity         int       `db:"quantity"`
+	RefundedQuantity int       `db:"refunded_quantity"`
+	Total            float64   `db:"total"`
+}
+
+// CreateRefund reserves a refund against an order and returns it as pending.
+// Without lines the whole remaining balance is refunded, otherwise each line
+// is refunded pro rata of what the customer paid for it. The caller moves
+// the money and then settles the refund with CompleteRefund.
+func (s *service) CreateRefund(orderID string, request types.RefundRequest, approvedBy uuid.UUID) (types.Refund, error) {
+	refund := types.Refund{
+		ID:         uuid.New(),
+		Reason:     request.Reason,
+		Status:     "pending",
+		ApprovedBy: &approvedBy,
+		Created_at: time.Now(),
+		Updated_at: time.Now(),
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return refund, err
+	}
+	defer tx.Rollback()
+
+	var order struct {
+		ID             uuid.UUID `db:"id"`
+		TotalOrderCost float64   `db:"total_order_cost"`
+		RefundedTotal  float64   `db:"refunded_total"`
+	}
+	query, args, err := QB.Select("id", "total_order_cost", "refunded_total").
+		From("orders").
+		Where(squirrel.Eq{"id": orderID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return refund, err
+	}
+	if err := tx.Get(&order, query, args...); err != nil {
+		return refund, fmt.Errorf("order not found: %w", err)
+	}
+	refund.OrderId = order.ID
+
+	var pending float64
+	query, args, err = QB.Select("COALESCE(SUM(amount), 0)").
+		From("refunds").
+		Where(squirrel.Eq{"order_id": order.ID, "status": "pending"}).
+		ToSql()
+	if err != nil {
+		return refund, err
+	}
+	if err := tx.Get(&pending, query, args...); err != nil {
+		return refund, err
+	}
+
+	remaining := roundMoney(order.TotalOrderCost - order.RefundedTotal - pending)
+	if remaining <= 0 {
+		return refund, fmt.Errorf("%w: order has already been refunded in full", ErrInvalidRefund)
+	}
+
+	var lines []refundableLine
+	query, args, err = QB.Select("id", "quantity", "refunded_quantity", "total").
+		From("order_items").
+		Where(squirrel.Eq{"order_id": order.ID}).
+		ToSql()
+	if err != nil {
+		return refund, err
+	}
+	if err := tx.Select(&lines, query, args...); err != nil {
+		return refund, err
+	}
+
+	byID := make(map[uuid.UUID]refundableLine, len(lines))
+	for _, line := range lines {
+		byID[line.ID] = line
+	}
+
+	if len(request.Items) == 0 {
+		for _, line := range lines {
+			if quantity := line.Quantity - line.RefundedQuantity; quantity > 0 {
+				refund.Items = append(refund.Items, types.RefundItem{
+					OrderItemId: line.ID,
+					Quantity:    quantity,
+					Amount:      roundMoney(line.Total * float64(quantity) / float64(line.Quantity)),
+				})
+			}
+		}
+		refund.Amount = remaining
+	} else {
+		requested := make(map[uuid.UUID]int)
+		for _, item := range request.Items {
+			requested[item.OrderItemId] += item.Quantity
+		}
+		for orderItemID, quantity := range requested {
+			line, ok := byID[orderItemID]
+			if !ok {
+				return refund, fmt.Errorf("%w: item %s is not part of this order", ErrInvalidRefund, orderItemID)
+			}
+			if quantity <= 0 || quantity > line.Quantity-line.RefundedQuantity {
+				return refund, fmt.Errorf("%w: only %d of item %s can still be refunded",
+					ErrInvalidRefund, line.Quantity-line.RefundedQuantity, orderItemID)
+			}
+			amount := roundMoney(line.Total * float64(quantity) / float64(line.Quantity))
+			refund.Items = append(refund.Items, types.RefundItem{
+				OrderItemId: orderItemID,
+				Quantity:    quantity,
+				Amount:      amount,
+			})
+			refund.Amount += amount
+		}
+		refund.Amount = roundMoney(refund.Amount)
+		if refund.Amount > remaining {
+			refund.Amount = remaining
+		}
+	}
+
+	if refund.Amount <= 0 {
+		return refund, fmt.Errorf("%w: nothing to refund", ErrInvalidRefund)
+	}
+
+	query, args, err = QB.Insert("refunds").
+		Columns("id", "order_id", "amount", "reason", "status", "approved_by", "created_at", "updated_at").
+		Values(refund.ID, refund.OrderId, refund.Amount, refund.Reason, refund.Status, refund.ApprovedBy, refund.Created_at, refund.Updated_at).
+		ToSql()
+	if err != nil {
+		return refund, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return refund, fmt.Errorf("error inserting refund: %w", err)
+	}
+
+	for i := range refund.Items {
+		item := &refund.Items[i]
+		item.ID = uuid.New()
+		item.RefundId = refund.ID
+
+		query, args, err := QB.Insert("refund_items").
+			Columns("id", "refund_id", "order_item_id", "quantity", "amount").
+			Values(item.ID, item.RefundId, item.OrderItemId, item.Quantity, item.Amount).
+			ToSql()
+		if err != nil {
+			return refund, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return refund, fmt.Errorf("error inserting refund item: %w", err)
+		}
+
+		query, args, err = QB.Update("order_items").
+			Set("refunded_quantity", squirrel.Expr("refunded_quantity + ?", item.Quantity)).
+			Where(squirrel.Eq{"id": item.OrderItemId}).
+			ToSql()
+		if err != nil {
+			return refund, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return refund, err
+		}
+	}
+
+	return refund, tx.Commit()
+}
+
+// CompleteRefund settles a pending refund with the amount that went back to
+// the customer, which may fall short of what was asked for. The failure
+// reason says why it did. A short refund keeps only the share of each line
+// it paid for and releases the rest, so those lines can be refunded again;
+// a refund of nothing has failed and releases them all.
+func (s *service) CompleteRefund(refundID uuid.UUID, refunded float64, failureReason *string) (types.Refund, error) {
+	var refund types.Refund
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return refund, err
+	}
+	defer tx.Rollback()
+
+	var requested float64
+	query, args, err := QB.Select("amount").
+		From("refunds").
+		Where(squirrel.Eq{"id": refundID, "status": "pending"}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return refund, err
+	}
+	if err := tx.Get(&requested, query, args...); err != nil {
+		return refund, fmt.Errorf("pending refund not found: %w", err)
+	}
+
+	refunded = math.Min(roundMoney(refunded), requested)
+	status := "completed"
+	if refunded <= 0 {
+		status = "failed"
+	}
+
+	update := QB.Update("refunds").
+		Set("status", status).
+		Set("failure_reason", failureReason)
+	if status == "completed" {
+		update = update.Set("amount", refunded)
+	}
+	query, args, err = update.
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": refundID}).
+		Suffix("RETURNING *").
+		ToSql()
+	if err != nil {
+		return refund, err
+	}
+	if err := tx.Get(&refund, query, args...); err != nil {
+		return refund, err
+	}
+
+	if status == "completed" {
+		query, args, err = QB.Update("orders").
+			Set("refunded_total", squirrel.Expr("refunded_total + ?", refund.Amount)).
+			Set("updated_at", time.Now()).
+			Where(squirrel.Eq{"id": refund.OrderId}).
+			ToSql()
+		if err != nil {
+			return refund, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return refund, err
+		}
+	}
+
+	query, args, err = QB.Select("*").From("refund_items").Where(squirrel.Eq{"refund_id": refund.ID}).ToSql()
+	if err != nil {
+		return refund, err
+	}
+	var items []types.RefundItem
+	if err := tx.Select(&items, query, args...); err != nil {
+		return refund, err
+	}
+
+	share := refunded / requested
+	for _, item := range items {
+		keep := int(math.Floor(float64(item.Quantity)*share + 0.0001))
+		if keep < item.Quantity {
+			query, args, err := QB.Update("order_items").
+				Set("refunded_quantity", squirrel.Expr("refunded_quantity - ?", item.Quantity-keep)).
+				Where(squirrel.Eq{"id": item.OrderItemId}).
+				ToSql()
+			if err != nil {
+				return refund, err
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				return refund, err
+			}
+		}
+
+		// A failed refund keeps its lines as a record of what was asked for
+		if status == "failed" || keep == item.Quantity {
+			refund.Items = append(refund.Items, item)
+			continue
+		}
+		if keep == 0 {
+			query, args, err = QB.Delete("refund_items").Where(squirrel.Eq{"id": item.ID}).ToSql()
+		} else {
+			item.Amount = roundMoney(item.Amount * float64(keep) / float64(item.Quantity))
+			item.Quantity = keep
+			query, args, err = QB.Update("refund_items").
+				Set("quantity", item.Quantity).
+				Set("amount", item.Amount).
+				Where(squirrel.Eq{"id": item.ID}).
+				ToSql()
+			refund.Items = append(refund.Items, item)
+		}
+		if err != nil {
+			return refund, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return refund, err
+		}
+	}
+
+	return refund, tx.Commit()
+}
+
+func (s *service) ListOrderRefunds(orderID string) ([]types.Refund, error) {
+	refunds := []types.Refund{}
+	query, args, err := QB.Select("*").
+		From("refunds").
+		Where(squirrel.Eq{"order_id": orderID}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Select(&refunds, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list refunds: %w", err)
+	}
+
+	for i := range refunds {
+		query, args, err := QB.Select("*").From("refund_items").Where(squirrel.Eq{"refund_id": refunds[i].ID}).ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.db.Select(&refunds[i].Items, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to list refund items: %w", err)
+		}
+	}
+
+	return refunds, nil
+}
+
+func (s *service) attachOrderRefunds(order *types.Order) error {
+	refunds, err := s.ListOrderRefunds(order.ID.String())
+	if err != nil {
+		return err
+	}
+	order.Refunds = refunds
+	return nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+// SalesReport sums up a vendor's paid orders placed in [from, to). Cancelled
+// orders and orders not yet paid in full are left out.
+func (s *service) SalesReport(vendorID string, from, to time.Time) (types.SalesReport, error) {
+	report := types.SalesReport{From: from, To: to}
+
+	id, err := uuid.Parse(vendorID)
+	if err != nil {
+		return report, fmt.Errorf("invalid vendor id: %w", err)
+	}
+	report.VendorId = id
+
+	query, args, err := QB.Select(
+		"COUNT(*) AS order_count",
+		"COALESCE(SUM(subtotal), 0) AS subtotal",
+		"COALESCE(SUM(discount_total), 0) AS discount_total",
+		"COALESCE(SUM(tax_total), 0) AS tax_total",
+		"COALESCE(SUM(service_charge_total), 0) AS service_charge_total",
+		"COALESCE(SUM(tip_total), 0) AS tip_total",
+		"COALESCE(SUM(delivery_fee), 0) AS delivery_fee_total",
+		"COALESCE(SUM(total_order_cost), 0) AS total",
+	).
+		From("orders").
+		Where(squirrel.Eq{"vendor_id": id}).
+		Where(squirrel.NotEq{"status": []string{"cancelled", "pending_payment"}}).
+		Where(squirrel.Eq{"payment_status": []string{"paid", "partially_refunded", "refunded"}}).
+		Where(squirrel.GtOrEq{"created_at": from}).
+		Where(squirrel.Lt{"created_at": to}).
+		ToSql()
+	if err != nil {
+		return report, fmt.Errorf("error building report query: %w", err)
+	}
+
+	if err := s.db.Get(&report, query, args...); err != nil {
+		return report, fmt.Errorf("error building sales report: %w", err)
+	}
+
+	// Refunds count in the period they were paid out, not when the order was placed
+	query, args, err = QB.Select("COALESCE(SUM(refunds.amount), 0)").
+		From("refunds").
+		Join("orders ON orders.id = refunds.order_id").
+		Where(squirrel.Eq{"orders.vendor_id": id, "refunds.status": "completed"}).
+		Where(squirrel.GtOrEq{"refunds.created_at": from}).
+		Where(squirrel.Lt{"refunds.created_at": to}).
+		ToSql()
+	if err != nil {
+		return report, fmt.Errorf("error building refunds query: %w", err)
+	}
+
+	if err := s.db.Get(&report.RefundTotal, query, args...); err != nil {
+		return report, fmt.Errorf("error summing refunds: %w", err)
+	}
+	report.NetTotal = roundMoney(report.Total - report.RefundTotal)
+
+	return report, nil
+}
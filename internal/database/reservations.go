@@ -0,0 +1,532 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReservation  = errors.New("invalid reservation")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationConflict = errors.New("no table available for this reservation")
+)
+
+// maxCombinedTables caps how many combinable tables are pushed together to
+// seat one party.
+const maxCombinedTables = 3
+
+// exclusionViolation is the Postgres error raised when two active bookings
+// overlap on a table.
+const exclusionViolation = "23P01"
+
+var reservationColumns = []string{
+	"id", "vendor_id", "customer_id", "party_size", "starts_at", "ends_at", "status", "name", "phone", "notes",
+	"created_at", "updated_at",
+}
+
+// reservationTransitions lists the statuses each status can move to.
+var reservationTransitions = map[string][]string{
+	"pending":   {"confirmed", "declined", "cancelled"},
+	"confirmed": {"cancelled", "seated", "no_show"},
+	"seated":    {"completed"},
+}
+
+// holdsTables reports whether a reservation in this status still keeps its
+// tables booked.
+func holdsTables(status string) bool {
+	return status == "pending" || status == "confirmed" || status == "seated"
+}
+
+type reservationSettings struct {
+	TurnMinutes     int `db:"reservation_turn_minutes"`
+	IntervalMinutes int `db:"reservation_interval_minutes"`
+}
+
+// tableBooking is a period during which a table is held by a reservation.
+type tableBooking struct {
+	TableID  uuid.UUID `db:"table_id"`
+	StartsAt time.Time `db:"starts_at"`
+	EndsAt   time.Time `db:"ends_at"`
+}
+
+// CreateReservation books a party in for a time slot. The reservation lasts
+// the vendor's turn time and is given the smallest free table, or failing
+// that the smallest combination of combinable tables, that seats the party.
+func (s *service) CreateReservation(reservation types.Reservation) (*types.Reservation, error) {
+	if reservation.VendorId == uuid.Nil {
+		return nil, fmt.Errorf("%w: vendor_id is required", ErrInvalidReservation)
+	}
+	if reservation.PartySize <= 0 {
+		return nil, fmt.Errorf("%w: party_size must be positive", ErrInvalidReservation)
+	}
+
+	settings, err := loadReservationSettings(s.db, reservation.VendorId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	reservation.StartsAt = reservation.StartsAt.UTC().Truncate(time.Minute)
+	reservation.EndsAt = reservation.StartsAt.Add(time.Duration(settings.TurnMinutes) * time.Minute)
+	if !reservation.StartsAt.After(now) {
+		return nil, fmt.Errorf("%w: starts_at must be in the future", ErrInvalidReservation)
+	}
+
+	hours, err := loadVendorHours(s.db, []uuid.UUID{reservation.VendorId})
+	if err != nil {
+		return nil, err
+	}
+	if h := hours[reservation.VendorId]; h != nil && !h.covers(reservation.StartsAt, reservation.EndsAt) {
+		return nil, fmt.Errorf("%w: vendor is not open for the whole reservation", ErrInvalidReservation)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tables, err := freeTables(tx, reservation.VendorId, reservation.StartsAt, reservation.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	assigned := assignTables(tables, reservation.PartySize)
+	if assigned == nil {
+		return nil, ErrReservationConflict
+	}
+
+	reservation.ID = uuid.New()
+	reservation.Status = "pending"
+	reservation.Created_at = now
+	reservation.Updated_at = now
+
+	query, args, err := QB.Insert("reservations").
+		Columns(reservationColumns...).
+		Values(reservation.ID, reservation.VendorId, reservation.CustomerId, reservation.PartySize, reservation.StartsAt,
+			reservation.EndsAt, reservation.Status, reservation.Name, reservation.Phone, reservation.Notes,
+			reservation.Created_at, reservation.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(reservationColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	var created types.Reservation
+	if err := tx.QueryRowx(query, args...).StructScan(&created); err != nil {
+		return nil, fmt.Errorf("error inserting reservation: %w", err)
+	}
+
+	insert := QB.Insert("reservation_tables").Columns("reservation_id", "table_id", "during")
+	for _, table := range assigned {
+		insert = insert.Values(created.ID, table.ID, squirrel.Expr("tsrange(?, ?)", created.StartsAt, created.EndsAt))
+		created.TableIds = append(created.TableIds, table.ID)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	// A booking made concurrently may have taken the table since we looked
+	if _, err := tx.Exec(query, args...); err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrReservationConflict
+		}
+		return nil, fmt.Errorf("error booking tables: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		if isExclusionViolation(err) {
+			return nil, ErrReservationConflict
+		}
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (s *service) GetReservation(id string) (*types.Reservation, error) {
+	reservationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrReservationNotFound
+	}
+	return getReservation(s.db, reservationID, false)
+}
+
+// ListVendorReservations lists a vendor's reservations, soonest first. A date
+// (YYYY-MM-DD) limits them to that day in the vendor's timezone.
+func (s *service) ListVendorReservations(vendorID uuid.UUID, queryParams url.Values) ([]types.Reservation, *types.Meta, error) {
+	additionalFilters := []string{fmt.Sprintf("vendor_id = '%s'", vendorID)}
+
+	if date := queryParams.Get("date"); date != "" {
+		hours, err := loadVendorHours(s.db, []uuid.UUID{vendorID})
+		if err != nil {
+			return nil, nil, err
+		}
+		loc := time.UTC
+		if h := hours[vendorID]; h != nil {
+			loc = h.loc
+		}
+		day, err := time.ParseInLocation(dateLayout, date, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: date must be formatted as YYYY-MM-DD", ErrInvalidReservation)
+		}
+		additionalFilters = append(additionalFilters, fmt.Sprintf("starts_at >= '%s' AND starts_at < '%s'",
+			day.UTC().Format(time.DateTime), day.AddDate(0, 0, 1).UTC().Format(time.DateTime)))
+	}
+
+	return s.listReservations(queryParams, additionalFilters)
+}
+
+// ListCustomerReservations lists the reservations a user has made.
+func (s *service) ListCustomerReservations(userID uuid.UUID, queryParams url.Values) ([]types.Reservation, *types.Meta, error) {
+	return s.listReservations(queryParams, []string{fmt.Sprintf("customer_id = '%s'", userID)})
+}
+
+func (s *service) listReservations(queryParams url.Values, additionalFilters []string) ([]types.Reservation, *types.Meta, error) {
+	var reservations []types.Reservation
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "starts_at")
+	}
+
+	meta, err := s.BuildQuery(
+		&reservations,
+		"reservations",
+		[]string{},
+		reservationColumns,
+		[]string{"name", "phone"},
+		queryParams,
+		additionalFilters,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+
+	if reservations == nil {
+		reservations = []types.Reservation{}
+	}
+	if err := attachReservationTables(s.db, reservations); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch reservation tables: %w", err)
+	}
+
+	return reservations, meta, nil
+}
+
+// UpdateReservationStatus moves a reservation along its lifecycle. Once it is
+// declined, cancelled, completed or a no-show its tables are released.
+func (s *service) UpdateReservationStatus(id string, status string) (*types.Reservation, error) {
+	reservationID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrReservationNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := transitionReservation(tx, reservationID, status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getReservation(s.db, reservationID, false)
+}
+
+func transitionReservation(tx *sqlx.Tx, id uuid.UUID, status string) (*types.Reservation, error) {
+	reservation, err := getReservation(tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range reservationTransitions[reservation.Status] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: a %s reservation cannot become %s", ErrInvalidReservation, reservation.Status, status)
+	}
+
+	query, args, err := QB.Update("reservations").
+		Set("status", status).
+		Set("updated_at", time.Now().UTC()).
+		Where("id = ?", id).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating reservation: %w", err)
+	}
+
+	if !holdsTables(status) {
+		query, args, err = QB.Update("reservation_tables").
+			Set("is_active", false).
+			Where("reservation_id = ?", id).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error releasing tables: %w", err)
+		}
+	}
+
+	reservation.Status = status
+	return reservation, nil
+}
+
+// VendorAvailability lists the start times on a date (in the vendor's
+// timezone) at which the party can be seated for a full turn.
+func (s *service) VendorAvailability(vendorID uuid.UUID, date string, partySize int) ([]types.AvailabilitySlot, error) {
+	if partySize <= 0 {
+		return nil, fmt.Errorf("%w: party_size must be positive", ErrInvalidReservation)
+	}
+
+	settings, err := loadReservationSettings(s.db, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	hours, err := loadVendorHours(s.db, []uuid.UUID{vendorID})
+	if err != nil {
+		return nil, err
+	}
+	h := hours[vendorID]
+	day, err := time.ParseInLocation(dateLayout, date, h.loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be formatted as YYYY-MM-DD", ErrInvalidReservation)
+	}
+
+	windows := h.dayWindows(day)
+	slots := []types.AvailabilitySlot{}
+	if len(windows) == 0 {
+		return slots, nil
+	}
+
+	tables, err := freeTables(s.db, vendorID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := tableBookings(s.db, vendorID, windows[0].start.UTC(), windows[len(windows)-1].end.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	turn := time.Duration(settings.TurnMinutes) * time.Minute
+	interval := time.Duration(settings.IntervalMinutes) * time.Minute
+	now := time.Now()
+	for _, w := range windows {
+		for start := w.start; !start.Add(turn).After(w.end); start = start.Add(interval) {
+			if !start.After(now) {
+				continue
+			}
+			end := start.Add(turn)
+
+			var free []types.Table
+			for _, table := range tables {
+				if !tableBooked(bookings, table.ID, start, end) {
+					free = append(free, table)
+				}
+			}
+			if assignTables(free, partySize) != nil {
+				slots = append(slots, types.AvailabilitySlot{StartsAt: start, EndsAt: end})
+			}
+		}
+	}
+
+	return slots, nil
+}
+
+func loadReservationSettings(q sqlx.Queryer, vendorID uuid.UUID) (reservationSettings, error) {
+	var settings reservationSettings
+	query, args, err := QB.Select("reservation_turn_minutes", "reservation_interval_minutes").
+		From("vendors").
+		Where("id = ?", vendorID).
+		ToSql()
+	if err != nil {
+		return settings, err
+	}
+	if err := sqlx.Get(q, &settings, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return settings, fmt.Errorf("%w: vendor not found", ErrInvalidReservation)
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+// freeTables returns the vendor's reservable tables that no active booking
+// holds during [start, end), smallest first. A zero period returns them all.
+func freeTables(q sqlx.Queryer, vendorID uuid.UUID, start, end time.Time) ([]types.Table, error) {
+	builder := QB.Select(tableColumns...).
+		From("tables").
+		Where("vendor_id = ? AND is_reservable", vendorID).
+		OrderBy("capacity", "name")
+	if !start.IsZero() {
+		builder = builder.Where(`NOT EXISTS (
+			SELECT 1 FROM reservation_tables rt
+			WHERE rt.table_id = tables.id AND rt.is_active AND rt.during && tsrange(?, ?)
+		)`, start, end)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []types.Table
+	if err := sqlx.Select(q, &tables, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch free tables: %w", err)
+	}
+	return tables, nil
+}
+
+// tableBookings returns the active bookings of the vendor's tables that
+// overlap [start, end).
+func tableBookings(q sqlx.Queryer, vendorID uuid.UUID, start, end time.Time) ([]tableBooking, error) {
+	query, args, err := QB.Select("rt.table_id", "lower(rt.during) AS starts_at", "upper(rt.during) AS ends_at").
+		From("reservation_tables rt").
+		Join("tables t ON t.id = rt.table_id").
+		Where("t.vendor_id = ? AND rt.is_active AND rt.during && tsrange(?, ?)", vendorID, start, end).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var bookings []tableBooking
+	if err := sqlx.Select(q, &bookings, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch table bookings: %w", err)
+	}
+	return bookings, nil
+}
+
+func tableBooked(bookings []tableBooking, tableID uuid.UUID, start, end time.Time) bool {
+	for _, b := range bookings {
+		if b.TableID == tableID && b.StartsAt.Before(end) && start.Before(b.EndsAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// assignTables picks the tables to seat a party: the smallest single table
+// that fits, otherwise the combination of combinable tables with the fewest
+// spare seats. It returns nil when the party can't be seated.
+func assignTables(tables []types.Table, partySize int) []types.Table {
+	sorted := append([]types.Table(nil), tables...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Capacity < sorted[j].Capacity })
+
+	for _, table := range sorted {
+		if table.MinCapacity <= partySize && partySize <= table.Capacity {
+			return []types.Table{table}
+		}
+	}
+
+	var combinable []types.Table
+	for _, table := range sorted {
+		if table.IsCombinable {
+			combinable = append(combinable, table)
+		}
+	}
+
+	var best []types.Table
+	bestCapacity := 0
+	var search func(from int, picked []types.Table, capacity int)
+	search = func(from int, picked []types.Table, capacity int) {
+		if len(picked) >= 2 && capacity >= partySize {
+			if best == nil || capacity < bestCapacity || (capacity == bestCapacity && len(picked) < len(best)) {
+				best = append([]types.Table(nil), picked...)
+				bestCapacity = capacity
+			}
+			return
+		}
+		if len(picked) == maxCombinedTables {
+			return
+		}
+		for i := from; i < len(combinable); i++ {
+			search(i+1, append(picked, combinable[i]), capacity+combinable[i].Capacity)
+		}
+	}
+	search(0, nil, 0)
+
+	return best
+}
+
+func getReservation(q sqlx.Queryer, id uuid.UUID, forUpdate bool) (*types.Reservation, error) {
+	builder := QB.Select(reservationColumns...).From("reservations").Where("id = ?", id)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var reservation types.Reservation
+	if err := sqlx.Get(q, &reservation, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReservationNotFound
+		}
+		return nil, err
+	}
+
+	reservations := []types.Reservation{reservation}
+	if err := attachReservationTables(q, reservations); err != nil {
+		return nil, err
+	}
+	return &reservations[0], nil
+}
+
+func attachReservationTables(q sqlx.Queryer, reservations []types.Reservation) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(reservations))
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+	}
+
+	var rows []struct {
+		ReservationID uuid.UUID `db:"reservation_id"`
+		TableID       uuid.UUID `db:"table_id"`
+	}
+	query, args, err := QB.Select("reservation_id", "table_id").
+		From("reservation_tables").
+		Where(squirrel.Eq{"reservation_id": ids}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Select(q, &rows, query, args...); err != nil {
+		return err
+	}
+
+	tableIDs := make(map[uuid.UUID][]uuid.UUID, len(reservations))
+	for _, row := range rows {
+		tableIDs[row.ReservationID] = append(tableIDs[row.ReservationID], row.TableID)
+	}
+	for i := range reservations {
+		reservations[i].TableIds = tableIDs[reservations[i].ID]
+	}
+	return nil
+}
+
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == exclusionViolation
+}
@@ -0,0 +1,287 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+// ErrTipNotAllowed is returned when the tip on an order can no longer change.
+var ErrTipNotAllowed = errors.New("tip cannot be changed")
+
+var serviceChargeRuleColumns = []string{
+	"id", "vendor_id", "name", "rate", "min_party_size", "is_active", "created_at", "updated_at",
+}
+
+func (s *service) ListServiceChargeRules(queryParams url.Values) ([]types.ServiceChargeRule, *types.Meta, error) {
+	var rules []types.ServiceChargeRule
+
+	meta, err := s.BuildQuery(
+		&rules,
+		"service_charge_rules",
+		[]string{},
+		serviceChargeRuleColumns,
+		[]string{"name"},
+		queryParams,
+		[]string{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list service charge rules: %w", err)
+	}
+
+	if rules == nil {
+		rules = []types.ServiceChargeRule{}
+	}
+
+	return rules, meta, nil
+}
+
+func (s *service) GetServiceChargeRuleByID(id string) (*types.ServiceChargeRule, error) {
+	var rule types.ServiceChargeRule
+	query, args, err := QB.Select(strings.Join(serviceChargeRuleColumns, ", ")).
+		From("service_charge_rules").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&rule, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("service charge rule not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch service charge rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func (s *service) CreateServiceChargeRule(rule types.ServiceChargeRule) (*types.ServiceChargeRule, error) {
+	if rule.VendorId == uuid.Nil || rule.Name == "" {
+		return nil, errors.New("missing required parameters")
+	}
+	if rule.Rate <= 0 || rule.Rate >= 1 {
+		return nil, errors.New("rate must be a fraction between 0 and 1")
+	}
+	if rule.MinPartySize < 1 {
+		rule.MinPartySize = 1
+	}
+	if rule.IsActive == nil {
+		isActive := true
+		rule.IsActive = &isActive
+	}
+
+	rule.ID = uuid.New()
+	rule.Created_at = time.Now()
+	rule.Updated_at = time.Now()
+
+	query, args, err := QB.Insert("service_charge_rules").
+		Columns(serviceChargeRuleColumns...).
+		Values(rule.ID, rule.VendorId, rule.Name, rule.Rate, rule.MinPartySize, rule.IsActive, rule.Created_at, rule.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(serviceChargeRuleColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	if err := s.db.QueryRowx(query, args...).StructScan(&rule); err != nil {
+		return nil, fmt.Errorf("error inserting service charge rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+func (s *service) UpdateServiceChargeRule(id string, rule types.ServiceChargeRule) (*types.ServiceChargeRule, error) {
+	existing, err := s.GetServiceChargeRuleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Name == "" {
+		rule.Name = existing.Name
+	}
+	if rule.Rate == 0 {
+		rule.Rate = existing.Rate
+	}
+	if rule.Rate < 0 || rule.Rate >= 1 {
+		return nil, errors.New("rate must be a fraction between 0 and 1")
+	}
+	if rule.MinPartySize < 1 {
+		rule.MinPartySize = existing.MinPartySize
+	}
+	if rule.IsActive == nil {
+		rule.IsActive = existing.IsActive
+	}
+
+	query, args, err := QB.Update("service_charge_rules").
+		Set("name", rule.Name).
+		Set("rate", rule.Rate).
+		Set("min_party_size", rule.MinPartySize).
+		Set("is_active", rule.IsActive).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(serviceChargeRuleColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	var updated types.ServiceChargeRule
+	if err := s.db.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		return nil, fmt.Errorf("error updating service charge rule: %w", err)
+	}
+
+	return &updated, nil
+}
+
+func (s *service) DeleteServiceChargeRule(id string) error {
+	_, err := deleteById(s, id, "service_charge_rules")
+	if err != nil {
+		return fmt.Errorf("error deleting service charge rule: %w", err)
+	}
+	return nil
+}
+
+// serviceCharges works out the automatic charges for a dine-in order. The
+// charges are taken on the discounted subtotal, before tax.
+func serviceCharges(q sqlx.Queryer, vendorID uuid.UUID, partySize int, base float64) ([]types.OrderAdjustment, error) {
+	var rules []types.ServiceChargeRule
+	query, args, err := QB.Select(serviceChargeRuleColumns...).
+		From("service_charge_rules").
+		Where(squirrel.Eq{"vendor_id": vendorID, "is_active": true}).
+		Where(squirrel.LtOrEq{"min_party_size": partySize}).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &rules, query, args...); err != nil {
+		return nil, err
+	}
+
+	adjustments := make([]types.OrderAdjustment, 0, len(rules))
+	for _, rule := range rules {
+		rate := rule.Rate
+		adjustments = append(adjustments, types.OrderAdjustment{
+			ID:                  uuid.New(),
+			Type:                "service_charge",
+			ServiceChargeRuleId: &rule.ID,
+			Name:                rule.Name,
+			Rate:                &rate,
+			Amount:              roundMoney(base * rule.Rate),
+		})
+	}
+
+	return adjustments, nil
+}
+
+func createOrderAdjustments(tx *sqlx.Tx, orderID uuid.UUID, adjustments []types.OrderAdjustment) error {
+	for _, adjustment := range adjustments {
+		query, args, err := QB.Insert("order_adjustments").
+			Columns("id", "order_id", "type", "service_charge_rule_id", "name", "rate", "amount").
+			Values(adjustment.ID, orderID, adjustment.Type, adjustment.ServiceChargeRuleId, adjustment.Name, adjustment.Rate, adjustment.Amount).
+			ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tipAdjustment(amount float64) types.OrderAdjustment {
+	return types.OrderAdjustment{
+		ID:     uuid.New(),
+		Type:   "tip",
+		Name:   "Tip",
+		Amount: roundMoney(amount),
+	}
+}
+
+// SetOrderTip replaces the tip on an order, e.g. once the table has been
+// served, and keeps the order total in step. The tip is fixed once the order
+// is paid or cancelled.
+func (s *service) SetOrderTip(orderID string, amount float64) (types.Order, error) {
+	if amount < 0 {
+		return types.Order{}, errors.New("tip cannot be negative")
+	}
+	id, err := uuid.Parse(orderID)
+	if err != nil {
+		return types.Order{}, errors.New("invalid order id")
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return types.Order{}, err
+	}
+	defer tx.Rollback()
+
+	var current struct {
+		Status        string `db:"status"`
+		PaymentStatus string `db:"payment_status"`
+	}
+	if err := tx.Get(&current, `SELECT status, payment_status FROM orders WHERE id = $1 FOR UPDATE`, id); err != nil {
+		return types.Order{}, err
+	}
+	if current.Status == "cancelled" {
+		return types.Order{}, fmt.Errorf("%w: the order is cancelled", ErrTipNotAllowed)
+	}
+	switch current.PaymentStatus {
+	case "paid", "partially_refunded", "refunded":
+		return types.Order{}, fmt.Errorf("%w: the order is already paid", ErrTipNotAllowed)
+	}
+
+	query, args, err := QB.Delete("order_adjustments").
+		Where(squirrel.Eq{"order_id": id, "type": "tip"}).
+		ToSql()
+	if err != nil {
+		return types.Order{}, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return types.Order{}, err
+	}
+
+	tip := tipAdjustment(amount)
+	if tip.Amount > 0 {
+		if err := createOrderAdjustments(tx, id, []types.OrderAdjustment{tip}); err != nil {
+			return types.Order{}, err
+		}
+	}
+
+	query, args, err = QB.Update("orders").
+		Set("total_order_cost", squirrel.Expr("total_order_cost - tip_total + ?", tip.Amount)).
+		Set("tip_total", tip.Amount).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": orderID}).
+		ToSql()
+	if err != nil {
+		return types.Order{}, err
+	}
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return types.Order{}, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return types.Order{}, sql.ErrNoRows
+	}
+	// With a smaller total, what has been captured may now settle the order
+	if err := refreshOrderPaymentStatus(tx, id); err != nil {
+		return types.Order{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.Order{}, err
+	}
+
+	order, err := s.FetchOrder(orderID)
+	if err != nil {
+		return order, err
+	}
+	return order, s.AttachOrderItems(&order)
+}
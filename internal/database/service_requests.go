@@ -0,0 +1,283 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net/url"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidServiceRequest  = errors.New("invalid service request")
+	ErrServiceRequestNotFound = errors.New("service request not found")
+)
+
+var serviceRequestTypes = map[string]bool{"water": true, "bill": true, "help": true}
+
+// serviceRequestTransitions lists the statuses each status can move to.
+var serviceRequestTransitions = map[string][]string{
+	"open":         {"acknowledged", "resolved", "cancelled"},
+	"acknowledged": {"resolved", "cancelled"},
+}
+
+var serviceRequestColumns = []string{
+	"id", "vendor_id", "table_id", "table_session_id", "customer_id", "type", "status", "note",
+	"acknowledged_at", "acknowledged_by", "resolved_at", "resolved_by",
+	"EXTRACT(EPOCH FROM acknowledged_at - created_at)::int AS acknowledge_seconds",
+	"EXTRACT(EPOCH FROM resolved_at - created_at)::int AS resolve_seconds",
+	"created_at", "updated_at",
+}
+
+// CreateServiceRequest raises a request from a table. Asking for the same
+// thing again while staff haven't resolved it returns the pending request
+// instead, and created is false.
+func (s *service) CreateServiceRequest(request types.ServiceRequest) (*types.ServiceRequest, bool, error) {
+	if !serviceRequestTypes[request.Type] {
+		return nil, false, fmt.Errorf("%w: type must be water, bill or help", ErrInvalidServiceRequest)
+	}
+
+	query, args, err := QB.Select("vendor_id").From("tables").Where("id = ?", request.TableId).ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.db.Get(&request.VendorId, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("%w: table not found", ErrInvalidServiceRequest)
+		}
+		return nil, false, err
+	}
+
+	request.TableSessionId, err = tableSessionAt(s.db, request.TableId)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	query, args, err = QB.Insert("service_requests").
+		Columns("id", "vendor_id", "table_id", "table_session_id", "customer_id", "type", "status", "note",
+			"created_at", "updated_at").
+		Values(uuid.New(), request.VendorId, request.TableId, request.TableSessionId, request.CustomerId, request.Type,
+			"open", request.Note, now, now).
+		Suffix(fmt.Sprintf("ON CONFLICT (table_id, type) WHERE status IN ('open', 'acknowledged') DO NOTHING RETURNING %s",
+			strings.Join(serviceRequestColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	var created types.ServiceRequest
+	err = s.db.QueryRowx(query, args...).StructScan(&created)
+	if err == nil {
+		// The table now shows as needing service. The request is already
+		// saved, so a failed announcement is only logged
+		if err := publishTableEvents(s.db, []uuid.UUID{created.TableId}); err != nil {
+			logger.Log.WithError(err).Warn("Failed to publish table event")
+		}
+		return &created, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("error creating service request: %w", err)
+	}
+
+	query, args, err = QB.Select(serviceRequestColumns...).
+		From("service_requests").
+		Where("table_id = ? AND type = ? AND status IN ('open', 'acknowledged')", request.TableId, request.Type).
+		ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+	var pending types.ServiceRequest
+	if err := s.db.Get(&pending, query, args...); err != nil {
+		return nil, false, fmt.Errorf("error fetching pending service request: %w", err)
+	}
+	return &pending, false, nil
+}
+
+func (s *service) GetServiceRequest(id string) (*types.ServiceRequest, error) {
+	requestID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrServiceRequestNotFound
+	}
+	return getServiceRequest(s.db, requestID, false)
+}
+
+// ListServiceRequests is a vendor's service queue, oldest first. By default
+// it holds the requests staff still have to deal with; status=all or a
+// single status lists past requests too.
+func (s *service) ListServiceRequests(vendorID uuid.UUID, queryParams url.Values) ([]types.ServiceRequest, *types.Meta, error) {
+	var requests []types.ServiceRequest
+
+	additionalFilters := []string{fmt.Sprintf("vendor_id = '%s'", vendorID)}
+	switch status := queryParams.Get("status"); status {
+	case "":
+		additionalFilters = append(additionalFilters, "status IN ('open', 'acknowledged')")
+	case "all":
+	case "open", "acknowledged", "resolved", "cancelled":
+		additionalFilters = append(additionalFilters, fmt.Sprintf("status = '%s'", status))
+	default:
+		return nil, nil, fmt.Errorf("%w: status must be open, acknowledged, resolved, cancelled or all", ErrInvalidServiceRequest)
+	}
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "created_at")
+	}
+
+	meta, err := s.BuildQuery(
+		&requests,
+		"service_requests",
+		[]string{},
+		serviceRequestColumns,
+		[]string{},
+		queryParams,
+		additionalFilters,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list service requests: %w", err)
+	}
+
+	if requests == nil {
+		requests = []types.ServiceRequest{}
+	}
+	return requests, meta, nil
+}
+
+// UpdateServiceRequestStatus acknowledges, resolves or cancels a request on
+// behalf of staffID. Resolving a request nobody acknowledged counts as
+// acknowledging it at the same time.
+func (s *service) UpdateServiceRequestStatus(id string, status string, staffID *uuid.UUID) (*types.ServiceRequest, error) {
+	requestID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrServiceRequestNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	request, err := getServiceRequest(tx, requestID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range serviceRequestTransitions[request.Status] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: a %s request cannot become %s", ErrInvalidServiceRequest, request.Status, status)
+	}
+
+	now := time.Now()
+	builder := QB.Update("service_requests").
+		Set("status", status).
+		Set("updated_at", now).
+		Where("id = ?", requestID)
+	switch status {
+	case "acknowledged":
+		builder = builder.Set("acknowledged_at", now).Set("acknowledged_by", staffID)
+	case "resolved":
+		builder = builder.
+			Set("acknowledged_at", squirrel.Expr("COALESCE(acknowledged_at, ?)", now)).
+			Set("acknowledged_by", squirrel.Expr("COALESCE(acknowledged_by, ?)", staffID)).
+			Set("resolved_at", now).
+			Set("resolved_by", staffID)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating service request: %w", err)
+	}
+	if err := publishTableEvents(tx, []uuid.UUID{request.TableId}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getServiceRequest(s.db, requestID, false)
+}
+
+// ServiceReport averages staff response times per request type for the
+// requests raised in [from, to).
+func (s *service) ServiceReport(vendorID string, from, to time.Time) (types.ServiceReport, error) {
+	report := types.ServiceReport{From: from, To: to, Types: []types.ServiceTypeReport{}}
+
+	id, err := uuid.Parse(vendorID)
+	if err != nil {
+		return report, fmt.Errorf("invalid vendor id: %w", err)
+	}
+	report.VendorId = id
+
+	query, args, err := QB.Select(
+		"type",
+		"COUNT(*) AS request_count",
+		"COUNT(*) FILTER (WHERE status = 'resolved') AS resolved_count",
+		"COALESCE(ROUND(AVG(EXTRACT(EPOCH FROM acknowledged_at - created_at))::numeric, 1), 0) AS average_acknowledge_seconds",
+		"COALESCE(ROUND(AVG(EXTRACT(EPOCH FROM resolved_at - created_at))::numeric, 1), 0) AS average_resolve_seconds",
+	).
+		From("service_requests").
+		Where(squirrel.Eq{"vendor_id": id}).
+		Where(squirrel.GtOrEq{"created_at": from}).
+		Where(squirrel.Lt{"created_at": to}).
+		GroupBy("type").
+		OrderBy("type").
+		ToSql()
+	if err != nil {
+		return report, fmt.Errorf("error building report query: %w", err)
+	}
+
+	if err := s.db.Select(&report.Types, query, args...); err != nil {
+		return report, fmt.Errorf("error building service report: %w", err)
+	}
+	return report, nil
+}
+
+// resolveSessionServiceRequests clears whatever a session still had pending
+// once its bill is closed out.
+func resolveSessionServiceRequests(tx *sqlx.Tx, sessionID uuid.UUID, now time.Time) error {
+	query, args, err := QB.Update("service_requests").
+		Set("status", "resolved").
+		Set("acknowledged_at", squirrel.Expr("COALESCE(acknowledged_at, ?)", now)).
+		Set("resolved_at", now).
+		Set("updated_at", now).
+		Where("table_session_id = ? AND status IN ('open', 'acknowledged')", sessionID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+func getServiceRequest(q sqlx.Queryer, id uuid.UUID, forUpdate bool) (*types.ServiceRequest, error) {
+	builder := QB.Select(serviceRequestColumns...).From("service_requests").Where("id = ?", id)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var request types.ServiceRequest
+	if err := sqlx.Get(q, &request, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrServiceRequestNotFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
@@ -0,0 +1,713 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"math"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTableSession  = errors.New("invalid table session")
+	ErrTableSessionNotFound = errors.New("table session not found")
+	ErrTableOccupied        = errors.New("table is already occupied")
+	ErrBalanceDue           = errors.New("table session has an outstanding balance")
+)
+
+// uniqueViolation is the Postgres error raised when a second session tries
+// to sit at an occupied table.
+const uniqueViolation = "23505"
+
+var tableSessionColumns = []string{
+	"id", "vendor_id", "party_size", "server_id", "customer_id", "reservation_id", "merged_into_id",
+	"opened_at", "closed_at", "created_at", "updated_at",
+}
+
+// SeatParty opens a session for a party at a table, or at the tables held by
+// its reservation, which is marked as seated.
+func (s *service) SeatParty(seat types.SeatParty) (*types.TableSession, error) {
+	if seat.PartySize <= 0 {
+		return nil, fmt.Errorf("%w: party_size must be positive", ErrInvalidTableSession)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session := types.TableSession{
+		PartySize:     seat.PartySize,
+		ServerId:      seat.ServerId,
+		CustomerId:    seat.CustomerId,
+		ReservationId: seat.ReservationId,
+	}
+
+	var tableIDs []uuid.UUID
+	if seat.TableId != nil {
+		tableIDs = []uuid.UUID{*seat.TableId}
+	}
+
+	if seat.ReservationId != nil {
+		reservation, err := transitionReservation(tx, *seat.ReservationId, "seated")
+		if err != nil {
+			return nil, err
+		}
+		if tableIDs == nil {
+			tableIDs = reservation.TableIds
+		}
+		if session.CustomerId == nil {
+			session.CustomerId = reservation.CustomerId
+		}
+		session.VendorId = reservation.VendorId
+	}
+	if len(tableIDs) == 0 {
+		return nil, fmt.Errorf("%w: table_id is required", ErrInvalidTableSession)
+	}
+
+	created, err := openTableSession(tx, session, tableIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTableSession(created.ID.String())
+}
+
+// GetTableSession returns a session with its tables, orders and running bill.
+func (s *service) GetTableSession(id string) (*types.TableSession, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrTableSessionNotFound
+	}
+
+	session, err := getTableSession(s.db, sessionID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := QB.Select("*").
+		From("orders").
+		Where("table_session_id = ?", sessionID).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	session.Orders = []types.Order{}
+	if err := s.db.Select(&session.Orders, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to fetch session orders: %w", err)
+	}
+
+	session.Bill, err = tableSessionBill(s.db, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListTableSessions lists a vendor's sessions, newest first. open=true or
+// open=false limits them to sessions still seated or already closed.
+func (s *service) ListTableSessions(vendorID uuid.UUID, queryParams url.Values) ([]types.TableSession, *types.Meta, error) {
+	var sessions []types.TableSession
+
+	additionalFilters := []string{fmt.Sprintf("vendor_id = '%s'", vendorID)}
+	switch queryParams.Get("open") {
+	case "true":
+		additionalFilters = append(additionalFilters, "closed_at IS NULL")
+	case "false":
+		additionalFilters = append(additionalFilters, "closed_at IS NOT NULL")
+	}
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "-opened_at")
+	}
+
+	meta, err := s.BuildQuery(
+		&sessions,
+		"table_sessions",
+		[]string{},
+		tableSessionColumns,
+		[]string{},
+		queryParams,
+		additionalFilters,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list table sessions: %w", err)
+	}
+
+	if sessions == nil {
+		sessions = []types.TableSession{}
+	}
+	if err := attachSessionTables(s.db, sessions); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch session tables: %w", err)
+	}
+
+	return sessions, meta, nil
+}
+
+// TransferTableSession moves a session from one of its tables to a free one.
+// The table to leave can be left out when the session only holds one.
+func (s *service) TransferTableSession(id string, fromTableID *uuid.UUID, toTableID uuid.UUID) (*types.TableSession, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrTableSessionNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session, err := openSessionForUpdate(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromTableID == nil {
+		if len(session.TableIds) != 1 {
+			return nil, fmt.Errorf("%w: from_table_id is required for sessions at several tables", ErrInvalidTableSession)
+		}
+		fromTableID = &session.TableIds[0]
+	}
+	if !containsUUID(session.TableIds, *fromTableID) {
+		return nil, fmt.Errorf("%w: the session is not at that table", ErrInvalidTableSession)
+	}
+	if containsUUID(session.TableIds, toTableID) {
+		return nil, fmt.Errorf("%w: the session is already at that table", ErrInvalidTableSession)
+	}
+
+	now := time.Now()
+	if err := leaveTables(tx, session.ID, []uuid.UUID{*fromTableID}, now); err != nil {
+		return nil, err
+	}
+	if err := seatTables(tx, *session, []uuid.UUID{toTableID}, now); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTableSession(session.ID.String())
+}
+
+// MergeTableSession pushes another table into the session. If a party is
+// already seated there, their session is folded into this one: its tables,
+// orders and guests move over and it is closed as merged.
+func (s *service) MergeTableSession(id string, tableID uuid.UUID) (*types.TableSession, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrTableSessionNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session, err := openSessionForUpdate(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if containsUUID(session.TableIds, tableID) {
+		return nil, fmt.Errorf("%w: the session is already at that table", ErrInvalidTableSession)
+	}
+
+	now := time.Now()
+	otherID, err := tableSessionAt(tx, tableID)
+	if err != nil {
+		return nil, err
+	}
+	if otherID == nil {
+		if err := seatTables(tx, *session, []uuid.UUID{tableID}, now); err != nil {
+			return nil, err
+		}
+	} else {
+		other, err := openSessionForUpdate(tx, *otherID)
+		if err != nil {
+			return nil, err
+		}
+		if other.VendorId != session.VendorId {
+			return nil, fmt.Errorf("%w: table belongs to another vendor", ErrInvalidTableSession)
+		}
+
+		if err := leaveTables(tx, other.ID, nil, now); err != nil {
+			return nil, err
+		}
+		if err := seatTables(tx, *session, other.TableIds, now); err != nil {
+			return nil, err
+		}
+
+		query, args, err := QB.Update("orders").
+			Set("table_session_id", session.ID).
+			Where("table_session_id = ?", other.ID).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error moving orders: %w", err)
+		}
+
+		query, args, err = QB.Update("table_sessions").
+			Set("closed_at", now).
+			Set("merged_into_id", session.ID).
+			Set("updated_at", now).
+			Where("id = ?", other.ID).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error closing merged session: %w", err)
+		}
+
+		query, args, err = QB.Update("table_sessions").
+			Set("party_size", squirrel.Expr("party_size + ?", other.PartySize)).
+			Set("updated_at", now).
+			Where("id = ?", session.ID).
+			ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, fmt.Errorf("error updating session: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTableSession(session.ID.String())
+}
+
+// CloseTableSession closes out the bill and frees the session's tables. It
+// refuses while orders are unpaid unless force is set, e.g. when the party
+// settled outside the system.
+func (s *service) CloseTableSession(id string, force bool) (*types.TableSession, error) {
+	sessionID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrTableSessionNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	session, err := openSessionForUpdate(tx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	bill, err := tableSessionBill(tx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if bill.BalanceDue > 0 && !force {
+		return nil, fmt.Errorf("%w of %.2f", ErrBalanceDue, bill.BalanceDue)
+	}
+
+	now := time.Now()
+	if err := leaveTables(tx, session.ID, nil, now); err != nil {
+		return nil, err
+	}
+	if err := resolveSessionServiceRequests(tx, session.ID, now); err != nil {
+		return nil, fmt.Errorf("error resolving service requests: %w", err)
+	}
+
+	query, args, err := QB.Update("table_sessions").
+		Set("closed_at", now).
+		Set("updated_at", now).
+		Where("id = ?", session.ID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error closing session: %w", err)
+	}
+
+	if session.ReservationId != nil {
+		reservation, err := getReservation(tx, *session.ReservationId, true)
+		if err != nil && !errors.Is(err, ErrReservationNotFound) {
+			return nil, err
+		}
+		if reservation != nil && reservation.Status == "seated" {
+			if _, err := transitionReservation(tx, reservation.ID, "completed"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTableSession(session.ID.String())
+}
+
+// TableTurnoverReport sums up the sessions a vendor closed in [from, to).
+// Sessions merged into another count as part of it.
+func (s *service) TableTurnoverReport(vendorID string, from, to time.Time) (types.TurnoverReport, error) {
+	report := types.TurnoverReport{From: from, To: to, Tables: []types.TableTurnover{}}
+
+	id, err := uuid.Parse(vendorID)
+	if err != nil {
+		return report, fmt.Errorf("invalid vendor id: %w", err)
+	}
+	report.VendorId = id
+
+	minutes := "EXTRACT(EPOCH FROM closed_at - opened_at) / 60"
+	query, args, err := QB.Select(
+		"COUNT(*) AS session_count",
+		"COALESCE(SUM(party_size), 0) AS cover_count",
+		fmt.Sprintf("COALESCE(ROUND(AVG(%s)::numeric, 1), 0) AS average_minutes", minutes),
+		fmt.Sprintf("COALESCE(ROUND(MIN(%s)::numeric, 1), 0) AS min_minutes", minutes),
+		fmt.Sprintf("COALESCE(ROUND(MAX(%s)::numeric, 1), 0) AS max_minutes", minutes),
+	).
+		From("table_sessions").
+		Where(squirrel.Eq{"vendor_id": id, "merged_into_id": nil}).
+		Where(squirrel.GtOrEq{"closed_at": from}).
+		Where(squirrel.Lt{"closed_at": to}).
+		ToSql()
+	if err != nil {
+		return report, fmt.Errorf("error building report query: %w", err)
+	}
+	if err := s.db.Get(&report, query, args...); err != nil {
+		return report, fmt.Errorf("error building turnover report: %w", err)
+	}
+
+	seated := "EXTRACT(EPOCH FROM tst.left_at - tst.seated_at) / 60"
+	query, args, err = QB.Select(
+		"t.id AS table_id",
+		"t.name AS table_name",
+		"COUNT(DISTINCT tst.session_id) AS session_count",
+		fmt.Sprintf("ROUND(AVG(%s)::numeric, 1) AS average_minutes", seated),
+		fmt.Sprintf("ROUND(SUM(%s)::numeric, 1) AS occupied_minutes", seated),
+	).
+		From("table_session_tables tst").
+		Join("tables t ON t.id = tst.table_id").
+		Where(squirrel.Eq{"t.vendor_id": id}).
+		Where(squirrel.GtOrEq{"tst.left_at": from}).
+		Where(squirrel.Lt{"tst.left_at": to}).
+		GroupBy("t.id", "t.name").
+		OrderBy("t.name").
+		ToSql()
+	if err != nil {
+		return report, fmt.Errorf("error building table turnover query: %w", err)
+	}
+	if err := s.db.Select(&report.Tables, query, args...); err != nil {
+		return report, fmt.Errorf("error building table turnover: %w", err)
+	}
+
+	return report, nil
+}
+
+// sessionForDineIn returns the open session at a table, seating a new one
+// for the order's party when there is none so dine-in orders always land on
+// a session.
+func sessionForDineIn(tx *sqlx.Tx, vendorID uuid.UUID, tableID uuid.UUID, customerID uuid.UUID, partySize int, now time.Time) (*uuid.UUID, error) {
+	sessionID, err := tableSessionAt(tx, tableID)
+	if err != nil || sessionID != nil {
+		return sessionID, err
+	}
+
+	session, err := openTableSession(tx, types.TableSession{
+		VendorId:   vendorID,
+		PartySize:  partySize,
+		CustomerId: &customerID,
+	}, []uuid.UUID{tableID}, now)
+	if err != nil {
+		return nil, err
+	}
+	return &session.ID, nil
+}
+
+func openTableSession(tx *sqlx.Tx, session types.TableSession, tableIDs []uuid.UUID, now time.Time) (*types.TableSession, error) {
+	// The vendor comes from the tables when it isn't already known
+	if session.VendorId == uuid.Nil {
+		query, args, err := QB.Select("vendor_id").From("tables").Where("id = ?", tableIDs[0]).ToSql()
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Get(&session.VendorId, query, args...); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: table not found", ErrInvalidTableSession)
+			}
+			return nil, err
+		}
+	}
+
+	session.ID = uuid.New()
+	session.OpenedAt = now
+	session.Created_at = now
+	session.Updated_at = now
+
+	query, args, err := QB.Insert("table_sessions").
+		Columns(tableSessionColumns...).
+		Values(session.ID, session.VendorId, session.PartySize, session.ServerId, session.CustomerId, session.ReservationId,
+			session.MergedIntoId, session.OpenedAt, session.ClosedAt, session.Created_at, session.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(tableSessionColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	var created types.TableSession
+	if err := tx.QueryRowx(query, args...).StructScan(&created); err != nil {
+		return nil, fmt.Errorf("error opening table session: %w", err)
+	}
+
+	if err := seatTables(tx, created, tableIDs, now); err != nil {
+		return nil, err
+	}
+	created.TableIds = tableIDs
+	return &created, nil
+}
+
+// seatTables sits the session at the tables, which must be the vendor's and
+// free, and marks them as taken.
+func seatTables(tx *sqlx.Tx, session types.TableSession, tableIDs []uuid.UUID, now time.Time) error {
+	var count int
+	query, args, err := QB.Select("COUNT(*)").
+		From("tables").
+		Where(squirrel.Eq{"id": tableIDs, "vendor_id": session.VendorId}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := tx.Get(&count, query, args...); err != nil {
+		return err
+	}
+	if count != len(tableIDs) {
+		return fmt.Errorf("%w: table does not belong to this vendor", ErrInvalidTableSession)
+	}
+
+	insert := QB.Insert("table_session_tables").Columns("session_id", "table_id", "seated_at")
+	for _, tableID := range tableIDs {
+		insert = insert.Values(session.ID, tableID, now)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return ErrTableOccupied
+		}
+		return fmt.Errorf("error seating tables: %w", err)
+	}
+
+	query, args, err = QB.Update("tables").
+		Set("is_available", false).
+		Set("customer_id", session.CustomerId).
+		Where(squirrel.Eq{"id": tableIDs}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return publishTableEvents(tx, tableIDs)
+}
+
+// leaveTables ends the session's stay at the given tables, or at all of
+// them when tableIDs is nil, and frees them.
+func leaveTables(tx *sqlx.Tx, sessionID uuid.UUID, tableIDs []uuid.UUID, now time.Time) error {
+	builder := QB.Update("table_session_tables").
+		Set("left_at", now).
+		Where("session_id = ? AND left_at IS NULL", sessionID)
+	if tableIDs != nil {
+		builder = builder.Where(squirrel.Eq{"table_id": tableIDs})
+	}
+	query, args, err := builder.Suffix("RETURNING table_id").ToSql()
+	if err != nil {
+		return err
+	}
+
+	var left []uuid.UUID
+	if err := tx.Select(&left, query, args...); err != nil {
+		return fmt.Errorf("error leaving tables: %w", err)
+	}
+	if len(left) == 0 {
+		return nil
+	}
+
+	query, args, err = QB.Update("tables").
+		Set("is_available", true).
+		Set("customer_id", nil).
+		Where(squirrel.Eq{"id": left}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return publishTableEvents(tx, left)
+}
+
+// tableSessionAt returns the id of the session seated at a table, if any.
+func tableSessionAt(q sqlx.Queryer, tableID uuid.UUID) (*uuid.UUID, error) {
+	var sessionID uuid.UUID
+	query, args, err := QB.Select("session_id").
+		From("table_session_tables").
+		Where("table_id = ? AND left_at IS NULL", tableID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Get(q, &sessionID, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &sessionID, nil
+}
+
+func openSessionForUpdate(tx *sqlx.Tx, id uuid.UUID) (*types.TableSession, error) {
+	session, err := getTableSession(tx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if session.ClosedAt != nil {
+		return nil, fmt.Errorf("%w: the session is already closed", ErrInvalidTableSession)
+	}
+	return session, nil
+}
+
+func getTableSession(q sqlx.Queryer, id uuid.UUID, forUpdate bool) (*types.TableSession, error) {
+	builder := QB.Select(tableSessionColumns...).From("table_sessions").Where("id = ?", id)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var session types.TableSession
+	if err := sqlx.Get(q, &session, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTableSessionNotFound
+		}
+		return nil, err
+	}
+
+	sessions := []types.TableSession{session}
+	if err := attachSessionTables(q, sessions); err != nil {
+		return nil, err
+	}
+	return &sessions[0], nil
+}
+
+// attachSessionTables sets the tables each session is at, or for closed
+// sessions every table it sat at.
+func attachSessionTables(q sqlx.Queryer, sessions []types.TableSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+
+	var rows []struct {
+		SessionID uuid.UUID  `db:"session_id"`
+		TableID   uuid.UUID  `db:"table_id"`
+		LeftAt    *time.Time `db:"left_at"`
+	}
+	query, args, err := QB.Select("session_id", "table_id", "left_at").
+		From("table_session_tables").
+		Where(squirrel.Eq{"session_id": ids}).
+		OrderBy("seated_at").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := sqlx.Select(q, &rows, query, args...); err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		open := sessions[i].ClosedAt == nil
+		for _, row := range rows {
+			if row.SessionID != sessions[i].ID || (open && row.LeftAt != nil) {
+				continue
+			}
+			if !containsUUID(sessions[i].TableIds, row.TableID) {
+				sessions[i].TableIds = append(sessions[i].TableIds, row.TableID)
+			}
+		}
+	}
+	return nil
+}
+
+// tableSessionBill totals the session's orders. Cancelled orders don't count.
+func tableSessionBill(q sqlx.Queryer, sessionID uuid.UUID) (*types.TableSessionBill, error) {
+	var bill types.TableSessionBill
+	query, args, err := QB.Select(
+		"COUNT(*) AS order_count",
+		"COALESCE(SUM(subtotal), 0) AS subtotal",
+		"COALESCE(SUM(discount_total), 0) AS discount_total",
+		"COALESCE(SUM(tax_total), 0) AS tax_total",
+		"COALESCE(SUM(service_charge_total), 0) AS service_charge_total",
+		"COALESCE(SUM(tip_total), 0) AS tip_total",
+		"COALESCE(SUM(total_order_cost), 0) AS total",
+	).
+		From("orders").
+		Where("table_session_id = ? AND status <> 'cancelled'", sessionID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Get(q, &bill, query, args...); err != nil {
+		return nil, fmt.Errorf("error totalling session bill: %w", err)
+	}
+
+	query, args, err = QB.Select("COALESCE(SUM(payments.captured_amount), 0)").
+		From("payments").
+		Join("orders ON orders.id = payments.order_id").
+		Where("orders.table_session_id = ? AND orders.status <> 'cancelled'", sessionID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Get(q, &bill.PaidTotal, query, args...); err != nil {
+		return nil, fmt.Errorf("error totalling session payments: %w", err)
+	}
+
+	bill.BalanceDue = math.Max(roundMoney(bill.Total-bill.PaidTotal), 0)
+	return &bill, nil
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -1,28 +1,58 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"net/http"
 	"net/url"
 	"restaurant-management-backend/internal/helpers"
 	"restaurant-management-backend/internal/types"
+	"strconv"
 )
 
+// needsServiceColumn derives whether a table is waiting on staff from its
+// unresolved service requests.
+const needsServiceColumn = `EXISTS (
+	SELECT 1 FROM service_requests sr WHERE sr.table_id = tables.id AND sr.status IN ('open', 'acknowledged')
+) AS is_needs_service`
+
+var tableColumns = []string{"id", "name", "vendor_id", "customer_id", "is_available", needsServiceColumn,
+	"capacity", "min_capacity", "is_reservable", "is_combinable", "qr_token_version",
+	"section_id", "pos_x", "pos_y", "shape", "rotation"}
+
+var tableShapes = map[string]bool{"rectangle": true, "square": true, "round": true}
+
+// FetchTables lists tables. section_id narrows the list to one section, or to
+// the tables outside any section with section_id=none.
+
 func (s *service) FetchTables(queryParams url.Values) ([]types.Table, *types.Meta, error) {
 	var tables []types.Table
 
-	columns := []string{"id", "name", "vendor_id", "customer_id", "is_available", "is_needs_service"}
-
 	searchColumns := []string{"name"}
 
+	additionalFilters := []string{}
+	switch sectionID := queryParams.Get("section_id"); sectionID {
+	case "":
+	case "none":
+		additionalFilters = append(additionalFilters, "section_id IS NULL")
+	default:
+		id, err := uuid.Parse(sectionID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid section id", ErrInvalidLayout)
+		}
+		additionalFilters = append(additionalFilters, fmt.Sprintf("section_id = '%s'", id))
+	}
+
 	meta, err := s.BuildQuery(
 		&tables,
 		"tables",
 		[]string{},
-		columns,
+		tableColumns,
 		searchColumns,
 		queryParams,
-		[]string{},
+		additionalFilters,
 	)
 
 	if err != nil {
@@ -37,17 +67,36 @@ func (s *service) FetchTables(queryParams url.Values) ([]types.Table, *types.Met
 }
 
 func (s *service) DeleteTable(id string) error {
-	query, args, err := QB.Delete("tables").Where("id = ?", id).ToSql()
+	tx, err := s.db.Beginx()
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec(query, args...)
-	return err
+	defer tx.Rollback()
+
+	query, args, err := QB.Delete("tables").Where("id = ?", id).Suffix("RETURNING id, vendor_id").ToSql()
+	if err != nil {
+		return err
+	}
+	var deleted struct {
+		ID       uuid.UUID `db:"id"        json:"id"`
+		VendorId uuid.UUID `db:"vendor_id" json:"vendor_id"`
+	}
+	if err := tx.Get(&deleted, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if err := publishEvent(tx, "table.deleted", []string{vendorTablesTopic(deleted.VendorId)}, deleted); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *service) GetTableByID(id string) (types.Table, error) {
 	var table types.Table
-	query, args, err := QB.Select("*").From("tables").Where("id = ?", id).ToSql()
+	query, args, err := QB.Select(tableColumns...).From("tables").Where("id = ?", id).ToSql()
 	if err != nil {
 		return table, err
 	}
@@ -79,7 +128,18 @@ func (s *service) ParseTableFromForm(r *http.Request) (types.Table, error) {
 	}
 
 	table.IsAvailable = helpers.ParseBoolWithDefault(r.FormValue("is_available"), true)
-	table.NeedsService = helpers.ParseBoolWithDefault(r.FormValue("is_needs_service"), false)
+	table.IsReservable = helpers.ParseBoolWithDefault(r.FormValue("is_reservable"), true)
+	table.IsCombinable = helpers.ParseBoolWithDefault(r.FormValue("is_combinable"), false)
+
+	table.Capacity, table.MinCapacity = 4, 1
+	if err := parseTableCapacity(&table, r); err != nil {
+		return table, err
+	}
+
+	table.Shape = "rectangle"
+	if err := s.parseTableLayout(&table, r); err != nil {
+		return table, err
+	}
 
 	table.ID = uuid.New()
 	return table, nil
@@ -87,14 +147,17 @@ func (s *service) ParseTableFromForm(r *http.Request) (types.Table, error) {
 
 func (s *service) InsertTable(table *types.Table) error {
 	query, args, err := QB.Insert("tables").
-		Columns("id", "name", "vendor_id", "customer_id", "is_available", "is_needs_service").
-		Values(table.ID, table.Name, table.VendorId, table.CustomerId, table.IsAvailable, table.NeedsService).
+		Columns("id", "name", "vendor_id", "customer_id", "is_available",
+			"capacity", "min_capacity", "is_reservable", "is_combinable",
+			"section_id", "pos_x", "pos_y", "shape", "rotation").
+		Values(table.ID, table.Name, table.VendorId, table.CustomerId, table.IsAvailable,
+			table.Capacity, table.MinCapacity, table.IsReservable, table.IsCombinable,
+			table.SectionId, table.PosX, table.PosY, table.Shape, table.Rotation).
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec(query, args...)
-	return err
+	return s.writeTable(table.ID, query, args)
 }
 
 func (s *service) UpdateTableFromForm(table *types.Table, r *http.Request) error {
@@ -118,10 +181,100 @@ func (s *service) UpdateTableFromForm(table *types.Table, r *http.Request) error
 		table.IsAvailable = helpers.ParseBoolWithDefault(isAvailable, table.IsAvailable)
 	}
 
-	if isNeedsService := r.FormValue("is_needs_service"); isNeedsService != "" {
-		table.NeedsService = helpers.ParseBoolWithDefault(isNeedsService, table.NeedsService)
+	if isReservable := r.FormValue("is_reservable"); isReservable != "" {
+		table.IsReservable = helpers.ParseBoolWithDefault(isReservable, table.IsReservable)
+	}
+
+	if isCombinable := r.FormValue("is_combinable"); isCombinable != "" {
+		table.IsCombinable = helpers.ParseBoolWithDefault(isCombinable, table.IsCombinable)
+	}
+
+	if err := parseTableCapacity(table, r); err != nil {
+		return err
+	}
+	return s.parseTableLayout(table, r)
+}
+
+// parseTableCapacity reads the number of seats a table offers. min_capacity
+// keeps small parties off large tables when booking.
+func parseTableCapacity(table *types.Table, r *http.Request) error {
+	if capacity := r.FormValue("capacity"); capacity != "" {
+		value, err := strconv.Atoi(capacity)
+		if err != nil || value < 1 {
+			return helpers.NewValidationError("Capacity must be a positive integer")
+		}
+		table.Capacity = value
 	}
 
+	if minCapacity := r.FormValue("min_capacity"); minCapacity != "" {
+		value, err := strconv.Atoi(minCapacity)
+		if err != nil || value < 1 {
+			return helpers.NewValidationError("Min capacity must be a positive integer")
+		}
+		table.MinCapacity = value
+	}
+
+	if table.MinCapacity > table.Capacity {
+		return helpers.NewValidationError("Min capacity cannot exceed capacity")
+	}
+	return nil
+}
+
+// parseTableLayout reads where the table sits on the floor plan. A section
+// must belong to the table's vendor; section_id=none takes the table out of
+// its section.
+func (s *service) parseTableLayout(table *types.Table, r *http.Request) error {
+	switch sectionID := r.FormValue("section_id"); sectionID {
+	case "":
+	case "none":
+		table.SectionId = nil
+	default:
+		id, err := uuid.Parse(sectionID)
+		if err != nil {
+			return helpers.NewValidationError("Invalid section id")
+		}
+		table.SectionId = &id
+	}
+	if table.SectionId != nil {
+		sections, err := vendorSectionIDs(s.db, table.VendorId)
+		if err != nil {
+			return err
+		}
+		if !sections[*table.SectionId] {
+			return helpers.NewValidationError("Section does not belong to this vendor")
+		}
+	}
+
+	if x := r.FormValue("x"); x != "" {
+		value, err := strconv.ParseFloat(x, 64)
+		if err != nil {
+			return helpers.NewValidationError("x must be a number")
+		}
+		table.PosX = value
+	}
+
+	if y := r.FormValue("y"); y != "" {
+		value, err := strconv.ParseFloat(y, 64)
+		if err != nil {
+			return helpers.NewValidationError("y must be a number")
+		}
+		table.PosY = value
+	}
+
+	if shape := r.FormValue("shape"); shape != "" {
+		if !tableShapes[shape] {
+			return helpers.NewValidationError("Shape must be rectangle, square or round")
+		}
+		table.Shape = shape
+	}
+
+	if rotation := r.FormValue("rotation"); rotation != "" {
+		value, err := strconv.Atoi(rotation)
+		if err != nil || value < 0 || value >= 360 {
+			return helpers.NewValidationError("Rotation must be between 0 and 359 degrees")
+		}
+		table.Rotation = value
+	}
 	return nil
 }
 
@@ -131,12 +284,37 @@ func (s *service) UpdateTable(table *types.Table) error {
 		Set("vendor_id", table.VendorId).
 		Set("customer_id", table.CustomerId).
 		Set("is_available", table.IsAvailable).
-		Set("is_needs_service", table.NeedsService).
+		Set("capacity", table.Capacity).
+		Set("min_capacity", table.MinCapacity).
+		Set("is_reservable", table.IsReservable).
+		Set("is_combinable", table.IsCombinable).
+		Set("section_id", table.SectionId).
+		Set("pos_x", table.PosX).
+		Set("pos_y", table.PosY).
+		Set("shape", table.Shape).
+		Set("rotation", table.Rotation).
 		Where("id = ?", table.ID).
 		ToSql()
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec(query, args...)
-	return err
+	return s.writeTable(table.ID, query, args)
+}
+
+// writeTable applies a change to a table and announces its new state when
+// the change commits.
+func (s *service) writeTable(tableID uuid.UUID, query string, args []interface{}) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	if err := publishTableEvents(tx, []uuid.UUID{tableID}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
@@ -0,0 +1,220 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"math"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTaxClass = errors.New("invalid tax class")
+
+var taxClassColumns = []string{
+	"id", "vendor_id", "name", "rate", "is_default", "created_at", "updated_at",
+}
+
+func (s *service) ListTaxClasses(queryParams url.Values) ([]types.TaxClass, *types.Meta, error) {
+	var taxClasses []types.TaxClass
+
+	meta, err := s.BuildQuery(
+		&taxClasses,
+		"tax_classes",
+		[]string{},
+		taxClassColumns,
+		[]string{"name"},
+		queryParams,
+		[]string{},
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tax classes: %w", err)
+	}
+
+	if taxClasses == nil {
+		taxClasses = []types.TaxClass{}
+	}
+
+	return taxClasses, meta, nil
+}
+
+func (s *service) GetTaxClassByID(id string) (*types.TaxClass, error) {
+	var taxClass types.TaxClass
+	query, args, err := QB.Select(strings.Join(taxClassColumns, ", ")).
+		From("tax_classes").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&taxClass, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("tax class not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch tax class: %w", err)
+	}
+	return &taxClass, nil
+}
+
+func (s *service) CreateTaxClass(taxClass types.TaxClass) (*types.TaxClass, error) {
+	if taxClass.VendorId == uuid.Nil || taxClass.Name == "" {
+		return nil, errors.New("missing required parameters")
+	}
+	rate, isDefault := 0.0, false
+	setTaxClassDefaults(&taxClass, types.TaxClass{Rate: &rate, IsDefault: &isDefault})
+	if err := validateTaxRate(*taxClass.Rate); err != nil {
+		return nil, err
+	}
+
+	taxClass.ID = uuid.New()
+	taxClass.Created_at = time.Now()
+	taxClass.Updated_at = time.Now()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if *taxClass.IsDefault {
+		if err := clearDefaultTaxClass(tx, taxClass.VendorId); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err := QB.Insert("tax_classes").
+		Columns("id", "vendor_id", "name", "rate", "is_default", "created_at", "updated_at").
+		Values(taxClass.ID, taxClass.VendorId, taxClass.Name, taxClass.Rate, taxClass.IsDefault, taxClass.Created_at, taxClass.Updated_at).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(taxClassColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	if err := tx.QueryRowx(query, args...).StructScan(&taxClass); err != nil {
+		return nil, fmt.Errorf("error inserting tax class: %w", err)
+	}
+
+	return &taxClass, tx.Commit()
+}
+
+func (s *service) UpdateTaxClass(id string, taxClass types.TaxClass) (*types.TaxClass, error) {
+	existing, err := s.GetTaxClassByID(id)
+	if err != nil {
+		return nil, err
+	}
+	setTaxClassDefaults(&taxClass, *existing)
+	if err := validateTaxRate(*taxClass.Rate); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if *taxClass.IsDefault && !*existing.IsDefault {
+		if err := clearDefaultTaxClass(tx, existing.VendorId); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err := QB.Update("tax_classes").
+		Set("name", taxClass.Name).
+		Set("rate", taxClass.Rate).
+		Set("is_default", taxClass.IsDefault).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(taxClassColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	var updated types.TaxClass
+	if err := tx.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		return nil, fmt.Errorf("error updating tax class: %w", err)
+	}
+
+	return &updated, tx.Commit()
+}
+
+func (s *service) DeleteTaxClass(id string) error {
+	_, err := deleteById(s, id, "tax_classes")
+	if err != nil {
+		return fmt.Errorf("error deleting tax class: %w", err)
+	}
+	return nil
+}
+
+// setTaxClassDefaults fills in the fields a tax class payload left out.
+func setTaxClassDefaults(taxClass *types.TaxClass, defaults types.TaxClass) {
+	if taxClass.Name == "" {
+		taxClass.Name = defaults.Name
+	}
+	if taxClass.Rate == nil {
+		taxClass.Rate = defaults.Rate
+	}
+	if taxClass.IsDefault == nil {
+		taxClass.IsDefault = defaults.IsDefault
+	}
+}
+
+func clearDefaultTaxClass(tx *sqlx.Tx, vendorID uuid.UUID) error {
+	query, args, err := QB.Update("tax_classes").
+		Set("is_default", false).
+		Where(squirrel.Eq{"vendor_id": vendorID, "is_default": true}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// checkTaxClassVendor makes sure items are only taxed with a tax class of
+// their own vendor.
+func checkTaxClassVendor(q sqlx.Queryer, taxClassID *uuid.UUID, vendorID uuid.UUID) error {
+	if taxClassID == nil {
+		return nil
+	}
+
+	var owner uuid.UUID
+	if err := sqlx.Get(q, &owner, "SELECT vendor_id FROM tax_classes WHERE id = $1", *taxClassID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: tax class not found", ErrInvalidTaxClass)
+		}
+		return err
+	}
+	if owner != vendorID {
+		return fmt.Errorf("%w: the tax class belongs to another vendor", ErrInvalidTaxClass)
+	}
+	return nil
+}
+
+func validateTaxRate(rate float64) error {
+	if rate < 0 || rate >= 1 {
+		return errors.New("rate must be a fraction between 0 and 1")
+	}
+	return nil
+}
+
+// calculateTax splits a line amount into its net and tax parts. Inclusive
+// amounts already contain the tax, exclusive amounts get it added on top.
+func calculateTax(amount, rate float64, inclusive bool) (float64, float64) {
+	if inclusive {
+		net := roundMoney(amount / (1 + rate))
+		return net, roundMoney(amount - net)
+	}
+	return roundMoney(amount), roundMoney(amount * rate)
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+var ErrInvalidVariant = errors.New("invalid item variant")
+
+var variantColumns = []string{
+	"id", "item_id", "name", "sku", "price", "is_default", "is_available", "display_order", "created_at", "updated_at",
+	"stock_quantity", "low_stock_threshold",
+}
+
+func (s *service) ListItemVariants(itemID uuid.UUID) ([]types.ItemVariant, error) {
+	variants, err := itemVariants(s.db, []uuid.UUID{itemID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list item variants: %w", err)
+	}
+	if variants[itemID] == nil {
+		return []types.ItemVariant{}, nil
+	}
+	return variants[itemID], nil
+}
+
+func (s *service) GetItemVariantByID(id string) (*types.ItemVariant, error) {
+	var variant types.ItemVariant
+	query, args, err := QB.Select(strings.Join(variantColumns, ", ")).
+		From("item_variants").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SQL query: %w", err)
+	}
+	if err := s.db.Get(&variant, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("variant not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch variant: %w", err)
+	}
+	return &variant, nil
+}
+
+func (s *service) CreateItemVariant(itemID string, variant types.ItemVariant) (*types.ItemVariant, error) {
+	item, err := s.GetItemByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+	if variant.Name == "" || variant.Price <= 0 {
+		return nil, errors.New("missing required parameters")
+	}
+	isAvailable, displayOrder := true, 0
+	setItemVariantDefaults(&variant, types.ItemVariant{IsAvailable: &isAvailable, DisplayOrder: &displayOrder})
+
+	variant.ID = uuid.New()
+	variant.ItemId = item.ID
+	variant.Created_at = time.Now()
+	variant.Updated_at = time.Now()
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if variant.IsDefault {
+		if err := clearDefaultVariant(tx, item.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertItemVariant(tx, &variant); err != nil {
+		return nil, fmt.Errorf("error inserting variant: %w", err)
+	}
+
+	if variant.IsDefault {
+		if err := syncItemPrice(tx, item.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &variant, tx.Commit()
+}
+
+func (s *service) UpdateItemVariant(id string, variant types.ItemVariant) (*types.ItemVariant, error) {
+	existing, err := s.GetItemVariantByID(id)
+	if err != nil {
+		return nil, err
+	}
+	setItemVariantDefaults(&variant, *existing)
+	if variant.Price < 0 {
+		return nil, errors.New("price cannot be negative")
+	}
+	// The default can be moved to another variant but not simply removed
+	if existing.IsDefault {
+		variant.IsDefault = true
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if variant.IsDefault && !existing.IsDefault {
+		if err := clearDefaultVariant(tx, existing.ItemId); err != nil {
+			return nil, err
+		}
+	}
+
+	query, args, err := QB.Update("item_variants").
+		Set("name", variant.Name).
+		Set("sku", variant.Sku).
+		Set("price", variant.Price).
+		Set("is_default", variant.IsDefault).
+		Set("is_available", variant.IsAvailable).
+		Set("display_order", variant.DisplayOrder).
+		Set("low_stock_threshold", variant.LowStockThreshold).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": id}).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(variantColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building update query: %w", err)
+	}
+
+	var updated types.ItemVariant
+	if err := tx.QueryRowx(query, args...).StructScan(&updated); err != nil {
+		return nil, fmt.Errorf("error updating variant: %w", err)
+	}
+
+	if updated.IsDefault {
+		if err := syncItemPrice(tx, updated.ItemId); err != nil {
+			return nil, err
+		}
+	}
+
+	return &updated, tx.Commit()
+}
+
+// DeleteItemVariant removes a variant. An item always keeps at least one
+// variant, and when the default goes the next variant in line takes over.
+func (s *service) DeleteItemVariant(id string) error {
+	variant, err := s.GetItemVariantByID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var remaining []uuid.UUID
+	query, args, err := QB.Select("id").
+		From("item_variants").
+		Where("item_id = ? AND id <> ?", variant.ItemId, variant.ID).
+		OrderBy("display_order", "created_at").
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if err := tx.Select(&remaining, query, args...); err != nil {
+		return err
+	}
+	if len(remaining) == 0 {
+		return errors.New("an item must keep at least one variant")
+	}
+
+	query, args, err = QB.Delete("item_variants").Where("id = ?", variant.ID).ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("error deleting variant: %w", err)
+	}
+
+	if variant.IsDefault {
+		query, args, err = QB.Update("item_variants").Set("is_default", true).Where("id = ?", remaining[0]).ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+		if err := syncItemPrice(tx, variant.ItemId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// setItemVariantDefaults fills in the fields a variant payload left out. An
+// empty SKU clears it.
+func setItemVariantDefaults(variant *types.ItemVariant, defaults types.ItemVariant) {
+	if variant.Name == "" {
+		variant.Name = defaults.Name
+	}
+	if variant.Price == 0 {
+		variant.Price = defaults.Price
+	}
+	if variant.Sku == nil {
+		variant.Sku = defaults.Sku
+	} else if *variant.Sku == "" {
+		variant.Sku = nil
+	}
+	if variant.IsAvailable == nil {
+		variant.IsAvailable = defaults.IsAvailable
+	}
+	if variant.DisplayOrder == nil {
+		variant.DisplayOrder = defaults.DisplayOrder
+	}
+	if variant.LowStockThreshold == nil {
+		variant.LowStockThreshold = defaults.LowStockThreshold
+	}
+}
+
+func insertItemVariant(tx *sqlx.Tx, variant *types.ItemVariant) error {
+	query, args, err := QB.Insert("item_variants").
+		Columns(variantColumns...).
+		Values(variant.ID, variant.ItemId, variant.Name, variant.Sku, variant.Price, variant.IsDefault,
+			variant.IsAvailable, variant.DisplayOrder, variant.Created_at, variant.Updated_at,
+			nil, variant.LowStockThreshold).
+		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(variantColumns, ", "))).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	return tx.QueryRowx(query, args...).StructScan(variant)
+}
+
+func clearDefaultVariant(tx *sqlx.Tx, itemID uuid.UUID) error {
+	query, args, err := QB.Update("item_variants").
+		Set("is_default", false).
+		Where(squirrel.Eq{"item_id": itemID, "is_default": true}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+// syncItemPrice keeps items.price, the price shown in listings, at the price
+// of the item's default variant.
+func syncItemPrice(tx *sqlx.Tx, itemID uuid.UUID) error {
+	query, args, err := QB.Update("items").
+		Set("price", squirrel.Expr("(SELECT price FROM item_variants WHERE item_id = ? AND is_default)", itemID)).
+		Set("updated_at", time.Now()).
+		Where("id = ?", itemID).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, args...)
+	return err
+}
+
+func itemVariants(q sqlx.Queryer, itemIDs []uuid.UUID) (map[uuid.UUID][]types.ItemVariant, error) {
+	byItem := make(map[uuid.UUID][]types.ItemVariant)
+	if len(itemIDs) == 0 {
+		return byItem, nil
+	}
+
+	var variants []types.ItemVariant
+	query, args, err := QB.Select(variantColumns...).
+		From("item_variants").
+		Where(squirrel.Eq{"item_id": itemIDs}).
+		OrderBy("display_order", "price").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlx.Select(q, &variants, query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, variant := range variants {
+		byItem[variant.ItemId] = append(byItem[variant.ItemId], variant)
+	}
+	return byItem, nil
+}
+
+func (s *service) attachItemVariants(items []types.Item) error {
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+
+	variants, err := itemVariants(s.db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].Variants = variants[items[i].ID]
+	}
+	return nil
+}
+
+// resolveVariant picks the variant for a cart line, falling back to the
+// item's default, and makes sure it can still be ordered.
+func resolveVariant(q sqlx.Queryer, itemID uuid.UUID, variantID *uuid.UUID) (uuid.UUID, error) {
+	var variant types.ItemVariant
+	builder := QB.Select(variantColumns...).From("item_variants").Where("item_id = ?", itemID)
+	if variantID != nil {
+		builder = builder.Where("id = ?", *variantID)
+	} else {
+		builder = builder.Where("is_default")
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := sqlx.Get(q, &variant, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%w: variant not found for this item", ErrInvalidVariant)
+		}
+		return uuid.Nil, err
+	}
+	if !*variant.IsAvailable {
+		return uuid.Nil, fmt.Errorf("%w: %s is not available", ErrInvalidVariant, variant.Name)
+	}
+	return variant.ID, nil
+}
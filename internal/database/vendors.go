@@ -14,11 +14,30 @@ import (
 	"time"
 )
 
+// ErrInvalidVendor is returned when a vendor's settings cannot be saved as
+// given.
+var ErrInvalidVendor = errors.New("invalid vendor")
+
 var (
 	vendorColumns = []string{
 		"id",
 		"name",
 		"description",
+		"prices_include_tax",
+		"timezone",
+		"dine_in_enabled",
+		"pickup_enabled",
+		"delivery_enabled",
+		"pickup_lead_minutes",
+		"reservation_turn_minutes",
+		"reservation_interval_minutes",
+		"address_line1",
+		"address_line2",
+		"city",
+		"postal_code",
+		"country",
+		"latitude",
+		"longitude",
 		"created_at",
 		"updated_at",
 		helpers.ImageFormat,
@@ -28,14 +47,26 @@ var (
 func (s *service) ListVendors(queryParams url.Values) ([]types.Vendor, *types.Meta, error) {
 	var vendors []types.Vendor
 
+	columns := vendorColumns
+	var additionalFilters []string
+
+	geo, err := parseGeoQuery(queryParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	if geo != nil {
+		columns = append(append([]string{}, vendorColumns...), geo.distance()+" AS distance_km")
+		additionalFilters = append(additionalFilters, geo.filters()...)
+	}
+
 	meta, err := s.BuildQuery(
 		&vendors,
 		"vendors",
 		[]string{},
-		vendorColumns,
-		[]string{"name", "description"},
+		columns,
+		[]string{"name", "description", "city"},
 		queryParams,
-		[]string{},
+		additionalFilters,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list vendors: %w", err)
@@ -45,6 +76,10 @@ func (s *service) ListVendors(queryParams url.Values) ([]types.Vendor, *types.Me
 		vendors = []types.Vendor{}
 	}
 
+	if err := attachOpenStatus(s.db, vendors); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch opening hours: %w", err)
+	}
+
 	return vendors, meta, nil
 }
 
@@ -66,20 +101,55 @@ func (s *service) GetVendorByID(id string) (*types.Vendor, error) {
 		logger.Log.WithError(err).WithField("id", id).Error("Failed to fetch vendor from database")
 		return nil, fmt.Errorf("internal server error %w", err)
 	}
-	return vendor, nil
+
+	vendors := []types.Vendor{*vendor}
+	if err := attachOpenStatus(s.db, vendors); err != nil {
+		return nil, fmt.Errorf("failed to fetch opening hours: %w", err)
+	}
+	return &vendors[0], nil
 }
 
 func (s *service) CreateVendor(vendor types.Vendor) (*types.Vendor, error) {
 	vendor.ID = uuid.New()
 
+	if vendor.Timezone == "" {
+		vendor.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(vendor.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidVendor, vendor.Timezone)
+	}
+	if err := validateCoordinates(vendor.Latitude, vendor.Longitude); err != nil {
+		return nil, err
+	}
+	pricesIncludeTax, dineIn, pickup, delivery := false, true, true, false
+	leadMinutes, turnMinutes, intervalMinutes := 15, 90, 15
+	setVendorDefaults(&vendor, types.Vendor{
+		PricesIncludeTax:           &pricesIncludeTax,
+		DineInEnabled:              &dineIn,
+		PickupEnabled:              &pickup,
+		DeliveryEnabled:            &delivery,
+		PickupLeadMinutes:          &leadMinutes,
+		ReservationTurnMinutes:     &turnMinutes,
+		ReservationIntervalMinutes: &intervalMinutes,
+	})
+	if err := validateVendorSettings(vendor); err != nil {
+		return nil, err
+	}
+
 	if vendor.Img != nil {
 		*vendor.Img = strings.TrimPrefix(*vendor.Img, helpers.Domain+"/")
 	}
 
 	query, args, err := QB.
 		Insert("vendors").
-		Columns("id", "img", "name", "description").
-		Values(vendor.ID, vendor.Img, vendor.Name, vendor.Description).
+		Columns("id", "img", "name", "description", "prices_include_tax", "timezone",
+			"dine_in_enabled", "pickup_enabled", "delivery_enabled", "pickup_lead_minutes",
+			"reservation_turn_minutes", "reservation_interval_minutes",
+			"address_line1", "address_line2", "city", "postal_code", "country", "latitude", "longitude").
+		Values(vendor.ID, vendor.Img, vendor.Name, vendor.Description, vendor.PricesIncludeTax, vendor.Timezone,
+			vendor.DineInEnabled, vendor.PickupEnabled, vendor.DeliveryEnabled, vendor.PickupLeadMinutes,
+			vendor.ReservationTurnMinutes, vendor.ReservationIntervalMinutes,
+			vendor.AddressLine1, vendor.AddressLine2, vendor.City, vendor.PostalCode, vendor.Country, vendor.Latitude, vendor.Longitude).
 		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(vendorColumns, ", "))).
 		ToSql()
 	if err != nil {
@@ -112,11 +182,40 @@ func (s *service) UpdateVendor(newVendor types.Vendor, id string) (*types.Vendor
 		*newVendor.Img = strings.TrimPrefix(*newVendor.Img, helpers.Domain+"/")
 	}
 
+	if newVendor.Timezone == "" {
+		newVendor.Timezone = existingVendor.Timezone
+	}
+	if _, err := time.LoadLocation(newVendor.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidVendor, newVendor.Timezone)
+	}
+	if err := validateCoordinates(newVendor.Latitude, newVendor.Longitude); err != nil {
+		return nil, err
+	}
+	setVendorDefaults(&newVendor, *existingVendor)
+	if err := validateVendorSettings(newVendor); err != nil {
+		return nil, err
+	}
+
 	query, args, err := QB.
 		Update("vendors").
 		Set("img", newVendor.Img).
 		Set("name", newVendor.Name).
 		Set("description", newVendor.Description).
+		Set("prices_include_tax", newVendor.PricesIncludeTax).
+		Set("timezone", newVendor.Timezone).
+		Set("dine_in_enabled", newVendor.DineInEnabled).
+		Set("pickup_enabled", newVendor.PickupEnabled).
+		Set("delivery_enabled", newVendor.DeliveryEnabled).
+		Set("pickup_lead_minutes", newVendor.PickupLeadMinutes).
+		Set("reservation_turn_minutes", newVendor.ReservationTurnMinutes).
+		Set("reservation_interval_minutes", newVendor.ReservationIntervalMinutes).
+		Set("address_line1", newVendor.AddressLine1).
+		Set("address_line2", newVendor.AddressLine2).
+		Set("city", newVendor.City).
+		Set("postal_code", newVendor.PostalCode).
+		Set("country", newVendor.Country).
+		Set("latitude", newVendor.Latitude).
+		Set("longitude", newVendor.Longitude).
 		Set("updated_at", time.Now()).
 		Where(squirrel.Eq{"id": id}).
 		Suffix(fmt.Sprintf("RETURNING %s", strings.Join(vendorColumns, ", "))).
@@ -142,6 +241,61 @@ func (s *service) UpdateVendor(newVendor types.Vendor, id string) (*types.Vendor
 	return &updatedVendor, nil
 }
 
+// setVendorDefaults fills in the settings and location a vendor payload left
+// out.
+func setVendorDefaults(vendor *types.Vendor, defaults types.Vendor) {
+	if vendor.PricesIncludeTax == nil {
+		vendor.PricesIncludeTax = defaults.PricesIncludeTax
+	}
+	if vendor.DineInEnabled == nil {
+		vendor.DineInEnabled = defaults.DineInEnabled
+	}
+	if vendor.PickupEnabled == nil {
+		vendor.PickupEnabled = defaults.PickupEnabled
+	}
+	if vendor.DeliveryEnabled == nil {
+		vendor.DeliveryEnabled = defaults.DeliveryEnabled
+	}
+	if vendor.PickupLeadMinutes == nil {
+		vendor.PickupLeadMinutes = defaults.PickupLeadMinutes
+	}
+	if vendor.ReservationTurnMinutes == nil {
+		vendor.ReservationTurnMinutes = defaults.ReservationTurnMinutes
+	}
+	if vendor.ReservationIntervalMinutes == nil {
+		vendor.ReservationIntervalMinutes = defaults.ReservationIntervalMinutes
+	}
+	if vendor.AddressLine1 == nil {
+		vendor.AddressLine1 = defaults.AddressLine1
+	}
+	if vendor.AddressLine2 == nil {
+		vendor.AddressLine2 = defaults.AddressLine2
+	}
+	if vendor.City == nil {
+		vendor.City = defaults.City
+	}
+	if vendor.PostalCode == nil {
+		vendor.PostalCode = defaults.PostalCode
+	}
+	if vendor.Country == nil {
+		vendor.Country = defaults.Country
+	}
+	// Coordinates come as a pair, checked by validateCoordinates beforehand
+	if vendor.Latitude == nil && vendor.Longitude == nil {
+		vendor.Latitude, vendor.Longitude = defaults.Latitude, defaults.Longitude
+	}
+}
+
+func validateVendorSettings(vendor types.Vendor) error {
+	if *vendor.PickupLeadMinutes < 0 {
+		return fmt.Errorf("%w: pickup_lead_minutes cannot be negative", ErrInvalidVendor)
+	}
+	if *vendor.ReservationTurnMinutes <= 0 || *vendor.ReservationIntervalMinutes <= 0 {
+		return fmt.Errorf("%w: reservation_turn_minutes and reservation_interval_minutes must be positive", ErrInvalidVendor)
+	}
+	return nil
+}
+
 func (s *service) DeleteVendor(id string) error {
 	query, args, err := QB.Delete("vendors").
 		Where(squirrel.Eq{"id": id}).
@@ -228,3 +382,22 @@ func (s *service) ListVendorAdmins(vendorID string) ([]types.User, error) {
 
 	return users, nil
 }
+
+func (s *service) IsVendorAdmin(userID, vendorID uuid.UUID) (bool, error) {
+	var exists bool
+	query, args, err := QB.Select("1").
+		Prefix("SELECT EXISTS (").
+		From("vendor_admins").
+		Where(squirrel.Eq{"user_id": userID, "vendor_id": vendorID}).
+		Suffix(")").
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("error building vendor admin query: %w", err)
+	}
+
+	if err := s.db.Get(&exists, query, args...); err != nil {
+		return false, fmt.Errorf("error checking vendor admin: %w", err)
+	}
+
+	return exists, nil
+}
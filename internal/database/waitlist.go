@@ -0,0 +1,417 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"math"
+	"net/url"
+	"restaurant-management-backend/internal/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidWaitlistEntry  = errors.New("invalid waitlist entry")
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+)
+
+// waitlistTransitions lists the statuses each status can move to. Notifying
+// again just re-sends the message.
+var waitlistTransitions = map[string][]string{
+	"waiting":  {"notified", "seated", "cancelled", "no_show"},
+	"notified": {"notified", "seated", "cancelled", "no_show"},
+}
+
+// waitlistTurnoverDays is how far back closed table sessions are averaged
+// to estimate how long a table stays taken.
+const waitlistTurnoverDays = 30
+
+var waitlistColumns = []string{
+	"id", "vendor_id", "customer_id", "party_name", "party_size", "phone", "notes", "quoted_wait_minutes",
+	"created_at + quoted_wait_minutes * INTERVAL '1 minute' AS quoted_ready_at",
+	"status", "token", "notified_at", "seated_at", "table_session_id", "created_at", "updated_at",
+	`CASE WHEN status IN ('waiting', 'notified') THEN (
+		SELECT COUNT(*) FROM waitlist_entries ahead
+		WHERE ahead.vendor_id = waitlist_entries.vendor_id
+			AND ahead.status IN ('waiting', 'notified')
+			AND ahead.created_at <= waitlist_entries.created_at
+	) ELSE 0 END AS position`,
+}
+
+// QuoteWaitlist estimates the wait for a party of partySize joining the line
+// now.
+func (s *service) QuoteWaitlist(vendorID uuid.UUID, partySize int) (*types.WaitlistQuote, error) {
+	if partySize <= 0 {
+		return nil, fmt.Errorf("%w: party_size must be positive", ErrInvalidWaitlistEntry)
+	}
+
+	ahead, minutes, err := estimateWait(s.db, vendorID, partySize, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &types.WaitlistQuote{
+		VendorId:          vendorID,
+		PartySize:         partySize,
+		PartiesAhead:      ahead,
+		QuotedWaitMinutes: minutes,
+	}, nil
+}
+
+// CreateWaitlistEntry puts a party at the back of the line. Unless the host
+// quotes a wait themselves, it is estimated from the tables in use.
+func (s *service) CreateWaitlistEntry(entry types.WaitlistEntry) (*types.WaitlistEntry, error) {
+	entry.PartyName = strings.TrimSpace(entry.PartyName)
+	if entry.PartyName == "" {
+		return nil, fmt.Errorf("%w: party_name is required", ErrInvalidWaitlistEntry)
+	}
+	if entry.PartySize <= 0 {
+		return nil, fmt.Errorf("%w: party_size must be positive", ErrInvalidWaitlistEntry)
+	}
+	if entry.QuotedWaitMinutes < 0 {
+		return nil, fmt.Errorf("%w: quoted_wait_minutes cannot be negative", ErrInvalidWaitlistEntry)
+	}
+
+	now := time.Now()
+	if entry.QuotedWaitMinutes == 0 {
+		_, minutes, err := estimateWait(s.db, entry.VendorId, entry.PartySize, now)
+		if err != nil {
+			return nil, err
+		}
+		entry.QuotedWaitMinutes = minutes
+	}
+
+	token, err := newWaitlistToken()
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := QB.Insert("waitlist_entries").
+		Columns("id", "vendor_id", "customer_id", "party_name", "party_size", "phone", "notes",
+			"quoted_wait_minutes", "status", "token", "created_at", "updated_at").
+		Values(uuid.New(), entry.VendorId, entry.CustomerId, entry.PartyName, entry.PartySize, entry.Phone, entry.Notes,
+			entry.QuotedWaitMinutes, "waiting", token, now, now).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("error building insert query: %w", err)
+	}
+
+	var id uuid.UUID
+	if err := s.db.QueryRowx(query, args...).Scan(&id); err != nil {
+		return nil, fmt.Errorf("error creating waitlist entry: %w", err)
+	}
+	return getWaitlistEntry(s.db, "id = ?", id, false)
+}
+
+func (s *service) GetWaitlistEntry(id string) (*types.WaitlistEntry, error) {
+	entryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	return getWaitlistEntry(s.db, "id = ?", entryID, false)
+}
+
+// GetWaitlistEntryByToken is how guests look up their own place in line.
+func (s *service) GetWaitlistEntryByToken(token string) (*types.WaitlistEntry, error) {
+	if token == "" {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	return getWaitlistEntry(s.db, "token = ?", token, false)
+}
+
+// ListWaitlist is a vendor's line, first come first served. By default it
+// holds the parties still waiting; status=all or a single status lists past
+// entries too.
+func (s *service) ListWaitlist(vendorID uuid.UUID, queryParams url.Values) ([]types.WaitlistEntry, *types.Meta, error) {
+	var entries []types.WaitlistEntry
+
+	additionalFilters := []string{fmt.Sprintf("vendor_id = '%s'", vendorID)}
+	switch status := queryParams.Get("status"); status {
+	case "":
+		additionalFilters = append(additionalFilters, "status IN ('waiting', 'notified')")
+	case "all":
+	case "waiting", "notified", "seated", "cancelled", "no_show":
+		additionalFilters = append(additionalFilters, fmt.Sprintf("status = '%s'", status))
+	default:
+		return nil, nil, fmt.Errorf("%w: status must be waiting, notified, seated, cancelled, no_show or all", ErrInvalidWaitlistEntry)
+	}
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "created_at")
+	}
+
+	meta, err := s.BuildQuery(
+		&entries,
+		"waitlist_entries",
+		[]string{},
+		waitlistColumns,
+		[]string{"party_name", "phone"},
+		queryParams,
+		additionalFilters,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list waitlist: %w", err)
+	}
+
+	if entries == nil {
+		entries = []types.WaitlistEntry{}
+	}
+	return entries, meta, nil
+}
+
+// UpdateWaitlistStatus notifies, cancels or marks a party as a no-show.
+// Seating goes through SeatWaitlistEntry.
+func (s *service) UpdateWaitlistStatus(id string, status string) (*types.WaitlistEntry, error) {
+	if status == "seated" {
+		return nil, fmt.Errorf("%w: seat the party at a table instead", ErrInvalidWaitlistEntry)
+	}
+	entryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	entry, err := getWaitlistEntry(tx, "id = ?", entryID, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWaitlistTransition(entry.Status, status); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	builder := QB.Update("waitlist_entries").
+		Set("status", status).
+		Set("updated_at", now).
+		Where("id = ?", entryID)
+	if status == "notified" {
+		builder = builder.Set("notified_at", now)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating waitlist entry: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getWaitlistEntry(s.db, "id = ?", entryID, false)
+}
+
+// SeatWaitlistEntry takes a party off the line and opens a table session for
+// it. The party size and customer default to the entry's.
+func (s *service) SeatWaitlistEntry(id string, seat types.SeatParty) (*types.WaitlistEntry, error) {
+	entryID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if seat.TableId == nil {
+		return nil, fmt.Errorf("%w: table_id is required", ErrInvalidWaitlistEntry)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	entry, err := getWaitlistEntry(tx, "id = ?", entryID, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWaitlistTransition(entry.Status, "seated"); err != nil {
+		return nil, err
+	}
+
+	session := types.TableSession{
+		VendorId:   entry.VendorId,
+		PartySize:  entry.PartySize,
+		ServerId:   seat.ServerId,
+		CustomerId: entry.CustomerId,
+	}
+	if seat.PartySize > 0 {
+		session.PartySize = seat.PartySize
+	}
+	if seat.CustomerId != nil {
+		session.CustomerId = seat.CustomerId
+	}
+
+	now := time.Now()
+	created, err := openTableSession(tx, session, []uuid.UUID{*seat.TableId}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := QB.Update("waitlist_entries").
+		Set("status", "seated").
+		Set("seated_at", now).
+		Set("table_session_id", created.ID).
+		Set("updated_at", now).
+		Where("id = ?", entryID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("error updating waitlist entry: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return getWaitlistEntry(s.db, "id = ?", entryID, false)
+}
+
+// waitingTable is a table that could take the party, and when it is expected
+// to be free.
+type waitingTable struct {
+	Capacity      int        `db:"capacity"`
+	MinCapacity   int        `db:"min_capacity"`
+	IsAvailable   *bool      `db:"is_available"`
+	OccupiedSince *time.Time `db:"occupied_since"`
+}
+
+// estimateWait returns how many parties that need the same tables are ahead
+// in line, and the minutes until a table should free up for the party. Busy
+// tables are expected to turn over after the vendor's average session
+// length, or its reservation turn time without enough history.
+func estimateWait(q sqlx.Queryer, vendorID uuid.UUID, partySize int, now time.Time) (int, int, error) {
+	turn, err := averageTurnMinutes(q, vendorID, now)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	query, args, err := QB.Select("capacity", "min_capacity", "is_available", `(
+			SELECT ts.opened_at FROM table_session_tables tst
+			JOIN table_sessions ts ON ts.id = tst.session_id
+			WHERE tst.table_id = tables.id AND tst.left_at IS NULL
+		) AS occupied_since`).
+		From("tables").
+		Where("vendor_id = ? AND capacity >= ? AND min_capacity <= ?", vendorID, partySize, partySize).
+		ToSql()
+	if err != nil {
+		return 0, 0, err
+	}
+	var tables []waitingTable
+	if err := sqlx.Select(q, &tables, query, args...); err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch tables: %w", err)
+	}
+
+	var freeAt []time.Time
+	minCapacity, maxCapacity := partySize, partySize
+	for _, table := range tables {
+		minCapacity = min(minCapacity, table.MinCapacity)
+		maxCapacity = max(maxCapacity, table.Capacity)
+		switch {
+		case table.OccupiedSince != nil:
+			expected := table.OccupiedSince.Add(time.Duration(turn) * time.Minute)
+			if expected.Before(now) {
+				// Overstaying parties are assumed to be about to leave
+				expected = now
+			}
+			freeAt = append(freeAt, expected)
+		case table.IsAvailable == nil || *table.IsAvailable:
+			freeAt = append(freeAt, now)
+		}
+	}
+	if len(freeAt) == 0 {
+		return 0, 0, fmt.Errorf("%w: no table can seat a party of %d", ErrInvalidWaitlistEntry, partySize)
+	}
+	sort.Slice(freeAt, func(i, j int) bool { return freeAt[i].Before(freeAt[j]) })
+
+	var ahead int
+	query, args, err = QB.Select("COUNT(*)").
+		From("waitlist_entries").
+		Where("vendor_id = ? AND status IN ('waiting', 'notified') AND party_size BETWEEN ? AND ?",
+			vendorID, minCapacity, maxCapacity).
+		ToSql()
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := sqlx.Get(q, &ahead, query, args...); err != nil {
+		return 0, 0, fmt.Errorf("failed to count waiting parties: %w", err)
+	}
+
+	// Parties ahead take the tables in the order they free up, a full turn
+	// later for every round through all of them
+	slot := freeAt[ahead%len(freeAt)].Add(time.Duration(ahead/len(freeAt)*turn) * time.Minute)
+	minutes := int(math.Ceil(slot.Sub(now).Minutes()/5) * 5)
+	return ahead, max(minutes, 0), nil
+}
+
+// averageTurnMinutes is how long the vendor's recent table sessions lasted,
+// falling back to its reservation turn time.
+func averageTurnMinutes(q sqlx.Queryer, vendorID uuid.UUID, now time.Time) (int, error) {
+	query, args, err := QB.Select().
+		Column(`COALESCE(ROUND((
+			SELECT AVG(EXTRACT(EPOCH FROM ts.closed_at - ts.opened_at)) / 60 FROM table_sessions ts
+			WHERE ts.vendor_id = vendors.id AND ts.closed_at >= ? AND ts.merged_into_id IS NULL
+		)), reservation_turn_minutes)::int`, now.AddDate(0, 0, -waitlistTurnoverDays)).
+		From("vendors").
+		Where("id = ?", vendorID).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var turn int
+	if err := sqlx.Get(q, &turn, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%w: vendor not found", ErrInvalidWaitlistEntry)
+		}
+		return 0, err
+	}
+	return turn, nil
+}
+
+func checkWaitlistTransition(from, to string) error {
+	for _, next := range waitlistTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: a %s party cannot become %s", ErrInvalidWaitlistEntry, from, to)
+}
+
+func newWaitlistToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating waitlist token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func getWaitlistEntry(q sqlx.Queryer, where string, arg interface{}, forUpdate bool) (*types.WaitlistEntry, error) {
+	builder := QB.Select(waitlistColumns...).From("waitlist_entries").Where(where, arg)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var entry types.WaitlistEntry
+	if err := sqlx.Get(q, &entry, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWaitlistEntryNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
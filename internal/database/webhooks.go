@@ -0,0 +1,699 @@
+package database
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"net"
+	"net/url"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidWebhookEndpoint  = errors.New("invalid webhook endpoint")
+	ErrInvalidWebhookDelivery  = errors.New("invalid webhook delivery")
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+var webhookDeliveryStatuses = map[string]bool{
+	"pending":   true,
+	"succeeded": true,
+	"dead":      true,
+}
+
+var webhookEndpointColumns = []string{"id", "vendor_id", "url", "secret", "description", "is_active", "created_at", "updated_at"}
+
+var webhookDeliveryColumns = []string{
+	"webhook_deliveries.id", "webhook_deliveries.event_id", "outbox_events.type AS event_type",
+	"webhook_deliveries.endpoint_id", "webhook_deliveries.status", "webhook_deliveries.attempts",
+	"webhook_deliveries.next_attempt_at", "webhook_deliveries.last_attempt_at",
+	"webhook_deliveries.last_response_status", "webhook_deliveries.last_error",
+	"webhook_deliveries.created_at", "webhook_deliveries.updated_at",
+}
+
+// ListWebhookEndpoints returns the vendor's endpoints without their secrets.
+func (s *service) ListWebhookEndpoints(vendorID uuid.UUID) ([]types.WebhookEndpoint, error) {
+	query, args, err := QB.Select(webhookEndpointColumns...).
+		From("webhook_endpoints").
+		Where("vendor_id = ?", vendorID).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []types.WebhookEndpoint{}
+	if err := s.db.Select(&endpoints, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	if err := attachWebhookEventTypes(s.db, endpoints); err != nil {
+		return nil, err
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	return endpoints, nil
+}
+
+func (s *service) GetWebhookEndpoint(vendorID uuid.UUID, id string) (*types.WebhookEndpoint, error) {
+	endpoint, err := getWebhookEndpoint(s.db, vendorID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.Secret = ""
+	return endpoint, nil
+}
+
+// CreateWebhookEndpoint registers an endpoint with a fresh signing secret.
+// This is the only response the secret is shown in until it is rotated.
+func (s *service) CreateWebhookEndpoint(vendorID uuid.UUID, endpoint types.WebhookEndpoint) (*types.WebhookEndpoint, error) {
+	if err := validateWebhookEndpoint(&endpoint); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	isActive := true
+	if endpoint.IsActive != nil {
+		isActive = *endpoint.IsActive
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id := uuid.New()
+	now := time.Now()
+	query, args, err := QB.Insert("webhook_endpoints").
+		Columns("id", "vendor_id", "url", "secret", "description", "is_active", "created_at", "updated_at").
+		Values(id, vendorID, endpoint.Url, secret, endpoint.Description, isActive, now, now).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	if err := setWebhookEventTypes(tx, id, endpoint.EventTypes); err != nil {
+		return nil, err
+	}
+
+	created, err := getWebhookEndpoint(tx, vendorID, id.String(), false)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+// UpdateWebhookEndpoint replaces the endpoint's URL, description and event
+// types. is_active is left alone when it is not given.
+func (s *service) UpdateWebhookEndpoint(vendorID uuid.UUID, id string, endpoint types.WebhookEndpoint) (*types.WebhookEndpoint, error) {
+	if err := validateWebhookEndpoint(&endpoint); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	current, err := getWebhookEndpoint(tx, vendorID, id, true)
+	if err != nil {
+		return nil, err
+	}
+	isActive := current.IsActive
+	if endpoint.IsActive != nil {
+		isActive = endpoint.IsActive
+	}
+
+	query, args, err := QB.Update("webhook_endpoints").
+		Set("url", endpoint.Url).
+		Set("description", endpoint.Description).
+		Set("is_active", isActive).
+		Set("updated_at", time.Now()).
+		Where("id = ?", current.ID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	if err := setWebhookEventTypes(tx, current.ID, endpoint.EventTypes); err != nil {
+		return nil, err
+	}
+
+	updated, err := getWebhookEndpoint(tx, vendorID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	updated.Secret = ""
+	return updated, nil
+}
+
+// DeleteWebhookEndpoint removes the endpoint along with its delivery log.
+func (s *service) DeleteWebhookEndpoint(vendorID uuid.UUID, id string) error {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrWebhookEndpointNotFound
+	}
+
+	query, args, err := QB.Delete("webhook_endpoints").Where("id = ? AND vendor_id = ?", endpointID, vendorID).ToSql()
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+// RotateWebhookSecret replaces the endpoint's signing secret and returns the
+// new one. Deliveries still waiting are signed with it from now on.
+func (s *service) RotateWebhookSecret(vendorID uuid.UUID, id string) (*types.WebhookEndpoint, error) {
+	endpoint, err := getWebhookEndpoint(s.db, vendorID, id, false)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	query, args, err := QB.Update("webhook_endpoints").
+		Set("secret", secret).
+		Set("updated_at", now).
+		Where("id = ?", endpoint.ID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	endpoint.Secret = secret
+	endpoint.Updated_at = now
+	return endpoint, nil
+}
+
+// ListWebhookDeliveries is the endpoint's delivery log, newest first. status
+// narrows it to pending, succeeded or dead deliveries and event_type to one
+// kind of event.
+func (s *service) ListWebhookDeliveries(vendorID uuid.UUID, endpointID string, queryParams url.Values) ([]types.WebhookDelivery, *types.Meta, error) {
+	endpoint, err := getWebhookEndpoint(s.db, vendorID, endpointID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	additionalFilters := []string{fmt.Sprintf("webhook_deliveries.endpoint_id = '%s'", endpoint.ID)}
+	if status := queryParams.Get("status"); status != "" {
+		if !webhookDeliveryStatuses[status] {
+			return nil, nil, fmt.Errorf("%w: status must be pending, succeeded or dead", ErrInvalidWebhookDelivery)
+		}
+		additionalFilters = append(additionalFilters, fmt.Sprintf("webhook_deliveries.status = '%s'", status))
+	}
+	if eventType := queryParams.Get("event_type"); eventType != "" {
+		if !webhookEventTypes[eventType] && eventType != WebhookTestEvent {
+			return nil, nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhookDelivery, eventType)
+		}
+		additionalFilters = append(additionalFilters, fmt.Sprintf("outbox_events.type = '%s'", eventType))
+	}
+
+	if queryParams.Get("sort") == "" {
+		queryParams.Set("sort", "-webhook_deliveries.created_at")
+	}
+
+	var deliveries []types.WebhookDelivery
+	meta, err := s.BuildQuery(
+		&deliveries,
+		"webhook_deliveries",
+		[]string{"outbox_events ON outbox_events.id = webhook_deliveries.event_id"},
+		webhookDeliveryColumns,
+		[]string{"outbox_events.type"},
+		queryParams,
+		additionalFilters,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	if deliveries == nil {
+		deliveries = []types.WebhookDelivery{}
+	}
+	for i := range deliveries {
+		clearSettledRetry(&deliveries[i])
+	}
+	return deliveries, meta, nil
+}
+
+// GetWebhookDelivery returns a delivery with the payload that was sent and
+// every attempt at sending it.
+func (s *service) GetWebhookDelivery(vendorID uuid.UUID, endpointID, deliveryID string) (*types.WebhookDelivery, error) {
+	endpoint, err := getWebhookEndpoint(s.db, vendorID, endpointID, false)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return getWebhookDelivery(s.db, endpoint.ID, id)
+}
+
+// RetryWebhookDelivery sends a delivery again as soon as the dispatcher gets
+// to it, with a fresh round of attempts. Dead deliveries come back to life
+// this way, and succeeded ones are sent again.
+func (s *service) RetryWebhookDelivery(vendorID uuid.UUID, endpointID, deliveryID string) (*types.WebhookDelivery, error) {
+	endpoint, err := getWebhookEndpoint(s.db, vendorID, endpointID, false)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	now := time.Now()
+	query, args, err := QB.Update("webhook_deliveries").
+		Set("status", "pending").
+		Set("attempts", 0).
+		Set("next_attempt_at", now).
+		Set("updated_at", now).
+		Where("id = ? AND endpoint_id = ?", id, endpoint.ID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry webhook delivery: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return getWebhookDelivery(s.db, endpoint.ID, id)
+}
+
+// SendTestWebhook queues a webhook.test event for this endpoint alone, so a
+// partner can check their receiver and signature verification.
+func (s *service) SendTestWebhook(vendorID uuid.UUID, endpointID string) (*types.WebhookDelivery, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	endpoint, err := getWebhookEndpoint(tx, vendorID, endpointID, false)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.IsActive != nil && !*endpoint.IsActive {
+		return nil, fmt.Errorf("%w: the endpoint is disabled", ErrInvalidWebhookEndpoint)
+	}
+
+	eventID, err := enqueueEvent(tx, vendorID, WebhookTestEvent, map[string]interface{}{
+		"endpoint_id": endpoint.ID,
+		"message":     "This is a test event.",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Delivered to this endpoint only, so the event is already fanned out
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE outbox_events SET dispatched_at = $1 WHERE id = $2", now, eventID); err != nil {
+		return nil, err
+	}
+	deliveryID := uuid.New()
+	query, args, err := QB.Insert("webhook_deliveries").
+		Columns("id", "event_id", "endpoint_id", "status", "next_attempt_at", "created_at", "updated_at").
+		Values(deliveryID, eventID, endpoint.ID, "pending", now, now, now).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return nil, fmt.Errorf("failed to queue test webhook: %w", err)
+	}
+
+	delivery, err := getWebhookDelivery(tx, endpoint.ID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return delivery, tx.Commit()
+}
+
+// FanOutOutboxEvents turns up to limit undispatched outbox events into one
+// delivery per subscribed, active endpoint of the event's vendor, and
+// returns how many events it handled. Several dispatchers can run at once;
+// each takes different events.
+func (s *service) FanOutOutboxEvents(limit int) (int, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var eventIDs []uuid.UUID
+	if err := tx.Select(&eventIDs, `SELECT id FROM outbox_events
+		WHERE dispatched_at IS NULL
+		ORDER BY created_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`, limit); err != nil {
+		return 0, fmt.Errorf("failed to load outbox events: %w", err)
+	}
+	if len(eventIDs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	for _, eventID := range eventIDs {
+		if _, err := tx.Exec(`INSERT INTO webhook_deliveries (event_id, endpoint_id, next_attempt_at, created_at, updated_at)
+			SELECT o.id, e.id, $2::timestamp, $2::timestamp, $2::timestamp
+			FROM outbox_events o
+			JOIN webhook_endpoints e ON e.vendor_id = o.vendor_id AND e.is_active
+			WHERE o.id = $1 AND (
+				NOT EXISTS (SELECT 1 FROM webhook_endpoint_events ee WHERE ee.endpoint_id = e.id)
+				OR EXISTS (SELECT 1 FROM webhook_endpoint_events ee WHERE ee.endpoint_id = e.id AND ee.event_type = o.type)
+			)
+			ON CONFLICT (event_id, endpoint_id) DO NOTHING`, eventID, now); err != nil {
+			return 0, fmt.Errorf("failed to fan out outbox event: %w", err)
+		}
+	}
+
+	query, args, err := QB.Update("outbox_events").
+		Set("dispatched_at", now).
+		Where(squirrel.Eq{"id": eventIDs}).
+		ToSql()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return 0, fmt.Errorf("failed to mark outbox events dispatched: %w", err)
+	}
+	return len(eventIDs), tx.Commit()
+}
+
+// ClaimWebhookDeliveries takes up to limit deliveries that are due and holds
+// them for lease by moving their next attempt past it. A dispatcher that
+// dies mid-delivery leaves them to be picked up again once the lease runs
+// out. Deliveries to disabled endpoints wait until they are enabled again.
+func (s *service) ClaimWebhookDeliveries(limit int, lease time.Duration) ([]types.WebhookJob, error) {
+	now := time.Now()
+
+	var deliveryIDs []uuid.UUID
+	if err := s.db.Select(&deliveryIDs, `WITH due AS (
+			SELECT d.id FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND e.is_active
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries SET next_attempt_at = $3, updated_at = $1
+		FROM due WHERE webhook_deliveries.id = due.id
+		RETURNING webhook_deliveries.id`, now, limit, now.Add(lease)); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	if len(deliveryIDs) == 0 {
+		return nil, nil
+	}
+
+	query, args, err := QB.Select("d.id AS delivery_id", "o.id AS event_id", "o.type AS event_type", "o.vendor_id",
+		"o.created_at AS event_created_at", "d.attempts + 1 AS attempt", "e.url", "e.secret", "o.payload::text AS payload").
+		From("webhook_deliveries d").
+		Join("outbox_events o ON o.id = d.event_id").
+		Join("webhook_endpoints e ON e.id = d.endpoint_id").
+		Where(squirrel.Eq{"d.id": deliveryIDs}).
+		OrderBy("o.created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		types.WebhookJob
+		Payload string `db:"payload"`
+	}
+	if err := s.db.Select(&rows, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+
+	jobs := make([]types.WebhookJob, len(rows))
+	for i, row := range rows {
+		jobs[i] = row.WebhookJob
+		jobs[i].Payload = []byte(row.Payload)
+	}
+	return jobs, nil
+}
+
+// RecordWebhookAttempt logs an attempt and moves its delivery to status:
+// pending to try again at nextAttemptAt, succeeded, or dead to give up.
+func (s *service) RecordWebhookAttempt(attempt types.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	if !webhookDeliveryStatuses[status] {
+		return fmt.Errorf("%w: status must be pending, succeeded or dead", ErrInvalidWebhookDelivery)
+	}
+
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query, args, err := QB.Insert("webhook_delivery_attempts").
+		Columns("id", "delivery_id", "attempt", "response_status", "response_body", "error", "duration_ms", "created_at").
+		Values(uuid.New(), attempt.DeliveryId, attempt.Attempt, attempt.ResponseStatus, attempt.ResponseBody,
+			attempt.Error, attempt.DurationMs, now).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to log webhook attempt: %w", err)
+	}
+
+	query, args, err = QB.Update("webhook_deliveries").
+		Set("status", status).
+		Set("attempts", attempt.Attempt).
+		Set("next_attempt_at", nextAttemptAt).
+		Set("last_attempt_at", now).
+		Set("last_response_status", attempt.ResponseStatus).
+		Set("last_error", attempt.Error).
+		Set("updated_at", now).
+		Where("id = ?", attempt.DeliveryId).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return tx.Commit()
+}
+
+func getWebhookEndpoint(q sqlx.Queryer, vendorID uuid.UUID, id string, forUpdate bool) (*types.WebhookEndpoint, error) {
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrWebhookEndpointNotFound
+	}
+
+	builder := QB.Select(webhookEndpointColumns...).
+		From("webhook_endpoints").
+		Where("id = ? AND vendor_id = ?", endpointID, vendorID)
+	if forUpdate {
+		builder = builder.Suffix("FOR UPDATE")
+	}
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoint types.WebhookEndpoint
+	if err := sqlx.Get(q, &endpoint, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookEndpointNotFound
+		}
+		return nil, err
+	}
+	endpoints := []types.WebhookEndpoint{endpoint}
+	if err := attachWebhookEventTypes(q, endpoints); err != nil {
+		return nil, err
+	}
+	return &endpoints[0], nil
+}
+
+func getWebhookDelivery(q sqlx.Queryer, endpointID, deliveryID uuid.UUID) (*types.WebhookDelivery, error) {
+	query, args, err := QB.Select(append(webhookDeliveryColumns, "outbox_events.payload::text AS payload")...).
+		From("webhook_deliveries").
+		Join("outbox_events ON outbox_events.id = webhook_deliveries.event_id").
+		Where("webhook_deliveries.id = ? AND webhook_deliveries.endpoint_id = ?", deliveryID, endpointID).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var row struct {
+		types.WebhookDelivery
+		Payload string `db:"payload"`
+	}
+	if err := sqlx.Get(q, &row, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	delivery := row.WebhookDelivery
+	delivery.Payload = []byte(row.Payload)
+	clearSettledRetry(&delivery)
+
+	query, args, err = QB.Select("id", "delivery_id", "attempt", "response_status", "response_body", "error", "duration_ms", "created_at").
+		From("webhook_delivery_attempts").
+		Where("delivery_id = ?", delivery.ID).
+		OrderBy("created_at").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	delivery.AttemptLog = []types.WebhookDeliveryAttempt{}
+	if err := sqlx.Select(q, &delivery.AttemptLog, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to load webhook attempts: %w", err)
+	}
+	return &delivery, nil
+}
+
+// clearSettledRetry hides the next attempt of deliveries that will not be
+// attempted again.
+func clearSettledRetry(delivery *types.WebhookDelivery) {
+	if delivery.Status != "pending" {
+		delivery.NextAttemptAt = nil
+	}
+}
+
+func attachWebhookEventTypes(q sqlx.Queryer, endpoints []types.WebhookEndpoint) error {
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(endpoints))
+	byID := make(map[uuid.UUID]*types.WebhookEndpoint, len(endpoints))
+	for i := range endpoints {
+		ids[i] = endpoints[i].ID
+		endpoints[i].EventTypes = []string{}
+		byID[endpoints[i].ID] = &endpoints[i]
+	}
+
+	query, args, err := QB.Select("endpoint_id", "event_type").
+		From("webhook_endpoint_events").
+		Where(squirrel.Eq{"endpoint_id": ids}).
+		OrderBy("event_type").
+		ToSql()
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		EndpointId uuid.UUID `db:"endpoint_id"`
+		EventType  string    `db:"event_type"`
+	}
+	if err := sqlx.Select(q, &rows, query, args...); err != nil {
+		return fmt.Errorf("failed to load webhook event types: %w", err)
+	}
+	for _, row := range rows {
+		endpoint := byID[row.EndpointId]
+		endpoint.EventTypes = append(endpoint.EventTypes, row.EventType)
+	}
+	return nil
+}
+
+func setWebhookEventTypes(tx *sqlx.Tx, endpointID uuid.UUID, eventTypes []string) error {
+	if _, err := tx.Exec("DELETE FROM webhook_endpoint_events WHERE endpoint_id = $1", endpointID); err != nil {
+		return fmt.Errorf("failed to clear webhook event types: %w", err)
+	}
+	if len(eventTypes) == 0 {
+		return nil
+	}
+
+	insert := QB.Insert("webhook_endpoint_events").Columns("endpoint_id", "event_type")
+	for _, eventType := range eventTypes {
+		insert = insert.Values(endpointID, eventType)
+	}
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to save webhook event types: %w", err)
+	}
+	return nil
+}
+
+// validateWebhookEndpoint checks the URL, which must not point into our own
+// network, and the event types, dropping duplicate event types.
+func validateWebhookEndpoint(endpoint *types.WebhookEndpoint) error {
+	endpoint.Url = strings.TrimSpace(endpoint.Url)
+	parsed, err := url.Parse(endpoint.Url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an http or https URL", ErrInvalidWebhookEndpoint)
+	}
+	// Names are checked again when the dispatcher connects, whatever they
+	// resolve to by then
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: url must point to a public host", ErrInvalidWebhookEndpoint)
+	}
+	if ip := net.ParseIP(host); ip != nil && !helpers.CheckPublicIP(ip) {
+		return fmt.Errorf("%w: url must point to a public host", ErrInvalidWebhookEndpoint)
+	}
+	if endpoint.Description != nil {
+		description := strings.TrimSpace(*endpoint.Description)
+		endpoint.Description = &description
+		if description == "" {
+			endpoint.Description = nil
+		}
+	}
+
+	seen := make(map[string]bool, len(endpoint.EventTypes))
+	eventTypes := []string{}
+	for _, eventType := range endpoint.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhookEndpoint, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	sort.Strings(eventTypes)
+	endpoint.EventTypes = eventTypes
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
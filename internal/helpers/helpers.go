@@ -1,6 +1,9 @@
 package helpers
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,11 +12,13 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,8 +26,11 @@ var (
 	Domain      = os.Getenv("DOMAIN")
 	ImageFormat = fmt.Sprintf("CASE WHEN NULLIF(img,'') IS NOT NULL THEN FORMAT ('%s/%%s',img) ELSE NULL END AS img ", Domain)
 	secretKey   = []byte(os.Getenv("JWT_SECRET"))
+	tableKey    = []byte(os.Getenv("TABLE_TOKEN_SECRET"))
 )
 
+var ErrInvalidTableToken = errors.New("invalid table token")
+
 func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -36,6 +44,31 @@ func WriteJSONResponse(w http.ResponseWriter, statusCode int, data interface{})
 	_, _ = w.Write(jsonResp)
 }
 
+// DecodeJSONBody decodes a JSON request body into v and reports which fields
+// were sent as null, so a partial update can tell clearing a field apart from
+// leaving it out.
+func DecodeJSONBody(r *http.Request, v interface{}) (map[string]bool, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	nulls := make(map[string]bool)
+	for name, value := range fields {
+		if string(value) == "null" {
+			nulls[name] = true
+		}
+	}
+	return nulls, nil
+}
+
 func HandleFileUpload(r *http.Request, table string) (*string, error) {
 	file, fileHeader, err := r.FormFile("img")
 	if err != nil && !errors.Is(err, http.ErrMissingFile) {
@@ -96,6 +129,19 @@ func CheckValidPhone(phone string) bool {
 	return re.MatchString(phone)
 }
 
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which
+// net.IP does not count as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// CheckPublicIP reports whether ip is reachable on the public internet, as
+// opposed to loopback, link-local (cloud metadata services live there),
+// private or otherwise reserved for local use.
+func CheckPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || sharedAddressSpace.Contains(ip))
+}
+
 func CheckValidImageType(filename string) bool {
 	ext := filepath.Ext(filename)
 	switch ext {
@@ -184,6 +230,49 @@ func ParseJWT(tokenString string) (uuid.UUID, error) {
 	return userID, nil
 }
 
+// SignTableToken builds the token printed in a table's QR code. It names the
+// table and the token version, signed so it can't be made up for another
+// table.
+func SignTableToken(tableID uuid.UUID, version int) string {
+	payload := fmt.Sprintf("%s.%d", tableID, version)
+	return payload + "." + tableTokenSignature(payload)
+}
+
+// ParseTableToken checks a table token's signature and returns the table and
+// version it was issued for. Whether that version is still current is up to
+// the caller.
+func ParseTableToken(token string) (uuid.UUID, int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return uuid.Nil, 0, ErrInvalidTableToken
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(tableTokenSignature(payload))) {
+		return uuid.Nil, 0, ErrInvalidTableToken
+	}
+
+	tableID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidTableToken
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidTableToken
+	}
+	return tableID, version, nil
+}
+
+func tableTokenSignature(payload string) string {
+	key := tableKey
+	if len(key) == 0 {
+		key = secretKey
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
 func ParseBoolWithDefault(value string, defaultValue bool) bool {
 	if value == "" {
 		return defaultValue
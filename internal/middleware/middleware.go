@@ -51,7 +51,19 @@ func JWTMiddleware(db *sqlx.DB) func(http.Handler) http.Handler {
 				return
 			}
 
+			// A guest only lasts as long as the table code they scanned
+			active, err := s.GuestSessionActive(user)
+			if err != nil {
+				helpers.HandleError(w, http.StatusInternalServerError, "Unable to verify guest session")
+				return
+			}
+			if !active {
+				helpers.HandleError(w, http.StatusUnauthorized, "Guest session has expired")
+				return
+			}
+
 			ctx := context.WithValue(r.Context(), "user", user)
+			ctx = context.WithValue(ctx, database.UserIDKey, user.ID.String())
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -61,7 +73,7 @@ func RoleMiddleware(allowedRoles ...int) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			user, ok := r.Context().Value("user").(types.User)
+			user, ok := GetUser(r)
 			if !ok {
 				helpers.HandleError(w, http.StatusUnauthorized, "Unauthorized: User information is missing")
 				return
@@ -83,3 +95,18 @@ func RoleMiddleware(allowedRoles ...int) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// GetUser returns the user attached to the request by JWTMiddleware.
+func GetUser(r *http.Request) (types.User, bool) {
+	user, ok := r.Context().Value("user").(types.User)
+	return user, ok
+}
+
+func HasRole(user types.User, role int) bool {
+	for _, userRole := range user.Roles {
+		if userRole == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+)
+
+// Notifier tells guests about their place in line, e.g. by SMS. Real
+// providers plug in behind this interface.
+type Notifier interface {
+	Name() string
+	// WaitlistReady tells a waiting party that their table is ready.
+	WaitlistReady(ctx context.Context, entry types.WaitlistEntry) error
+}
+
+// New returns the notifier configured through NOTIFIER. Only the logging
+// notifier exists for now.
+func New() Notifier {
+	switch os.Getenv("NOTIFIER") {
+	default:
+		return LogNotifier{}
+	}
+}
+
+// LogNotifier writes notifications to the log instead of sending them, for
+// local development.
+type LogNotifier struct{}
+
+func (LogNotifier) Name() string {
+	return "log"
+}
+
+func (LogNotifier) WaitlistReady(ctx context.Context, entry types.WaitlistEntry) error {
+	phone := ""
+	if entry.Phone != nil {
+		phone = *entry.Phone
+	}
+	logger.Log.WithField("waitlist_entry_id", entry.ID).
+		WithField("party_name", entry.PartyName).
+		WithField("phone", phone).
+		Info("Waitlist table ready")
+	return nil
+}
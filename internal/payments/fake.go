@@ -0,0 +1,132 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeProvider is an in-memory gateway for local development. Tokens
+// starting with "decline" are refused, everything else is approved.
+type FakeProvider struct {
+	secret string
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+}
+
+type fakePayment struct {
+	authorized float64
+	captured   float64
+	refunded   float64
+	status     string
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret:   secret,
+		payments: make(map[string]*fakePayment),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Authorize(_ context.Context, req AuthorizeRequest) (Result, error) {
+	if req.Amount <= 0 {
+		return Result{}, fmt.Errorf("amount must be positive")
+	}
+	if strings.HasPrefix(req.Token, "decline") {
+		return Result{Status: "failed"}, ErrDeclined
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reference := "fake_" + uuid.NewString()
+	p.payments[reference] = &fakePayment{authorized: req.Amount, status: "authorized"}
+	return Result{Reference: reference, Status: "authorized", Amount: req.Amount}, nil
+}
+
+func (p *FakeProvider) Capture(_ context.Context, reference string, amount float64) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return Result{}, ErrUnknownPayment
+	}
+	if payment.status != "authorized" || amount <= 0 || amount > payment.authorized {
+		return Result{}, ErrInvalidState
+	}
+
+	payment.captured = amount
+	payment.status = "captured"
+	return Result{Reference: reference, Status: payment.status, Amount: amount}, nil
+}
+
+func (p *FakeProvider) Refund(_ context.Context, reference string, amount float64) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return Result{}, ErrUnknownPayment
+	}
+	if amount <= 0 || payment.refunded+amount > payment.captured+0.001 {
+		return Result{}, ErrInvalidState
+	}
+
+	payment.refunded += amount
+	payment.status = "partially_refunded"
+	if payment.refunded >= payment.captured {
+		payment.status = "refunded"
+	}
+	return Result{Reference: reference, Status: payment.status, Amount: amount}, nil
+}
+
+func (p *FakeProvider) Void(_ context.Context, reference string) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[reference]
+	if !ok {
+		return Result{}, ErrUnknownPayment
+	}
+	if payment.status != "authorized" {
+		return Result{}, ErrInvalidState
+	}
+
+	payment.status = "voided"
+	return Result{Reference: reference, Status: payment.status}, nil
+}
+
+func (p *FakeProvider) ParseWebhook(r *http.Request) (WebhookEvent, error) {
+	var event WebhookEvent
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return event, err
+	}
+
+	if err := VerifySignature(p.secret, payload, r.Header.Get(SignatureHeader), time.Now()); err != nil {
+		return event, err
+	}
+
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return event, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	if event.ID == "" || event.Reference == "" {
+		return event, fmt.Errorf("invalid webhook payload: id and reference are required")
+	}
+	event.Payload = payload
+
+	return event, nil
+}
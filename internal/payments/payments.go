@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"restaurant-management-backend/internal/logger"
+)
+
+var (
+	ErrDeclined         = errors.New("payment declined")
+	ErrUnknownPayment   = errors.New("unknown payment")
+	ErrInvalidState     = errors.New("payment is not in a valid state for this operation")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// PaymentProvider is implemented by every payment gateway the API can talk
+// to. Amounts are in the order currency, references are the provider's ids.
+type PaymentProvider interface {
+	Name() string
+	Authorize(ctx context.Context, req AuthorizeRequest) (Result, error)
+	Capture(ctx context.Context, reference string, amount float64) (Result, error)
+	Refund(ctx context.Context, reference string, amount float64) (Result, error)
+	Void(ctx context.Context, reference string) (Result, error)
+	// ParseWebhook verifies an incoming callback and decodes it.
+	ParseWebhook(r *http.Request) (WebhookEvent, error)
+}
+
+type AuthorizeRequest struct {
+	OrderID string
+	Amount  float64
+	// Token is the card or wallet token collected by the client.
+	Token string
+}
+
+type Result struct {
+	Reference string
+	Status    string
+	Amount    float64
+}
+
+type WebhookEvent struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+	Payload   []byte  `json:"-"`
+}
+
+// New returns the provider configured through PAYMENT_PROVIDER. Only the local
+// fake exists for now, real gateways plug in here. Webhooks are signed with
+// PAYMENT_WEBHOOK_SECRET and all of them are rejected while it is unset.
+func New() PaymentProvider {
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if secret == "" {
+		logger.Log.Warn("PAYMENT_WEBHOOK_SECRET is not set, payment webhooks will be rejected")
+	}
+
+	switch os.Getenv("PAYMENT_PROVIDER") {
+	default:
+		return NewFakeProvider(secret)
+	}
+}
@@ -0,0 +1,127 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer is how many events a subscriber may fall behind by
+// before it is dropped.
+const subscriptionBuffer = 64
+
+// Listener delivers every notification sent on a Postgres channel until the
+// context is done or the connection fails.
+type Listener func(ctx context.Context, channel string, handle func(payload string)) error
+
+// Hub fans events out to the clients connected to this instance. Events
+// reach it through Postgres, so a change made through any instance is seen
+// by all of them.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives the events published on any of its topics. C is
+// closed when the hub drops a subscriber that fell too far behind.
+type Subscription struct {
+	C      chan types.RealtimeEvent
+	topics map[string]bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+func (h *Hub) Subscribe(topics []string) *Subscription {
+	sub := &Subscription{
+		C:      make(chan types.RealtimeEvent, subscriptionBuffer),
+		topics: make(map[string]bool, len(topics)),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(sub)
+}
+
+// Publish hands the event to every local subscriber of one of its topics.
+func (h *Hub) Publish(event types.RealtimeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.wants(event.Topics) {
+			continue
+		}
+		select {
+		case sub.C <- event:
+		default:
+			// A stuck client must not hold up everyone else
+			h.drop(sub)
+		}
+	}
+}
+
+// Run feeds the hub from channel, reconnecting with backoff whenever the
+// listener fails, until ctx is done.
+func (h *Hub) Run(ctx context.Context, listen Listener, channel string) {
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := listen(ctx, channel, h.handle)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		logger.Log.WithError(err).WithField("retry_in", backoff.String()).Error("Real-time listener stopped")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (h *Hub) handle(payload string) {
+	var event types.RealtimeEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		logger.Log.WithError(err).Warn("Ignoring malformed real-time event")
+		return
+	}
+	h.Publish(event)
+}
+
+// drop must be called with the lock held.
+func (h *Hub) drop(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.C)
+}
+
+func (s *Subscription) wants(topics []string) bool {
+	for _, topic := range topics {
+		if s.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package receipts
+
+import (
+	"bytes"
+	"github.com/go-pdf/fpdf"
+	"strings"
+)
+
+// ESC/POS commands understood by most thermal receipt printers.
+var (
+	escposInit     = []byte{0x1b, 0x40}       // ESC @: reset
+	escposCodePage = []byte{0x1b, 0x74, 0x10} // ESC t 16: Windows-1252
+	escposFeed     = []byte{0x1b, 0x64, 0x04} // ESC d 4: feed past the cutter
+	escposCut      = []byte{0x1d, 0x56, 0x01} // GS V 1: partial cut
+)
+
+// ESCPOS wraps rendered text in the commands to print and cut it on a
+// thermal printer. Characters outside Windows-1252 print as '?'.
+func ESCPOS(text string) []byte {
+	var out bytes.Buffer
+	out.Write(escposInit)
+	out.Write(escposCodePage)
+	for _, r := range strings.ReplaceAll(text, "\r\n", "\n") {
+		switch {
+		case r == '\n':
+			out.WriteByte('\n')
+		case r < 0x20:
+			// Control characters would be read as printer commands
+		case r < 0x80, r >= 0xa0 && r <= 0xff:
+			out.WriteByte(byte(r))
+		case r == '€':
+			out.WriteByte(0x80)
+		default:
+			out.WriteByte('?')
+		}
+	}
+	if !strings.HasSuffix(text, "\n") {
+		out.WriteByte('\n')
+	}
+	out.Write(escposFeed)
+	out.Write(escposCut)
+	return out.Bytes()
+}
+
+const (
+	pdfMarginMM = 4
+	// Courier glyphs are 0.6 em wide.
+	pdfCharWidthEm  = 0.6
+	pdfLineSpacing  = 1.2
+	pdfPointsPerMM  = 72 / 25.4
+	pdfMinPageMM    = 40
+	pdfBottomFeedMM = 8
+)
+
+// PDF lays rendered text out in Courier on a page as wide as the paper and
+// as long as the text, so it prints the same as on a thermal printer.
+func PDF(text string, paperMM, width int) ([]byte, error) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+
+	printable := float64(paperMM - 2*pdfMarginMM)
+	emMM := printable / float64(width) / pdfCharWidthEm
+	lineMM := emMM * pdfLineSpacing
+	height := max(float64(2*pdfMarginMM+pdfBottomFeedMM)+lineMM*float64(len(lines)), pdfMinPageMM)
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: float64(paperMM), Ht: height},
+	})
+	pdf.SetMargins(pdfMarginMM, pdfMarginMM, pdfMarginMM)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	pdf.SetFont("Courier", "", emMM*pdfPointsPerMM)
+
+	// The core fonts are Windows-1252
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	for _, line := range lines {
+		pdf.CellFormat(printable, lineMM, translate(line), "", 1, "L", false, 0, "")
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,242 @@
+package receipts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidTemplate = errors.New("invalid receipt template")
+
+// Kinds and Formats are what a vendor can provide templates for.
+var (
+	Kinds   = []string{"receipt", "kitchen"}
+	Formats = []string{"text", "html"}
+)
+
+// paperColumns is how many characters of the printer's standard font fit on
+// each paper width, in millimetres.
+var paperColumns = map[int]int{
+	58: 32,
+	80: 48,
+}
+
+// Columns returns how many characters fit across paperMM wide paper.
+func Columns(paperMM int) (int, error) {
+	columns, ok := paperColumns[paperMM]
+	if !ok {
+		return 0, fmt.Errorf("paper must be 58 or 80 mm wide")
+	}
+	return columns, nil
+}
+
+// DefaultTemplate returns the built-in layout for kind and format.
+func DefaultTemplate(kind, format string) (string, bool) {
+	body, ok := defaultTemplates[kind+"/"+format]
+	return body, ok
+}
+
+// Text renders a text template for a printer receipt.Width characters wide.
+func Text(body string, receipt types.Receipt) (string, error) {
+	tmpl, err := texttemplate.New("receipt").Funcs(textFuncs(receipt.Width)).Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, receipt); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return out.String(), nil
+}
+
+// HTML renders an HTML template. Values from the order are escaped.
+func HTML(body string, receipt types.Receipt) (string, error) {
+	tmpl, err := htmltemplate.New("receipt").Funcs(htmltemplate.FuncMap(commonFuncs)).Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, receipt); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return out.String(), nil
+}
+
+// Validate checks that a vendor's template parses and renders a sample order
+// on both paper widths, so a broken template is refused when it is saved
+// rather than when a receipt is printed.
+func Validate(format, body string) error {
+	for _, paperMM := range []int{58, 80} {
+		receipt := Sample()
+		receipt.PaperMM = paperMM
+		receipt.Width = paperColumns[paperMM]
+
+		var err error
+		switch format {
+		case "text":
+			_, err = Text(body, receipt)
+		case "html":
+			_, err = HTML(body, receipt)
+		default:
+			return fmt.Errorf("%w: format must be text or html", ErrInvalidTemplate)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sample is the order templates are checked against.
+func Sample() types.Receipt {
+	placed := time.Date(2024, time.March, 1, 19, 30, 0, 0, time.UTC)
+	return types.Receipt{
+		Vendor: types.ReceiptVendor{
+			Name:         "Sample Kitchen",
+			AddressLines: []string{"1 Market Street", "Springfield 12345"},
+		},
+		Number:          "A1B2C3D4",
+		Status:          "preparing",
+		PaymentStatus:   "paid",
+		FulfillmentType: "dine_in",
+		TableName:       "12",
+		PartySize:       2,
+		PlacedAt:        placed,
+		PrintedAt:       placed.Add(45 * time.Minute),
+		Lines: []types.ReceiptLine{
+			{
+				Name:        "Margherita pizza with a name long enough to wrap",
+				VariantName: "Large",
+				Quantity:    2,
+				UnitPrice:   12.5,
+				Amount:      25,
+				Modifiers:   []types.ReceiptModifier{{GroupName: "Extras", Name: "Extra cheese", PriceDelta: 1.5}},
+			},
+			{Name: "Lemonade", Quantity: 1, UnitPrice: 3.2, Amount: 3.2, Modifiers: []types.ReceiptModifier{}},
+		},
+		Subtotal:      28.2,
+		Discounts:     []types.ReceiptAmount{{Label: "SPRING10", Amount: 2.82}},
+		DiscountTotal: 2.82,
+		Taxes:         []types.ReceiptTax{{Name: "VAT", Rate: 0.2, Amount: 5.08}},
+		TaxTotal:      5.08,
+		Adjustments:   []types.ReceiptAmount{{Label: "Service charge", Amount: 3}},
+		Total:         33.46,
+		NetTotal:      33.46,
+	}
+}
+
+// commonFuncs are available to text and HTML templates.
+var commonFuncs = map[string]interface{}{
+	"money": func(amount float64) string {
+		return strconv.FormatFloat(amount, 'f', 2, 64)
+	},
+	"percent": percent,
+	"taxLabel": func(tax types.ReceiptTax) string {
+		label := tax.Name + " " + percent(tax.Rate)
+		if tax.IsInclusive {
+			label += " incl."
+		}
+		return label
+	},
+	"upper": strings.ToUpper,
+	"title": func(s string) string {
+		s = strings.ReplaceAll(s, "_", " ")
+		if s == "" {
+			return s
+		}
+		return strings.ToUpper(s[:1]) + s[1:]
+	},
+}
+
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', -1, 64) + "%"
+}
+
+// textFuncs adds helpers that lay text out on a printer width characters
+// wide.
+func textFuncs(width int) texttemplate.FuncMap {
+	funcs := texttemplate.FuncMap{
+		"center": func(s string) string {
+			lines := wrap(s, width)
+			for i, line := range lines {
+				lines[i] = strings.Repeat(" ", (width-utf8.RuneCountInString(line))/2) + line
+			}
+			return strings.Join(lines, "\n")
+		},
+		"row": func(left, right string) string {
+			return row(left, right, width)
+		},
+		"rule": func(char string) string {
+			return strings.Repeat(char, width/max(utf8.RuneCountInString(char), 1))
+		},
+		"wrap": func(s string) string {
+			return strings.Join(wrap(s, width), "\n")
+		},
+		"indent": func(spaces int, s string) string {
+			pad := strings.Repeat(" ", spaces)
+			lines := wrap(s, width-spaces)
+			for i, line := range lines {
+				lines[i] = pad + line
+			}
+			return strings.Join(lines, "\n")
+		},
+	}
+	for name, fn := range commonFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// row puts left and right on either side of a line. A left side too long to
+// share the line wraps, with right on its last line if it fits there.
+func row(left, right string, width int) string {
+	lines := wrap(left, width)
+	last := lines[len(lines)-1]
+	gap := width - utf8.RuneCountInString(last) - utf8.RuneCountInString(right)
+	if gap >= 1 {
+		lines[len(lines)-1] = last + strings.Repeat(" ", gap) + right
+	} else if right != "" {
+		lines = append(lines, strings.Repeat(" ", max(width-utf8.RuneCountInString(right), 0))+right)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrap breaks s into lines of at most width characters, at spaces where it
+// can.
+func wrap(s string, width int) []string {
+	if width < 1 {
+		return []string{s}
+	}
+
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(s) {
+		runes := []rune(word)
+		if len(line) > 0 && len(line)+1+len(runes) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		for len(runes) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, runes...)
+	}
+	return append(lines, string(line))
+}
@@ -0,0 +1,108 @@
+package receipts
+
+// defaultTemplates are used for any kind and format a vendor has not
+// replaced. Vendors start their own from these.
+var defaultTemplates = map[string]string{
+	"receipt/text": `{{center .Vendor.Name}}
+{{range .Vendor.AddressLines}}{{center .}}
+{{end}}{{rule "="}}
+{{row (printf "Order #%s" .Number) (.PlacedAt.Format "2006-01-02 15:04")}}
+{{if .TableName}}{{row (printf "Table %s" .TableName) (printf "Guests %d" .PartySize)}}
+{{else if .PickupAt}}{{row "Pickup" (.PickupAt.Format "15:04")}}
+{{else if .DeliveryAddress}}{{wrap (printf "Deliver to %s" .DeliveryAddress)}}
+{{end}}{{rule "-"}}
+{{range .Lines}}{{row (printf "%dx %s" .Quantity .Name) (money .Amount)}}
+{{if .VariantName}}{{indent 3 .VariantName}}
+{{end}}{{range .Modifiers}}{{indent 3 (printf "+ %s" .Name)}}
+{{end}}{{end}}{{rule "-"}}
+{{row "Subtotal" (money .Subtotal)}}
+{{range .Discounts}}{{row .Label (printf "-%s" (money .Amount))}}
+{{end}}{{range .Taxes}}{{row (taxLabel .) (money .Amount)}}
+{{end}}{{range .Adjustments}}{{row .Label (money .Amount)}}
+{{end}}{{rule "="}}
+{{row "TOTAL" (money .Total)}}
+{{if .RefundedTotal}}{{row "Refunded" (printf "-%s" (money .RefundedTotal))}}
+{{row "Net" (money .NetTotal)}}
+{{end}}{{row "Payment" (title .PaymentStatus)}}
+{{rule "="}}
+{{center "Thank you!"}}
+`,
+
+	"kitchen/text": `{{center (upper (title .FulfillmentType))}}
+{{rule "="}}
+{{row (printf "#%s" .Number) (.PlacedAt.Format "15:04")}}
+{{if .TableName}}{{row (printf "Table %s" .TableName) (printf "Guests %d" .PartySize)}}
+{{else if .PickupAt}}{{row "Pickup" (.PickupAt.Format "15:04")}}
+{{end}}{{rule "-"}}
+{{range .Lines}}{{wrap (printf "%dx %s" .Quantity (upper .Name))}}
+{{if .VariantName}}{{indent 3 .VariantName}}
+{{end}}{{range .Modifiers}}{{indent 3 (printf "+ %s" .Name)}}
+{{end}}{{end}}{{rule "-"}}
+{{if .DeliveryInstructions}}{{wrap .DeliveryInstructions}}
+{{end}}{{row "Printed" (.PrintedAt.Format "15:04")}}
+`,
+
+	"receipt/html": `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt #{{.Number}}</title>
+<style>
+body { font-family: monospace; width: {{.PaperMM}}mm; margin: 0 auto; }
+h1 { font-size: 1.2em; text-align: center; margin: 0; }
+.address { text-align: center; }
+table { width: 100%; border-collapse: collapse; }
+td.amount { text-align: right; white-space: nowrap; }
+.detail td { padding-left: 1.5em; font-size: 0.9em; }
+.total td { font-weight: bold; border-top: 1px solid; }
+</style>
+</head>
+<body>
+<h1>{{.Vendor.Name}}</h1>
+<div class="address">{{range .Vendor.AddressLines}}{{.}}<br>{{end}}</div>
+<p>Order #{{.Number}}<br>{{.PlacedAt.Format "02 Jan 2006 15:04"}}
+{{if .TableName}}<br>Table {{.TableName}}, {{.PartySize}} guests{{else if .PickupAt}}<br>Pickup at {{.PickupAt.Format "15:04"}}{{else if .DeliveryAddress}}<br>Deliver to {{.DeliveryAddress}}{{end}}</p>
+<table>
+{{range .Lines}}<tr><td>{{.Quantity}}x {{.Name}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{if .VariantName}}<tr class="detail"><td colspan="2">{{.VariantName}}</td></tr>
+{{end}}{{range .Modifiers}}<tr class="detail"><td colspan="2">+ {{.Name}}</td></tr>
+{{end}}{{end}}<tr class="total"><td>Subtotal</td><td class="amount">{{money .Subtotal}}</td></tr>
+{{range .Discounts}}<tr><td>{{.Label}}</td><td class="amount">-{{money .Amount}}</td></tr>
+{{end}}{{range .Taxes}}<tr><td>{{taxLabel .}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}{{range .Adjustments}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr class="total"><td>Total</td><td class="amount">{{money .Total}}</td></tr>
+{{if .RefundedTotal}}<tr><td>Refunded</td><td class="amount">-{{money .RefundedTotal}}</td></tr>
+<tr><td>Net</td><td class="amount">{{money .NetTotal}}</td></tr>
+{{end}}<tr><td>Payment</td><td class="amount">{{title .PaymentStatus}}</td></tr>
+</table>
+<p class="address">Thank you!</p>
+</body>
+</html>
+`,
+
+	"kitchen/html": `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Ticket #{{.Number}}</title>
+<style>
+body { font-family: monospace; width: {{.PaperMM}}mm; margin: 0 auto; }
+h1 { font-size: 1.4em; text-align: center; margin: 0; }
+li { font-size: 1.2em; font-weight: bold; }
+li ul li { font-size: 0.8em; font-weight: normal; }
+</style>
+</head>
+<body>
+<h1>{{upper (title .FulfillmentType)}}</h1>
+<p>#{{.Number}} at {{.PlacedAt.Format "15:04"}}
+{{if .TableName}}<br>Table {{.TableName}}, {{.PartySize}} guests{{else if .PickupAt}}<br>Pickup at {{.PickupAt.Format "15:04"}}{{end}}</p>
+<ul>
+{{range .Lines}}<li>{{.Quantity}}x {{.Name}}{{if or .VariantName .Modifiers}}
+<ul>{{if .VariantName}}<li>{{.VariantName}}</li>{{end}}{{range .Modifiers}}<li>+ {{.Name}}</li>{{end}}</ul>{{end}}</li>
+{{end}}</ul>
+{{if .DeliveryInstructions}}<p>{{.DeliveryInstructions}}</p>{{end}}
+<p>Printed {{.PrintedAt.Format "15:04"}}</p>
+</body>
+</html>
+`,
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexMyAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	addresses, err := s.db.ListUserAddresses(user.ID)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, addresses)
+}
+
+func (s *Server) GetMyAddressHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := s.db.GetUserAddress(user.ID, r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Address not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, address)
+}
+
+func (s *Server) CreateMyAddressHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var address types.UserAddress
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	createdAddress, err := s.db.CreateUserAddress(user.ID, address)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdAddress)
+}
+
+func (s *Server) UpdateMyAddressHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var address types.UserAddress
+	if err := json.NewDecoder(r.Body).Decode(&address); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedAddress, err := s.db.UpdateUserAddress(user.ID, r.PathValue("id"), address)
+	if err != nil {
+		if errors.Is(err, database.ErrAddressNotFound) {
+			helpers.HandleError(w, http.StatusNotFound, "Address not found")
+			return
+		}
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedAddress)
+}
+
+func (s *Server) DeleteMyAddressHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteUserAddress(user.ID, r.PathValue("id")); err != nil {
+		if errors.Is(err, database.ErrAddressNotFound) {
+			helpers.HandleError(w, http.StatusNotFound, "Address not found")
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Address deleted successfully")
+}
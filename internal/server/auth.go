@@ -0,0 +1,72 @@
+package server
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	middleware2 "restaurant-management-backend/internal/middleware"
+	"restaurant-management-backend/internal/types"
+)
+
+// requireVendorAdmin lets site admins through and otherwise only admins of
+// the given vendor. It writes the error response itself when access is denied.
+func (s *Server) requireVendorAdmin(w http.ResponseWriter, r *http.Request, vendorID uuid.UUID) (types.User, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return user, false
+	}
+
+	if middleware2.HasRole(user, 1) {
+		return user, true
+	}
+
+	isAdmin, err := s.db.IsVendorAdmin(user.ID, vendorID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to check vendor admin")
+		helpers.HandleError(w, http.StatusInternalServerError, "Unable to verify permissions")
+		return user, false
+	}
+	if !isAdmin {
+		helpers.HandleError(w, http.StatusForbidden, "Forbidden: You are not an admin of this vendor")
+		return user, false
+	}
+
+	return user, true
+}
+
+// pathVendorAdmin parses the vendor in the path for its admins.
+func (s *Server) pathVendorAdmin(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return vendorID, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return vendorID, false
+	}
+	return vendorID, true
+}
+
+// itemVendorAdmin loads the item in the path if the user administers its
+// vendor.
+func (s *Server) itemVendorAdmin(w http.ResponseWriter, r *http.Request) (*types.Item, bool) {
+	item, err := s.db.GetItemByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Item not found")
+		return nil, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, item.VendorId); !ok {
+		return nil, false
+	}
+	return item, true
+}
+
+// requireUser returns the signed-in user, answering 401 when there is none.
+func requireUser(w http.ResponseWriter, r *http.Request) (types.User, bool) {
+	user, ok := middleware2.GetUser(r)
+	if !ok {
+		helpers.HandleError(w, http.StatusUnauthorized, "Unauthorized: User information is missing")
+	}
+	return user, ok
+}
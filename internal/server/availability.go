@@ -0,0 +1,46 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexItemSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	item, err := s.db.GetItemByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+
+	schedules, err := s.db.ListItemSchedules(item.ID)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, schedules)
+}
+
+func (s *Server) SetItemSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.itemVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Schedules []types.AvailabilitySchedule `json:"schedules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	schedules, err := s.db.SetItemSchedules(item.ID.String(), request.Schedules)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, schedules)
+}
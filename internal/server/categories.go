@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, meta, err := s.db.ListCategories(r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: categories})
+}
+
+func (s *Server) GetCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	category, err := s.db.GetCategoryByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, category)
+}
+
+func (s *Server) CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	var category types.Category
+	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, category.VendorId); !ok {
+		return
+	}
+
+	createdCategory, err := s.db.CreateCategory(category)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdCategory)
+}
+
+func (s *Server) UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetCategoryByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	var category types.Category
+	cleared, err := helpers.DecodeJSONBody(r, &category)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedCategory, err := s.db.UpdateCategory(existing.ID.String(), category, cleared)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedCategory)
+}
+
+func (s *Server) DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetCategoryByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	if err := s.db.DeleteCategory(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Category deleted successfully")
+}
+
+func (s *Server) SetItemCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.itemVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		CategoryIds []uuid.UUID `json:"category_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	categories, err := s.db.SetItemCategories(item.ID.String(), request.CategoryIds)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, categories)
+}
+
+// SetCategoryItemsHandler orders the items listed under a category, given
+// as {"item_ids": [...]}.
+func (s *Server) SetCategoryItemsHandler(w http.ResponseWriter, r *http.Request) {
+	category, err := s.db.GetCategoryByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, category.VendorId); !ok {
+		return
+	}
+
+	var request struct {
+		ItemIds []uuid.UUID `json:"item_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	items, err := s.db.SetCategoryItems(category.ID.String(), request.ItemIds)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, items)
+}
+
+func (s *Server) VendorMenuHandler(w http.ResponseWriter, r *http.Request) {
+	menu, err := s.db.GetVendorMenu(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, menu)
+}
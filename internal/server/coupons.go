@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	middleware2 "restaurant-management-backend/internal/middleware"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexCouponsHandler(w http.ResponseWriter, r *http.Request) {
+	coupons, meta, err := s.db.ListCoupons(r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: coupons})
+}
+
+func (s *Server) GetCouponHandler(w http.ResponseWriter, r *http.Request) {
+	coupon, err := s.db.GetCouponByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Coupon not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, coupon)
+}
+
+func (s *Server) CreateCouponHandler(w http.ResponseWriter, r *http.Request) {
+	var coupon types.Coupon
+	if err := json.NewDecoder(r.Body).Decode(&coupon); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if !s.requireCouponAdmin(w, r, coupon.VendorId) {
+		return
+	}
+
+	createdCoupon, err := s.db.CreateCoupon(coupon)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdCoupon)
+}
+
+func (s *Server) UpdateCouponHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetCouponByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Coupon not found")
+		return
+	}
+	if !s.requireCouponAdmin(w, r, existing.VendorId) {
+		return
+	}
+
+	var coupon types.Coupon
+	cleared, err := helpers.DecodeJSONBody(r, &coupon)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedCoupon, err := s.db.UpdateCoupon(existing.ID.String(), coupon, cleared)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedCoupon)
+}
+
+func (s *Server) DeleteCouponHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetCouponByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Coupon not found")
+		return
+	}
+	if !s.requireCouponAdmin(w, r, existing.VendorId) {
+		return
+	}
+
+	if err := s.db.DeleteCoupon(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Coupon deleted successfully")
+}
+
+func (s *Server) ApplyCouponHandler(w http.ResponseWriter, r *http.Request) {
+	code := r.FormValue("code")
+	if code == "" {
+		helpers.HandleError(w, http.StatusBadRequest, "Code is required")
+		return
+	}
+
+	cart, err := s.db.ApplyCartCoupon(s.db.GetUserID(r), code)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCoupon) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to apply coupon")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, cart)
+}
+
+func (s *Server) RemoveCouponHandler(w http.ResponseWriter, r *http.Request) {
+	cart, err := s.db.RemoveCartCoupon(s.db.GetUserID(r))
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to remove coupon")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, cart)
+}
+
+// requireCouponAdmin lets vendor admins manage their own vendor's coupons.
+// Coupons without a vendor work at every vendor, so only site admins manage
+// those.
+func (s *Server) requireCouponAdmin(w http.ResponseWriter, r *http.Request, vendorID *uuid.UUID) bool {
+	if vendorID != nil {
+		_, ok := s.requireVendorAdmin(w, r, *vendorID)
+		return ok
+	}
+
+	user, ok := requireUser(w, r)
+	if !ok {
+		return false
+	}
+	if !middleware2.HasRole(user, 1) {
+		helpers.HandleError(w, http.StatusForbidden, "Forbidden: Only site admins can manage coupons without a vendor")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+)
+
+func (s *Server) IndexDeliveryZonesHandler(w http.ResponseWriter, r *http.Request) {
+	zones, meta, err := s.db.ListDeliveryZones(r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: zones})
+}
+
+func (s *Server) GetDeliveryZoneHandler(w http.ResponseWriter, r *http.Request) {
+	zone, err := s.db.GetDeliveryZoneByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Delivery zone not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, zone)
+}
+
+func (s *Server) CreateDeliveryZoneHandler(w http.ResponseWriter, r *http.Request) {
+	var zone types.DeliveryZone
+	if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, zone.VendorId); !ok {
+		return
+	}
+
+	createdZone, err := s.db.CreateDeliveryZone(zone)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdZone)
+}
+
+func (s *Server) UpdateDeliveryZoneHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetDeliveryZoneByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Delivery zone not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	var zone types.DeliveryZone
+	if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedZone, err := s.db.UpdateDeliveryZone(existing.ID.String(), zone)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedZone)
+}
+
+func (s *Server) DeleteDeliveryZoneHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetDeliveryZoneByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Delivery zone not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	if err := s.db.DeleteDeliveryZone(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Delivery zone deleted successfully")
+}
+
+// DeliveryQuoteHandler tells a customer whether a vendor delivers to
+// ?lat=&lng= and for what fee, given the ?amount= of the order.
+func (s *Server) DeliveryQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+
+	point, err := parseLatLng(r)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var amount float64
+	if value := r.FormValue("amount"); value != "" {
+		amount, err = strconv.ParseFloat(value, 64)
+		if err != nil || amount < 0 {
+			helpers.HandleError(w, http.StatusBadRequest, "amount must be a non-negative number")
+			return
+		}
+	}
+
+	quote, err := s.db.QuoteDelivery(vendorID, point, amount)
+	if err != nil {
+		if errors.Is(err, database.ErrDeliveryUnavailable) || errors.Is(err, database.ErrInvalidLocation) {
+			helpers.HandleError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to quote delivery")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, quote)
+}
+
+// SetCartDeliveryHandler delivers the cart to a saved address (address_id),
+// a one-off address (address with lat and lng), or the user's default
+// address when neither is given.
+func (s *Server) SetCartDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	if rejectGuest(w, r) {
+		return
+	}
+
+	var destination types.DeliveryDestination
+
+	if addressID := r.FormValue("address_id"); addressID != "" {
+		id, err := uuid.Parse(addressID)
+		if err != nil {
+			helpers.HandleError(w, http.StatusBadRequest, "Invalid address ID")
+			return
+		}
+		destination.AddressId = &id
+	} else if address := r.FormValue("address"); address != "" {
+		point, err := parseLatLng(r)
+		if err != nil {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		destination.Address = address
+		destination.Point = point
+		if instructions := r.FormValue("delivery_instructions"); instructions != "" {
+			destination.Instructions = &instructions
+		}
+	}
+
+	cart, err := s.db.SetCartDelivery(s.db.GetUserID(r), destination)
+	if err != nil {
+		if errors.Is(err, database.ErrAddressNotFound) {
+			helpers.HandleError(w, http.StatusNotFound, "Address not found")
+			return
+		}
+		if errors.Is(err, database.ErrDeliveryUnavailable) || errors.Is(err, database.ErrInvalidLocation) {
+			helpers.HandleError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to set delivery address")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, cart)
+}
+
+func (s *Server) RemoveCartDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	cart, err := s.db.RemoveCartDelivery(s.db.GetUserID(r))
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to remove delivery address")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, cart)
+}
+
+func parseLatLng(r *http.Request) (types.LatLng, error) {
+	lat, err := strconv.ParseFloat(r.FormValue("lat"), 64)
+	if err != nil {
+		return types.LatLng{}, errors.New("lat must be a number")
+	}
+	lng, err := strconv.ParseFloat(r.FormValue("lng"), 64)
+	if err != nil {
+		return types.LatLng{}, errors.New("lng must be a number")
+	}
+	return types.LatLng{Lat: lat, Lng: lng}, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexTableSectionsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+
+	sections, err := s.db.ListTableSections(vendorID)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, sections)
+}
+
+func (s *Server) CreateTableSectionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var section types.TableSection
+	if err := json.NewDecoder(r.Body).Decode(&section); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	created, err := s.db.CreateTableSection(vendorID, section)
+	if err != nil {
+		writeFloorPlanError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusCreated, created)
+}
+
+func (s *Server) UpdateTableSectionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var section types.TableSection
+	if err := json.NewDecoder(r.Body).Decode(&section); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updated, err := s.db.UpdateTableSection(vendorID, r.PathValue("sectionId"), section)
+	if err != nil {
+		writeFloorPlanError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updated)
+}
+
+func (s *Server) DeleteTableSectionHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteTableSection(vendorID, r.PathValue("sectionId")); err != nil {
+		writeFloorPlanError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FloorPlanHandler returns the vendor's layout with the live status of every
+// table, for the host stand.
+func (s *Server) FloorPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	plan, err := s.db.FloorPlan(vendorID)
+	if err != nil {
+		writeFloorPlanError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, plan)
+}
+
+// UpdateFloorPlanHandler saves a rearranged layout in one go:
+// {"tables": [{"table_id": ..., "section_id": ..., "x": 0, "y": 0, "shape": "round", "rotation": 0}]}.
+func (s *Server) UpdateFloorPlanHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Tables []types.TableLayout `json:"tables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	plan, err := s.db.UpdateFloorPlan(vendorID, request.Tables)
+	if err != nil {
+		writeFloorPlanError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, plan)
+}
+
+func writeFloorPlanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrSectionNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Section not found")
+	case errors.Is(err, database.ErrInvalidLayout):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to update floor plan")
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+	"time"
+)
+
+// SetCartFulfillmentHandler picks dine_in (with table_id), pickup (with an
+// optional RFC 3339 pickup_at) or delivery for the cart.
+func (s *Server) SetCartFulfillmentHandler(w http.ResponseWriter, r *http.Request) {
+	if rejectGuest(w, r) {
+		return
+	}
+
+	fulfillment := types.Fulfillment{Type: r.FormValue("fulfillment_type")}
+
+	if tableID := r.FormValue("table_id"); tableID != "" {
+		id, err := uuid.Parse(tableID)
+		if err != nil {
+			helpers.HandleError(w, http.StatusBadRequest, "Invalid table ID")
+			return
+		}
+		fulfillment.TableId = &id
+	}
+
+	if pickupAt := r.FormValue("pickup_at"); pickupAt != "" {
+		at, err := time.Parse(time.RFC3339, pickupAt)
+		if err != nil {
+			helpers.HandleError(w, http.StatusBadRequest, "pickup_at must be an RFC 3339 timestamp")
+			return
+		}
+		fulfillment.PickupAt = &at
+	}
+
+	cart, err := s.db.SetCartFulfillment(s.db.GetUserID(r), fulfillment)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidFulfillment) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to set fulfillment")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, cart)
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"net/http"
+	"net/url"
+	"os"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	middleware2 "restaurant-management-backend/internal/middleware"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+	"strings"
+)
+
+// TableQRHandler renders the code printed on a table, as a PNG by default or
+// as an SVG with format=svg. size is the PNG width in pixels.
+func (s *Server) TableQRHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := s.staffTable(w, r)
+	if !ok {
+		return
+	}
+
+	content := tableQRContent(table)
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "png":
+		size := 256
+		if raw := r.URL.Query().Get("size"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 64 || parsed > 2048 {
+				helpers.HandleError(w, http.StatusBadRequest, "size must be between 64 and 2048")
+				return
+			}
+			size = parsed
+		}
+		png, err := qrcode.Encode(content, qrcode.Medium, size)
+		if err != nil {
+			helpers.HandleError(w, http.StatusInternalServerError, "Failed to render QR code")
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.WriteHeader(http.StatusOK)
+		w.Write(png)
+	case "svg":
+		code, err := qrcode.New(content, qrcode.Medium)
+		if err != nil {
+			helpers.HandleError(w, http.StatusInternalServerError, "Failed to render QR code")
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(qrSVG(code.Bitmap())))
+	default:
+		helpers.HandleError(w, http.StatusBadRequest, "format must be png or svg")
+	}
+}
+
+// RegenerateTableQRHandler replaces a table's code, e.g. after it was
+// photographed and shared. Guests seated with the old code are signed out.
+func (s *Server) RegenerateTableQRHandler(w http.ResponseWriter, r *http.Request) {
+	table, ok := s.staffTable(w, r)
+	if !ok {
+		return
+	}
+
+	rotated, err := s.db.RotateTableToken(table.ID.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to regenerate QR code")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"table_id": rotated.ID,
+		"version":  rotated.QrTokenVersion,
+		"token":    helpers.SignTableToken(rotated.ID, rotated.QrTokenVersion),
+		"content":  tableQRContent(rotated),
+	})
+}
+
+// CreateGuestSessionHandler trades a scanned table token for a guest sign-in,
+// so diners can order at the table without an account.
+func (s *Server) CreateGuestSessionHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Token == "" {
+		helpers.HandleError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	tableID, version, err := helpers.ParseTableToken(body.Token)
+	if err != nil {
+		helpers.HandleError(w, http.StatusUnauthorized, "Invalid or expired table code")
+		return
+	}
+
+	user, table, err := s.db.CreateGuestUser(tableID, version)
+	if err != nil {
+		if errors.Is(err, helpers.ErrInvalidTableToken) {
+			helpers.HandleError(w, http.StatusUnauthorized, "Invalid or expired table code")
+			return
+		}
+		logger.Log.WithError(err).Error("Failed to create guest")
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to start guest session")
+		return
+	}
+
+	token, err := helpers.GenerateJWT(user.ID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to generate token")
+		helpers.HandleError(w, http.StatusInternalServerError, "Error generating token")
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, types.GuestSession{
+		Token:     token.Token,
+		ExpiresAt: token.ExpiresAt,
+		User:      *user,
+		Table:     *table,
+	})
+}
+
+// staffTable loads the table in the path for its vendor's admins.
+func (s *Server) staffTable(w http.ResponseWriter, r *http.Request) (types.Table, bool) {
+	table, err := s.db.GetTableByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Table not found")
+		return table, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, table.VendorId); !ok {
+		return table, false
+	}
+	return table, true
+}
+
+// tableQRContent is what the code encodes: the ordering page from
+// QR_ORDER_URL with the token attached, or just the token when unset.
+func tableQRContent(table types.Table) string {
+	token := helpers.SignTableToken(table.ID, table.QrTokenVersion)
+	base := os.Getenv("QR_ORDER_URL")
+	if base == "" {
+		return token
+	}
+	orderURL, err := url.Parse(base)
+	if err != nil {
+		return token
+	}
+	query := orderURL.Query()
+	query.Set("token", token)
+	orderURL.RawQuery = query.Encode()
+	return orderURL.String()
+}
+
+// qrSVG draws one square per dark module. The bitmap already includes the
+// quiet zone around the code.
+func qrSVG(bitmap [][]bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]d %[1]d" shape-rendering="crispEdges">`, len(bitmap))
+	fmt.Fprintf(&b, `<rect width="%[1]d" height="%[1]d" fill="#fff"/><path fill="#000" d="`, len(bitmap))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+// guestTableID returns the table a guest user is ordering at.
+func guestTableID(user types.User) (uuid.UUID, bool) {
+	if !user.IsGuest || user.GuestTableId == nil {
+		return uuid.Nil, false
+	}
+	return *user.GuestTableId, true
+}
+
+// rejectGuest answers 403 for guest users, who only ever order dine-in at the
+// table they scanned.
+func rejectGuest(w http.ResponseWriter, r *http.Request) bool {
+	user, ok := middleware2.GetUser(r)
+	if ok && user.IsGuest {
+		helpers.HandleError(w, http.StatusForbidden, "Guests can only order to their table")
+		return true
+	}
+	return false
+}
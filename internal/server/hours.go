@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexOpeningHoursHandler(w http.ResponseWriter, r *http.Request) {
+	hours, err := s.db.ListOpeningHours(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, hours)
+}
+
+func (s *Server) SetOpeningHoursHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	var request struct {
+		Hours []types.OpeningHours `json:"hours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	hours, err := s.db.SetOpeningHours(vendorID.String(), request.Hours)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, hours)
+}
+
+func (s *Server) IndexHoursOverridesHandler(w http.ResponseWriter, r *http.Request) {
+	overrides, err := s.db.ListHoursOverrides(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, overrides)
+}
+
+func (s *Server) CreateHoursOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	var override types.HoursOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	created, err := s.db.CreateHoursOverride(vendorID.String(), override)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, created)
+}
+
+func (s *Server) DeleteHoursOverrideHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	if err := s.db.DeleteHoursOverride(vendorID.String(), r.PathValue("overrideId")); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Hours override deleted successfully")
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) LowStockHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	levels, err := s.db.ListLowStock(vendorID.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, levels)
+}
+
+func (s *Server) IndexInventoryAdjustmentsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	adjustments, meta, err := s.db.ListInventoryAdjustments(vendorID.String(), r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: adjustments})
+}
+
+func (s *Server) CreateInventoryAdjustmentHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	user, ok := s.requireVendorAdmin(w, r, vendorID)
+	if !ok {
+		return
+	}
+
+	var request types.StockAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	adjustment, err := s.db.AdjustStock(vendorID.String(), request, user.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidAdjustment) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, adjustment)
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexKitchenStationsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	stations, err := s.db.ListKitchenStations(vendorID)
+	if err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, stations)
+}
+
+func (s *Server) CreateKitchenStationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var station types.KitchenStation
+	if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	created, err := s.db.CreateKitchenStation(vendorID, station)
+	if err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusCreated, created)
+}
+
+func (s *Server) UpdateKitchenStationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var station types.KitchenStation
+	if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updated, err := s.db.UpdateKitchenStation(vendorID, r.PathValue("stationId"), station)
+	if err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updated)
+}
+
+func (s *Server) DeleteKitchenStationHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteKitchenStation(vendorID, r.PathValue("stationId")); err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetKitchenStationRoutesHandler replaces what the station makes:
+// {"item_ids": [...], "category_ids": [...]}.
+func (s *Server) SetKitchenStationRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var routes types.KitchenStationRoutes
+	if err := json.NewDecoder(r.Body).Decode(&routes); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	station, err := s.db.SetKitchenStationRoutes(vendorID, r.PathValue("stationId"), routes)
+	if err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, station)
+}
+
+// KitchenFeedHandler is what a station's screen polls: its tickets, most
+// urgent first, with their age. ?status= is open (default), bumped or all.
+func (s *Server) KitchenFeedHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	tickets, err := s.db.KitchenFeed(vendorID, r.PathValue("stationId"), r.URL.Query().Get("status"))
+	if err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, tickets)
+}
+
+func (s *Server) GetKitchenTicketHandler(w http.ResponseWriter, r *http.Request) {
+	ticket, ok := s.staffKitchenTicket(w, r)
+	if !ok {
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, ticket)
+}
+
+// BumpKitchenTicketHandler marks the whole ticket done. The order becomes
+// ready once every station has bumped its ticket.
+func (s *Server) BumpKitchenTicketHandler(w http.ResponseWriter, r *http.Request) {
+	s.kitchenTicketAction(w, r, func(id string) (*types.KitchenTicket, error) {
+		return s.db.BumpKitchenTicket(id)
+	})
+}
+
+func (s *Server) RecallKitchenTicketHandler(w http.ResponseWriter, r *http.Request) {
+	s.kitchenTicketAction(w, r, func(id string) (*types.KitchenTicket, error) {
+		return s.db.RecallKitchenTicket(id)
+	})
+}
+
+func (s *Server) BumpKitchenTicketLineHandler(w http.ResponseWriter, r *http.Request) {
+	s.kitchenTicketAction(w, r, func(id string) (*types.KitchenTicket, error) {
+		return s.db.BumpKitchenTicketLine(id, r.PathValue("lineId"))
+	})
+}
+
+func (s *Server) RecallKitchenTicketLineHandler(w http.ResponseWriter, r *http.Request) {
+	s.kitchenTicketAction(w, r, func(id string) (*types.KitchenTicket, error) {
+		return s.db.RecallKitchenTicketLine(id, r.PathValue("lineId"))
+	})
+}
+
+// SetKitchenTicketPriorityHandler rushes a ticket, or holds it back, with
+// {"priority": n}. Higher priorities are shown first.
+func (s *Server) SetKitchenTicketPriorityHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Priority *int `json:"priority"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Priority == nil {
+		helpers.HandleError(w, http.StatusBadRequest, "priority is required")
+		return
+	}
+
+	s.kitchenTicketAction(w, r, func(id string) (*types.KitchenTicket, error) {
+		return s.db.SetKitchenTicketPriority(id, *request.Priority)
+	})
+}
+
+// staffKitchenTicket loads the ticket in the path for its vendor's admins.
+func (s *Server) staffKitchenTicket(w http.ResponseWriter, r *http.Request) (*types.KitchenTicket, bool) {
+	ticket, err := s.db.GetKitchenTicket(r.PathValue("id"))
+	if err != nil {
+		writeKitchenError(w, err)
+		return nil, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, ticket.VendorId); !ok {
+		return nil, false
+	}
+	return ticket, true
+}
+
+func (s *Server) kitchenTicketAction(w http.ResponseWriter, r *http.Request, action func(id string) (*types.KitchenTicket, error)) {
+	ticket, ok := s.staffKitchenTicket(w, r)
+	if !ok {
+		return
+	}
+
+	updatedTicket, err := action(ticket.ID.String())
+	if err != nil {
+		writeKitchenError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedTicket)
+}
+
+func writeKitchenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrKitchenStationNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Kitchen station not found")
+	case errors.Is(err, database.ErrKitchenTicketNotFound):
+		helpers.HandleError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, database.ErrInvalidKitchenStation):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to update kitchen")
+	}
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexModifierGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	groups, meta, err := s.db.ListModifierGroups(r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: groups})
+}
+
+func (s *Server) GetModifierGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, err := s.db.GetModifierGroupByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Modifier group not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, group)
+}
+
+func (s *Server) CreateModifierGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var group types.ModifierGroup
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, group.VendorId); !ok {
+		return
+	}
+
+	createdGroup, err := s.db.CreateModifierGroup(group)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdGroup)
+}
+
+func (s *Server) UpdateModifierGroupHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetModifierGroupByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Modifier group not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	var group types.ModifierGroup
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedGroup, err := s.db.UpdateModifierGroup(existing.ID.String(), group)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedGroup)
+}
+
+func (s *Server) DeleteModifierGroupHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetModifierGroupByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Modifier group not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	if err := s.db.DeleteModifierGroup(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Modifier group deleted successfully")
+}
+
+func (s *Server) CreateModifierOptionHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetModifierGroupByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Modifier group not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	var option types.ModifierOption
+	if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	createdOption, err := s.db.CreateModifierOption(existing.ID.String(), option)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdOption)
+}
+
+func (s *Server) UpdateModifierOptionHandler(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.modifierOptionVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var option types.ModifierOption
+	if err := json.NewDecoder(r.Body).Decode(&option); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedOption, err := s.db.UpdateModifierOption(existing.ID.String(), option)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedOption)
+}
+
+func (s *Server) DeleteModifierOptionHandler(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.modifierOptionVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteModifierOption(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Modifier option deleted successfully")
+}
+
+// IndexItemModifierGroupsHandler lists the groups offered with an item with
+// every option, inactive ones included, for the vendor's admins.
+func (s *Server) IndexItemModifierGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.itemVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	groups, err := s.db.GetItemModifierGroups(item.ID, true)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, groups)
+}
+
+// modifierOptionVendorAdmin loads the modifier option in the path if the
+// user administers the vendor of its group.
+func (s *Server) modifierOptionVendorAdmin(w http.ResponseWriter, r *http.Request) (*types.ModifierOption, bool) {
+	option, err := s.db.GetModifierOptionByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Modifier option not found")
+		return nil, false
+	}
+	group, err := s.db.GetModifierGroupByID(option.GroupId.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Modifier group not found")
+		return nil, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, group.VendorId); !ok {
+		return nil, false
+	}
+	return option, true
+}
+
+func (s *Server) SetItemModifierGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.itemVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		GroupIds []uuid.UUID `json:"group_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	groups, err := s.db.SetItemModifierGroups(item.ID.String(), request.GroupIds)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, groups)
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/payments"
+	"restaurant-management-backend/internal/types"
+	"slices"
+	"strconv"
+)
+
+// chargeOrder authorizes the outstanding balance of an order and, unless
+// told otherwise, captures it straight away. Every step is written to the
+// payment ledger, failed attempts included.
+func (s *Server) chargeOrder(r *http.Request, order types.Order, method, token string, capture bool) (types.Payment, error) {
+	payment, err := s.db.CreatePayment(order.ID, s.payments.Name(), method)
+	if err != nil {
+		return payment, err
+	}
+
+	result, err := s.payments.Authorize(r.Context(), payments.AuthorizeRequest{
+		OrderID: order.ID.String(),
+		Amount:  payment.Amount,
+		Token:   token,
+	})
+	if err != nil {
+		reason := err.Error()
+		payment.Status = "failed"
+		payment.FailureReason = &reason
+		if err := s.db.UpdatePayment(payment, types.PaymentTransaction{Type: "authorize", Amount: payment.Amount}); err != nil {
+			logger.Log.WithError(err).Error("Failed to record declined payment")
+		}
+		return payment, err
+	}
+
+	payment.ProviderRef = &result.Reference
+	payment.Status = "authorized"
+	if err := s.db.UpdatePayment(payment, types.PaymentTransaction{Type: "authorize", Amount: result.Amount}); err != nil {
+		return payment, err
+	}
+
+	if !capture {
+		return payment, nil
+	}
+
+	return s.capturePayment(r, payment, payment.Amount)
+}
+
+func (s *Server) capturePayment(r *http.Request, payment types.Payment, amount float64) (types.Payment, error) {
+	if payment.Status != "authorized" || payment.ProviderRef == nil {
+		return payment, payments.ErrInvalidState
+	}
+
+	result, err := s.payments.Capture(r.Context(), *payment.ProviderRef, amount)
+	if err != nil {
+		return payment, err
+	}
+
+	payment.Status = "captured"
+	payment.CapturedAmount = result.Amount
+	err = s.db.UpdatePayment(payment, types.PaymentTransaction{Type: "capture", Amount: result.Amount})
+	return payment, err
+}
+
+func (s *Server) IndexOrderPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	order, ok := s.accessibleOrder(w, r)
+	if !ok {
+		return
+	}
+
+	payments, err := s.db.ListOrderPayments(order.ID.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, payments)
+}
+
+func (s *Server) CreateOrderPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	order, ok := s.accessibleOrder(w, r)
+	if !ok {
+		return
+	}
+
+	method := r.FormValue("method")
+	if method == "" {
+		method = "pay_at_table"
+	}
+	if method != "pay_now" && method != "pay_at_table" {
+		helpers.HandleError(w, http.StatusBadRequest, "Method must be pay_now or pay_at_table")
+		return
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		helpers.HandleError(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	capture := helpers.ParseBoolWithDefault(r.FormValue("capture"), true)
+
+	payment, err := s.chargeOrder(r, order, method, token, capture)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, payment)
+}
+
+func (s *Server) CapturePaymentHandler(w http.ResponseWriter, r *http.Request) {
+	payment, err := s.db.GetPaymentByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+	if !s.requirePaymentVendorAdmin(w, r, *payment) {
+		return
+	}
+
+	amount := payment.Amount
+	if value := r.FormValue("amount"); value != "" {
+		amount, err = strconv.ParseFloat(value, 64)
+		if err != nil || amount <= 0 {
+			helpers.HandleError(w, http.StatusBadRequest, "Amount must be a positive number")
+			return
+		}
+	}
+
+	captured, err := s.capturePayment(r, *payment, amount)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, captured)
+}
+
+func (s *Server) VoidPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	payment, err := s.db.GetPaymentByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+	if !s.requirePaymentVendorAdmin(w, r, *payment) {
+		return
+	}
+	if payment.Status != "authorized" || payment.ProviderRef == nil {
+		writePaymentError(w, payments.ErrInvalidState)
+		return
+	}
+
+	if _, err := s.payments.Void(r.Context(), *payment.ProviderRef); err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	payment.Status = "voided"
+	if err := s.db.UpdatePayment(*payment, types.PaymentTransaction{Type: "void"}); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, payment)
+}
+
+// requirePaymentVendorAdmin only lets admins of the vendor of the payment's
+// order through.
+func (s *Server) requirePaymentVendorAdmin(w http.ResponseWriter, r *http.Request, payment types.Payment) bool {
+	order, err := s.db.FetchOrder(payment.OrderId.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	_, ok := s.requireVendorAdmin(w, r, order.VendorId)
+	return ok
+}
+
+// webhookTransitions lists, for each provider event, the payment statuses it
+// may move a payment on from.
+var webhookTransitions = map[string][]string{
+	"payment.authorized": {"pending"},
+	"payment.captured":   {"pending", "authorized"},
+	"payment.refunded":   {"captured", "partially_refunded"},
+	"payment.voided":     {"pending", "authorized"},
+	"payment.failed":     {"pending", "authorized"},
+}
+
+// PaymentWebhookHandler receives asynchronous callbacks from the provider.
+// Replayed events are acknowledged without being applied twice.
+func (s *Server) PaymentWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	event, err := s.payments.ParseWebhook(r)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			helpers.HandleError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	payment, err := s.db.GetPaymentByProviderRef(s.payments.Name(), event.Reference)
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+
+	from, known := webhookTransitions[event.Type]
+	if !known {
+		helpers.WriteJSONResponse(w, http.StatusOK, map[string]string{"message": "Event ignored"})
+		return
+	}
+	// Events can arrive late or out of order. Acknowledge those that no
+	// longer apply so the provider stops sending them, but leave the
+	// payment as it is.
+	if !slices.Contains(from, payment.Status) {
+		logger.Log.WithField("payment_id", payment.ID).
+			WithField("event_type", event.Type).
+			WithField("status", payment.Status).
+			Warn("Ignoring payment webhook that does not apply to the payment's status")
+		helpers.WriteJSONResponse(w, http.StatusOK, map[string]string{"message": "Event ignored"})
+		return
+	}
+
+	transaction := types.PaymentTransaction{
+		Type:    "webhook:" + event.Type,
+		Amount:  event.Amount,
+		EventId: &event.ID,
+		Payload: event.Payload,
+	}
+
+	switch event.Type {
+	case "payment.authorized":
+		payment.Status = "authorized"
+	case "payment.captured":
+		payment.Status = "captured"
+		payment.CapturedAmount = event.Amount
+	case "payment.refunded":
+		payment.RefundedAmount += event.Amount
+		payment.Status = "partially_refunded"
+		if payment.RefundedAmount >= payment.CapturedAmount {
+			payment.Status = "refunded"
+		}
+	case "payment.voided":
+		payment.Status = "voided"
+	case "payment.failed":
+		reason := "declined by provider"
+		payment.Status = "failed"
+		payment.FailureReason = &reason
+	}
+
+	if err := s.db.UpdatePayment(*payment, transaction); err != nil {
+		if errors.Is(err, database.ErrDuplicatePaymentEvent) {
+			helpers.WriteJSONResponse(w, http.StatusOK, map[string]string{"message": "Event already processed"})
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, map[string]string{"message": "Event processed"})
+}
+
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, payments.ErrDeclined):
+		helpers.HandleError(w, http.StatusPaymentRequired, err.Error())
+	case errors.Is(err, payments.ErrInvalidState), errors.Is(err, payments.ErrUnknownPayment),
+		errors.Is(err, database.ErrOrderAlreadyPaid):
+		helpers.HandleError(w, http.StatusConflict, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	}
+}
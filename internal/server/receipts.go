@@ -0,0 +1,199 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/receipts"
+	"strconv"
+)
+
+// OrderReceiptHandler prints an order. kind is receipt (default) or kitchen,
+// a ticket without prices for staff. format is text (default), escpos, html
+// or pdf, and paper is the roll width in mm, 58 or 80 (default).
+func (s *Server) OrderReceiptHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind == "" {
+		kind = "receipt"
+	}
+	if kind != "receipt" && kind != "kitchen" {
+		helpers.HandleError(w, http.StatusBadRequest, "kind must be receipt or kitchen")
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "text"
+	}
+	paperMM := 80
+	if raw := query.Get("paper"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			helpers.HandleError(w, http.StatusBadRequest, "paper must be 58 or 80")
+			return
+		}
+		paperMM = parsed
+	}
+	width, err := receipts.Columns(paperMM)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "paper must be 58 or 80")
+		return
+	}
+
+	order, err := s.db.FetchOrder(r.PathValue("id"))
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	// Customers get their receipt, kitchen tickets are for staff
+	if order.CustomerId != user.ID || kind != "receipt" {
+		if _, ok := s.requireVendorAdmin(w, r, order.VendorId); !ok {
+			return
+		}
+	}
+
+	receipt, err := s.db.OrderReceipt(order.ID.String())
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	receipt.PaperMM = paperMM
+	receipt.Width = width
+
+	templateFormat := "text"
+	if format == "html" {
+		templateFormat = "html"
+	}
+	template, err := s.db.ReceiptTemplate(order.VendorId, kind, templateFormat)
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s", kind, receipt.Number)
+	switch format {
+	case "html":
+		out, err := receipts.HTML(template.Body, *receipt)
+		if err != nil {
+			writeRenderError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(out))
+	case "text", "escpos", "pdf":
+		text, err := receipts.Text(template.Body, *receipt)
+		if err != nil {
+			writeRenderError(w, err)
+			return
+		}
+		switch format {
+		case "text":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(text))
+		case "escpos":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".bin"))
+			w.WriteHeader(http.StatusOK)
+			w.Write(receipts.ESCPOS(text))
+		case "pdf":
+			pdf, err := receipts.PDF(text, paperMM, width)
+			if err != nil {
+				writeRenderError(w, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".pdf"))
+			w.WriteHeader(http.StatusOK)
+			w.Write(pdf)
+		}
+	default:
+		helpers.HandleError(w, http.StatusBadRequest, "format must be text, escpos, html or pdf")
+	}
+}
+
+// IndexReceiptTemplatesHandler lists the layouts the vendor prints with,
+// built-in ones included, so they have something to start editing from.
+func (s *Server) IndexReceiptTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	templates, err := s.db.ListReceiptTemplates(vendorID)
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, templates)
+}
+
+// SaveReceiptTemplateHandler replaces the vendor's layout for a kind and
+// format with {"body": "..."}, a Go template rendered from the receipt.
+func (s *Server) SaveReceiptTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	template, err := s.db.SaveReceiptTemplate(vendorID, r.PathValue("kind"), r.PathValue("format"), request.Body)
+	if err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, template)
+}
+
+// DeleteReceiptTemplateHandler goes back to the built-in layout.
+func (s *Server) DeleteReceiptTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteReceiptTemplate(vendorID, r.PathValue("kind"), r.PathValue("format")); err != nil {
+		writeReceiptError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeRenderError reports a receipt that could not be printed. Templates
+// are checked when they are saved, so this is a fault on our side rather
+// than in the request.
+func writeRenderError(w http.ResponseWriter, err error) {
+	logger.Log.WithError(err).Error("Failed to render receipt")
+	helpers.HandleError(w, http.StatusInternalServerError, "Failed to render receipt")
+}
+
+func writeReceiptError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		helpers.HandleError(w, http.StatusNotFound, "Order not found")
+	case errors.Is(err, database.ErrReceiptTemplateNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Receipt template not found")
+	case errors.Is(err, database.ErrInvalidReceiptTemplate):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to print receipt")
+	}
+}
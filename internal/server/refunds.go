@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexOrderRefundsHandler(w http.ResponseWriter, r *http.Request) {
+	order, err := s.db.FetchOrder(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, order.VendorId); !ok {
+		return
+	}
+
+	refunds, err := s.db.ListOrderRefunds(order.ID.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, refunds)
+}
+
+// RefundOrderHandler refunds an order in full or line by line. Money goes
+// back through the captured payments first; whatever was not paid through
+// the gateway is recorded as refunded outside of it.
+func (s *Server) RefundOrderHandler(w http.ResponseWriter, r *http.Request) {
+	order, err := s.db.FetchOrder(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	user, ok := s.requireVendorAdmin(w, r, order.VendorId)
+	if !ok {
+		return
+	}
+
+	var request types.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if request.Reason == "" {
+		helpers.HandleError(w, http.StatusBadRequest, "Reason is required")
+		return
+	}
+
+	refund, err := s.db.CreateRefund(order.ID.String(), request, user.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidRefund) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	refunded, err := s.refundPayments(r, order, refund.Amount)
+	if err == nil {
+		// What the captured payments did not cover went back outside the gateway
+		refunded = refund.Amount
+	}
+	if err != nil && refunded == 0 {
+		reason := err.Error()
+		if _, err := s.db.CompleteRefund(refund.ID, 0, &reason); err != nil {
+			logger.Log.WithError(err).Error("Failed to mark refund as failed")
+		}
+		helpers.HandleError(w, http.StatusBadGateway, "Refund failed: "+reason)
+		return
+	}
+
+	// Only what actually went back to the customer is recorded, and the
+	// lines are scaled down to match
+	var reason *string
+	if err != nil {
+		logger.Log.WithError(err).WithField("refund_id", refund.ID).Error("Refund only partially went through the payment provider")
+		message := err.Error()
+		reason = &message
+	}
+
+	completed, err := s.db.CompleteRefund(refund.ID, refunded, reason)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if reason != nil {
+		helpers.WriteJSONResponse(w, http.StatusBadGateway, map[string]interface{}{
+			"error":  "Refund only partially went through: " + *reason,
+			"refund": completed,
+		})
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, completed)
+}
+
+// refundPayments sends up to amount back through the order's captured
+// payments, newest first, and returns how much the provider refunded.
+func (s *Server) refundPayments(r *http.Request, order types.Order, amount float64) (float64, error) {
+	payments, err := s.db.ListOrderPayments(order.ID.String())
+	if err != nil {
+		return 0, err
+	}
+
+	var refunded float64
+	for i := len(payments) - 1; i >= 0 && amount-refunded > 0.001; i-- {
+		payment := payments[i]
+		refundable := payment.CapturedAmount - payment.RefundedAmount
+		if payment.ProviderRef == nil || refundable <= 0 {
+			continue
+		}
+
+		portion := math.Min(refundable, amount-refunded)
+		result, err := s.payments.Refund(r.Context(), *payment.ProviderRef, portion)
+		if err != nil {
+			return refunded, err
+		}
+
+		payment.RefundedAmount += result.Amount
+		payment.Status = "partially_refunded"
+		if payment.RefundedAmount >= payment.CapturedAmount {
+			payment.Status = "refunded"
+		}
+		if err := s.db.UpdatePayment(payment, types.PaymentTransaction{Type: "refund", Amount: result.Amount}); err != nil {
+			return refunded, err
+		}
+		refunded += result.Amount
+	}
+
+	return refunded, nil
+}
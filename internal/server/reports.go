@@ -0,0 +1,85 @@
+package server
+
+import (
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"time"
+)
+
+const reportDateLayout = "2006-01-02"
+
+// parseReportRange reads the from/to query parameters, defaulting to the last
+// 30 days. The to date is inclusive.
+func parseReportRange(r *http.Request) (time.Time, time.Time, error) {
+	to := time.Now().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -30)
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse(reportDateLayout, value)
+		if err != nil {
+			return from, to, err
+		}
+		from = parsed
+	}
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse(reportDateLayout, value)
+		if err != nil {
+			return from, to, err
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	return from, to, nil
+}
+
+func (s *Server) SalesReportHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	from, to, err := parseReportRange(r)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Dates must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	report, err := s.db.SalesReport(vendorID.String(), from, to)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, report)
+}
+
+func (s *Server) TurnoverReportHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	from, to, err := parseReportRange(r)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Dates must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	report, err := s.db.TableTurnoverReport(vendorID.String(), from, to)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, report)
+}
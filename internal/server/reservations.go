@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+)
+
+func (s *Server) CreateReservationHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var reservation types.Reservation
+	if err := json.NewDecoder(r.Body).Decode(&reservation); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	reservation.CustomerId = &user.ID
+	if reservation.Name == nil {
+		reservation.Name = &user.Name
+	}
+	if reservation.Phone == nil && user.Phone != "" {
+		reservation.Phone = &user.Phone
+	}
+
+	createdReservation, err := s.db.CreateReservation(reservation)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdReservation)
+}
+
+func (s *Server) GetReservationHandler(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := s.accessibleReservation(w, r)
+	if !ok {
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, reservation)
+}
+
+func (s *Server) ConfirmReservationHandler(w http.ResponseWriter, r *http.Request) {
+	s.vendorReservationTransition(w, r, "confirmed")
+}
+
+func (s *Server) DeclineReservationHandler(w http.ResponseWriter, r *http.Request) {
+	s.vendorReservationTransition(w, r, "declined")
+}
+
+func (s *Server) SeatReservationHandler(w http.ResponseWriter, r *http.Request) {
+	s.vendorReservationTransition(w, r, "seated")
+}
+
+func (s *Server) CompleteReservationHandler(w http.ResponseWriter, r *http.Request) {
+	s.vendorReservationTransition(w, r, "completed")
+}
+
+func (s *Server) NoShowReservationHandler(w http.ResponseWriter, r *http.Request) {
+	s.vendorReservationTransition(w, r, "no_show")
+}
+
+// CancelReservationHandler lets the customer who booked, or the vendor's
+// admins, cancel a reservation.
+func (s *Server) CancelReservationHandler(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := s.accessibleReservation(w, r)
+	if !ok {
+		return
+	}
+
+	updatedReservation, err := s.db.UpdateReservationStatus(reservation.ID.String(), "cancelled")
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedReservation)
+}
+
+func (s *Server) IndexVendorReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	reservations, meta, err := s.db.ListVendorReservations(vendorID, r.URL.Query())
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: reservations})
+}
+
+func (s *Server) IndexMyReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	reservations, meta, err := s.db.ListCustomerReservations(user.ID, r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: reservations})
+}
+
+// VendorAvailabilityHandler lists the reservation slots on ?date= that can
+// seat ?party_size= guests.
+func (s *Server) VendorAvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+
+	date := r.FormValue("date")
+	if date == "" {
+		helpers.HandleError(w, http.StatusBadRequest, "date is required")
+		return
+	}
+	partySize, err := strconv.Atoi(r.FormValue("party_size"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "party_size must be a number")
+		return
+	}
+
+	slots, err := s.db.VendorAvailability(vendorID, date, partySize)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, slots)
+}
+
+// accessibleReservation loads the reservation in the path if the user made
+// it or administers its vendor.
+func (s *Server) accessibleReservation(w http.ResponseWriter, r *http.Request) (*types.Reservation, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	reservation, err := s.db.GetReservation(r.PathValue("id"))
+	if err != nil {
+		writeReservationError(w, err)
+		return nil, false
+	}
+
+	if reservation.CustomerId != nil && *reservation.CustomerId == user.ID {
+		return reservation, true
+	}
+	if _, ok := s.requireVendorAdmin(w, r, reservation.VendorId); !ok {
+		return nil, false
+	}
+	return reservation, true
+}
+
+func (s *Server) vendorReservationTransition(w http.ResponseWriter, r *http.Request, status string) {
+	reservation, err := s.db.GetReservation(r.PathValue("id"))
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, reservation.VendorId); !ok {
+		return
+	}
+
+	updatedReservation, err := s.db.UpdateReservationStatus(reservation.ID.String(), status)
+	if err != nil {
+		writeReservationError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedReservation)
+}
+
+func writeReservationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrReservationNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Reservation not found")
+	case errors.Is(err, database.ErrReservationConflict):
+		helpers.HandleError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, database.ErrInvalidReservation):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to process reservation")
+	}
+}
@@ -12,11 +12,13 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"restaurant-management-backend/internal/database"
 	"restaurant-management-backend/internal/helpers"
 	"restaurant-management-backend/internal/logger"
 	middleware2 "restaurant-management-backend/internal/middleware"
 	"restaurant-management-backend/internal/service"
 	"restaurant-management-backend/internal/types"
+	"strconv"
 	"strings"
 )
 
@@ -31,11 +33,15 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	r.Route("/api/v1", func(r chi.Router) {
 
+		r.Get("/stream", s.StreamHandler)
+
 		r.Route("/auth", func(r chi.Router) {
 			r.Post("/signup", s.SignUpHandler)
 			r.Post("/login", s.LoginHandler)
 		})
 
+		r.Post("/guest-sessions", s.CreateGuestSessionHandler)
+
 		r.Route("/users", func(user chi.Router) {
 			user.Use(middleware2.RoleMiddleware(1))
 
@@ -46,6 +52,15 @@ func (s *Server) RegisterRoutes() http.Handler {
 			user.Delete("/{id}", s.deleteUserHandler)
 		})
 
+		r.Route("/me", func(r chi.Router) {
+			r.Get("/addresses", s.IndexMyAddressesHandler)
+			r.Post("/addresses", s.CreateMyAddressHandler)
+			r.Get("/addresses/{id}", s.GetMyAddressHandler)
+			r.Put("/addresses/{id}", s.UpdateMyAddressHandler)
+			r.Delete("/addresses/{id}", s.DeleteMyAddressHandler)
+			r.Get("/reservations", s.IndexMyReservationsHandler)
+		})
+
 		r.Route("/roles", func(r chi.Router) {
 
 			r.Get("/", s.indexRolesHandler)
@@ -60,12 +75,26 @@ func (s *Server) RegisterRoutes() http.Handler {
 			r.Get("/{id}", s.GetTableHandler)
 			r.Put("/{id}", s.UpdateTableHandler)
 			r.Delete("/{id}", s.DeleteTableHandler)
+			r.Get("/{id}/qr", s.TableQRHandler)
+			r.Post("/{id}/qr/regenerate", s.RegenerateTableQRHandler)
 		})
 
 		r.Route("/orders", func(r chi.Router) {
 			r.Get("/", s.IndexOrdersHandler)
 			r.Get("/{id}", s.GetOrderHandler)
 			r.Put("/{id}", s.UpdateOrderHandler)
+			r.Put("/{id}/tip", s.UpdateOrderTipHandler)
+			r.Get("/{id}/receipt", s.OrderReceiptHandler)
+			r.Get("/{id}/payments", s.IndexOrderPaymentsHandler)
+			r.Post("/{id}/payments", s.CreateOrderPaymentHandler)
+			r.With(middleware2.RoleMiddleware(1, 2)).Get("/{id}/refunds", s.IndexOrderRefundsHandler)
+			r.With(middleware2.RoleMiddleware(1, 2)).Post("/{id}/refunds", s.RefundOrderHandler)
+		})
+
+		r.Route("/payments", func(r chi.Router) {
+			r.Post("/webhook", s.PaymentWebhookHandler)
+			r.Post("/{id}/capture", s.CapturePaymentHandler)
+			r.Post("/{id}/void", s.VoidPaymentHandler)
 		})
 
 		r.Route("/items", func(r chi.Router) {
@@ -74,6 +103,112 @@ func (s *Server) RegisterRoutes() http.Handler {
 			r.Get("/{id}", s.GetItemHandler)
 			r.Put("/{id}", s.UpdateItemHandler)
 			r.Delete("/{id}", s.DeleteItemHandler)
+			r.Put("/{id}/categories", s.SetItemCategoriesHandler)
+			r.Get("/{id}/modifier-groups", s.IndexItemModifierGroupsHandler)
+			r.Put("/{id}/modifier-groups", s.SetItemModifierGroupsHandler)
+			r.Get("/{id}/schedules", s.IndexItemSchedulesHandler)
+			r.Put("/{id}/schedules", s.SetItemSchedulesHandler)
+			r.Get("/{id}/variants", s.IndexItemVariantsHandler)
+			r.Post("/{id}/variants", s.CreateItemVariantHandler)
+		})
+
+		r.Route("/variants", func(r chi.Router) {
+			r.Get("/{id}", s.GetItemVariantHandler)
+			r.Put("/{id}", s.UpdateItemVariantHandler)
+			r.Delete("/{id}", s.DeleteItemVariantHandler)
+		})
+
+		r.Route("/categories", func(r chi.Router) {
+			r.Get("/", s.IndexCategoriesHandler)
+			r.Post("/", s.CreateCategoryHandler)
+			r.Get("/{id}", s.GetCategoryHandler)
+			r.Put("/{id}", s.UpdateCategoryHandler)
+			r.Delete("/{id}", s.DeleteCategoryHandler)
+			r.Put("/{id}/items", s.SetCategoryItemsHandler)
+		})
+
+		r.Route("/modifier-groups", func(r chi.Router) {
+			r.Get("/", s.IndexModifierGroupsHandler)
+			r.Post("/", s.CreateModifierGroupHandler)
+			r.Get("/{id}", s.GetModifierGroupHandler)
+			r.Put("/{id}", s.UpdateModifierGroupHandler)
+			r.Delete("/{id}", s.DeleteModifierGroupHandler)
+			r.Post("/{id}/options", s.CreateModifierOptionHandler)
+		})
+
+		r.Route("/modifier-options", func(r chi.Router) {
+			r.Put("/{id}", s.UpdateModifierOptionHandler)
+			r.Delete("/{id}", s.DeleteModifierOptionHandler)
+		})
+
+		r.Route("/tax-classes", func(r chi.Router) {
+			r.Get("/", s.IndexTaxClassesHandler)
+			r.Post("/", s.CreateTaxClassHandler)
+			r.Get("/{id}", s.GetTaxClassHandler)
+			r.Put("/{id}", s.UpdateTaxClassHandler)
+			r.Delete("/{id}", s.DeleteTaxClassHandler)
+		})
+
+		r.Route("/service-charges", func(r chi.Router) {
+			r.Get("/", s.IndexServiceChargeRulesHandler)
+			r.Post("/", s.CreateServiceChargeRuleHandler)
+			r.Get("/{id}", s.GetServiceChargeRuleHandler)
+			r.Put("/{id}", s.UpdateServiceChargeRuleHandler)
+			r.Delete("/{id}", s.DeleteServiceChargeRuleHandler)
+		})
+
+		r.Route("/delivery-zones", func(r chi.Router) {
+			r.Get("/", s.IndexDeliveryZonesHandler)
+			r.Post("/", s.CreateDeliveryZoneHandler)
+			r.Get("/{id}", s.GetDeliveryZoneHandler)
+			r.Put("/{id}", s.UpdateDeliveryZoneHandler)
+			r.Delete("/{id}", s.DeleteDeliveryZoneHandler)
+		})
+
+		r.Route("/reservations", func(r chi.Router) {
+			r.Post("/", s.CreateReservationHandler)
+			r.Get("/{id}", s.GetReservationHandler)
+			r.Post("/{id}/confirm", s.ConfirmReservationHandler)
+			r.Post("/{id}/decline", s.DeclineReservationHandler)
+			r.Post("/{id}/cancel", s.CancelReservationHandler)
+			r.Post("/{id}/seat", s.SeatReservationHandler)
+			r.Post("/{id}/complete", s.CompleteReservationHandler)
+			r.Post("/{id}/no-show", s.NoShowReservationHandler)
+		})
+
+		r.Route("/table-sessions", func(r chi.Router) {
+			r.Post("/", s.SeatPartyHandler)
+			r.Get("/{id}", s.GetTableSessionHandler)
+			r.Post("/{id}/transfer", s.TransferTableSessionHandler)
+			r.Post("/{id}/merge", s.MergeTableSessionHandler)
+			r.Post("/{id}/close", s.CloseTableSessionHandler)
+		})
+
+		r.Route("/service-requests", func(r chi.Router) {
+			r.Post("/", s.CreateServiceRequestHandler)
+			r.Get("/{id}", s.GetServiceRequestHandler)
+			r.Post("/{id}/acknowledge", s.AcknowledgeServiceRequestHandler)
+			r.Post("/{id}/resolve", s.ResolveServiceRequestHandler)
+			r.Post("/{id}/cancel", s.CancelServiceRequestHandler)
+		})
+
+		r.Route("/waitlist", func(r chi.Router) {
+			r.Get("/status/{token}", s.WaitlistStatusHandler)
+			r.Post("/status/{token}/cancel", s.LeaveWaitlistHandler)
+			r.Get("/{id}", s.GetWaitlistEntryHandler)
+			r.Post("/{id}/notify", s.NotifyWaitlistEntryHandler)
+			r.Post("/{id}/seat", s.SeatWaitlistEntryHandler)
+			r.Post("/{id}/cancel", s.CancelWaitlistEntryHandler)
+			r.Post("/{id}/no-show", s.NoShowWaitlistEntryHandler)
+		})
+
+		r.Route("/kitchen-tickets", func(r chi.Router) {
+			r.Get("/{id}", s.GetKitchenTicketHandler)
+			r.Post("/{id}/bump", s.BumpKitchenTicketHandler)
+			r.Post("/{id}/recall", s.RecallKitchenTicketHandler)
+			r.Put("/{id}/priority", s.SetKitchenTicketPriorityHandler)
+			r.Post("/{id}/lines/{lineId}/bump", s.BumpKitchenTicketLineHandler)
+			r.Post("/{id}/lines/{lineId}/recall", s.RecallKitchenTicketLineHandler)
 		})
 
 		r.Route("/cart", func(r chi.Router) {
@@ -81,14 +216,73 @@ func (s *Server) RegisterRoutes() http.Handler {
 			r.Post("/", s.CreateCartHandler)
 			r.Delete("/", s.EmptyCartHandler)
 			r.Post("/checkout", s.CheckoutHandler)
+			r.Post("/coupon", s.ApplyCouponHandler)
+			r.Delete("/coupon", s.RemoveCouponHandler)
+			r.Put("/delivery", s.SetCartDeliveryHandler)
+			r.Delete("/delivery", s.RemoveCartDeliveryHandler)
+			r.Put("/fulfillment", s.SetCartFulfillmentHandler)
+		})
+
+		r.Route("/coupons", func(r chi.Router) {
+			r.Get("/", s.IndexCouponsHandler)
+			r.With(middleware2.RoleMiddleware(1, 2)).Post("/", s.CreateCouponHandler)
+			r.Get("/{id}", s.GetCouponHandler)
+			r.With(middleware2.RoleMiddleware(1, 2)).Put("/{id}", s.UpdateCouponHandler)
+			r.With(middleware2.RoleMiddleware(1, 2)).Delete("/{id}", s.DeleteCouponHandler)
 		})
 
 		r.Route("/vendors", func(r chi.Router) {
 			r.Get("/", s.IndexVendorsHandler)
 			r.Post("/", s.CreateVendorHandler)
+			r.Get("/nearby", s.NearbyVendorsHandler)
 			r.Get("/{id}", s.GetVendorHandler)
 			r.Put("/{id}", s.UpdateVendorHandler)
 			r.Delete("/{id}", s.DeleteVendorHandler)
+			r.Get("/{id}/menu", s.VendorMenuHandler)
+			r.Get("/{id}/delivery-quote", s.DeliveryQuoteHandler)
+			r.Get("/{id}/availability", s.VendorAvailabilityHandler)
+			r.Get("/{id}/reservations", s.IndexVendorReservationsHandler)
+			r.Get("/{id}/table-sessions", s.IndexVendorTableSessionsHandler)
+			r.Get("/{id}/service-requests", s.IndexVendorServiceRequestsHandler)
+			r.Get("/{id}/waitlist", s.IndexVendorWaitlistHandler)
+			r.Post("/{id}/waitlist", s.CreateWaitlistEntryHandler)
+			r.Get("/{id}/waitlist/quote", s.WaitlistQuoteHandler)
+			r.Get("/{id}/floorplan", s.FloorPlanHandler)
+			r.Put("/{id}/floorplan", s.UpdateFloorPlanHandler)
+			r.Get("/{id}/sections", s.IndexTableSectionsHandler)
+			r.Post("/{id}/sections", s.CreateTableSectionHandler)
+			r.Put("/{id}/sections/{sectionId}", s.UpdateTableSectionHandler)
+			r.Delete("/{id}/sections/{sectionId}", s.DeleteTableSectionHandler)
+			r.Get("/{id}/stations", s.IndexKitchenStationsHandler)
+			r.Post("/{id}/stations", s.CreateKitchenStationHandler)
+			r.Put("/{id}/stations/{stationId}", s.UpdateKitchenStationHandler)
+			r.Delete("/{id}/stations/{stationId}", s.DeleteKitchenStationHandler)
+			r.Put("/{id}/stations/{stationId}/routes", s.SetKitchenStationRoutesHandler)
+			r.Get("/{id}/stations/{stationId}/feed", s.KitchenFeedHandler)
+			r.Get("/{id}/receipt-templates", s.IndexReceiptTemplatesHandler)
+			r.Put("/{id}/receipt-templates/{kind}/{format}", s.SaveReceiptTemplateHandler)
+			r.Delete("/{id}/receipt-templates/{kind}/{format}", s.DeleteReceiptTemplateHandler)
+			r.Get("/{id}/webhooks", s.IndexWebhooksHandler)
+			r.Post("/{id}/webhooks", s.CreateWebhookHandler)
+			r.Get("/{id}/webhooks/{webhookId}", s.GetWebhookHandler)
+			r.Put("/{id}/webhooks/{webhookId}", s.UpdateWebhookHandler)
+			r.Delete("/{id}/webhooks/{webhookId}", s.DeleteWebhookHandler)
+			r.Post("/{id}/webhooks/{webhookId}/rotate-secret", s.RotateWebhookSecretHandler)
+			r.Post("/{id}/webhooks/{webhookId}/test", s.TestWebhookHandler)
+			r.Get("/{id}/webhooks/{webhookId}/deliveries", s.IndexWebhookDeliveriesHandler)
+			r.Get("/{id}/webhooks/{webhookId}/deliveries/{deliveryId}", s.GetWebhookDeliveryHandler)
+			r.Post("/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/retry", s.RetryWebhookDeliveryHandler)
+			r.Get("/{id}/hours", s.IndexOpeningHoursHandler)
+			r.Put("/{id}/hours", s.SetOpeningHoursHandler)
+			r.Get("/{id}/hours/overrides", s.IndexHoursOverridesHandler)
+			r.Post("/{id}/hours/overrides", s.CreateHoursOverrideHandler)
+			r.Delete("/{id}/hours/overrides/{overrideId}", s.DeleteHoursOverrideHandler)
+			r.Get("/{id}/reports/sales", s.SalesReportHandler)
+			r.Get("/{id}/reports/turnover", s.TurnoverReportHandler)
+			r.Get("/{id}/reports/service", s.ServiceReportHandler)
+			r.Get("/{id}/inventory/low-stock", s.LowStockHandler)
+			r.Get("/{id}/inventory/adjustments", s.IndexInventoryAdjustmentsHandler)
+			r.Post("/{id}/inventory/adjustments", s.CreateInventoryAdjustmentHandler)
 			r.Get("/", s.IndexVendorAdminsHandler)
 			r.Post("/admin/grant", s.GrantAdminHandler)
 			r.Post("/admin/revoke", s.RevokeAdminHandler)
@@ -351,6 +545,10 @@ func (s *Server) revokeRoleHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) IndexOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	orders, meta, err := s.db.FetchOrders(r.URL.Query())
+	if errors.Is(err, database.ErrInvalidFulfillment) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -375,10 +573,20 @@ func (s *Server) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) UpdateOrderHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	status := r.FormValue("status")
+	order, err := s.db.FetchOrder(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, order.VendorId); !ok {
+		return
+	}
 
-	if err := s.db.UpdateOrderStatus(id, status); err != nil {
+	if err := s.db.UpdateOrderStatus(order.ID.String(), r.FormValue("status")); err != nil {
+		if errors.Is(err, database.ErrInvalidOrderStatus) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -386,11 +594,71 @@ func (s *Server) UpdateOrderHandler(w http.ResponseWriter, r *http.Request) {
 	helpers.WriteJSONResponse(w, http.StatusOK, map[string]string{"message": "Order status updated successfully"})
 }
 
+// accessibleOrder loads the order in the path if the user placed it or
+// administers its vendor.
+func (s *Server) accessibleOrder(w http.ResponseWriter, r *http.Request) (types.Order, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return types.Order{}, false
+	}
+
+	order, err := s.db.FetchOrder(r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.HandleError(w, http.StatusNotFound, "Order not found")
+			return types.Order{}, false
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return types.Order{}, false
+	}
+
+	if order.CustomerId == user.ID {
+		return order, true
+	}
+	if _, ok := s.requireVendorAdmin(w, r, order.VendorId); !ok {
+		return types.Order{}, false
+	}
+	return order, true
+}
+
+func (s *Server) UpdateOrderTipHandler(w http.ResponseWriter, r *http.Request) {
+	amount, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+	if err != nil || amount < 0 {
+		helpers.HandleError(w, http.StatusBadRequest, "Amount must be a non-negative number")
+		return
+	}
+
+	existing, ok := s.accessibleOrder(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := s.db.SetOrderTip(existing.ID.String(), amount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.HandleError(w, http.StatusNotFound, "Order not found")
+			return
+		}
+		if errors.Is(err, database.ErrTipNotAllowed) {
+			helpers.HandleError(w, http.StatusConflict, err.Error())
+			return
+		}
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, order)
+}
+
 ///////////////////
 
 func (s *Server) IndexTablesHandler(w http.ResponseWriter, r *http.Request) {
 	tables, meta, err := s.db.FetchTables(r.URL.Query())
 	if err != nil {
+		if errors.Is(err, database.ErrInvalidLayout) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -454,7 +722,13 @@ func (s *Server) DeleteTableHandler(w http.ResponseWriter, r *http.Request) {
 ///////////
 
 func (s *Server) ListItemsHandler(w http.ResponseWriter, r *http.Request) {
-	items, meta, err := s.db.ListItems(r.URL.Query())
+	query := r.URL.Query()
+	// Customers only see what they can order right now
+	if user, ok := middleware2.GetUser(r); !ok || !(middleware2.HasRole(user, 1) || middleware2.HasRole(user, 2)) {
+		query.Set("available", "true")
+	}
+
+	items, meta, err := s.db.ListItems(query)
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -471,6 +745,10 @@ func (s *Server) CreateItemHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	createdItem, err := s.db.CreateItem(item, r)
+	if errors.Is(err, database.ErrInvalidTaxClass) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -485,6 +763,24 @@ func (s *Server) GetItemHandler(w http.ResponseWriter, r *http.Request) {
 		helpers.HandleError(w, http.StatusNotFound, "Item not found")
 		return
 	}
+
+	item.Schedules, err = s.db.ListItemSchedules(item.ID)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	item.Variants, err = s.db.ListItemVariants(item.ID)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	item.ModifierGroups, err = s.db.GetItemModifierGroups(item.ID, false)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 	helpers.WriteJSONResponse(w, http.StatusOK, item)
 }
 
@@ -498,14 +794,25 @@ func (s *Server) DeleteItemHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) UpdateItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.itemVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
 	var updates map[string]interface{}
 	err := json.NewDecoder(r.Body).Decode(&updates)
 	if err != nil {
 		helpers.HandleError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	// The item stays with the vendor the user administers
+	delete(updates, "vendor_id")
 
-	updatedItem, err := s.db.UpdateItem(r.PathValue("id"), updates, r)
+	updatedItem, err := s.db.UpdateItem(item.ID.String(), updates, r)
+	if errors.Is(err, database.ErrInvalidTaxClass) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -536,25 +843,40 @@ func (s *Server) IndexCartHandler(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) CreateCartHandler(w http.ResponseWriter, r *http.Request) {
 	userID := s.db.GetUserID(r)
-	itemID, quantity, err := s.db.ParseAddCartParams(r)
+	line, err := s.db.ParseAddCartParams(r)
 	if err != nil {
 		helpers.HandleError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	item, err := s.db.GetCartItem(itemID)
+	item, err := s.db.GetCartItem(line.ItemId)
 	if err != nil {
 		helpers.HandleError(w, http.StatusNotFound, "Item does not exist")
 		return
 	}
 
+	if user, ok := middleware2.GetUser(r); ok {
+		if tableID, isGuest := guestTableID(user); isGuest {
+			table, err := s.db.GetTableByID(tableID.String())
+			if err != nil || table.VendorId != item.VendorId {
+				helpers.HandleError(w, http.StatusForbidden, "Guests can only order from the restaurant they are seated at")
+				return
+			}
+		}
+	}
+
 	cart, err := s.db.GetOrCreateCart(userID, item.VendorId)
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, "Failed to process cart")
 		return
 	}
 
-	if err := s.db.UpdateCartItem(cart.ID, itemID, quantity); err != nil {
+	if err := s.db.UpdateCartItem(cart.ID, line); err != nil {
+		if errors.Is(err, database.ErrInvalidModifiers) || errors.Is(err, database.ErrInvalidVariant) ||
+			errors.Is(err, database.ErrItemUnavailable) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		helpers.HandleError(w, http.StatusInternalServerError, "Failed to update cart item")
 		return
 	}
@@ -602,18 +924,87 @@ func (s *Server) CheckoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.db.ProcessCheckout(cart); err != nil {
+	checkout, err := s.db.ParseCheckoutParams(r)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// A guest's order always goes to the table whose code they scanned
+	if user, ok := middleware2.GetUser(r); ok {
+		if tableID, isGuest := guestTableID(user); isGuest {
+			checkout.TableId = &tableID
+		}
+	}
+
+	order, err := s.db.ProcessCheckout(cart, checkout)
+	if err != nil {
+		var stockErr *database.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			helpers.WriteJSONResponse(w, http.StatusConflict, map[string]interface{}{
+				"error":     stockErr.Error(),
+				"shortages": stockErr.Shortages,
+			})
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCoupon) || errors.Is(err, database.ErrCheckoutRejected) ||
+			errors.Is(err, database.ErrDeliveryUnavailable) || errors.Is(err, database.ErrInvalidFulfillment) ||
+			errors.Is(err, database.ErrInvalidModifiers) {
+			helpers.HandleError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		helpers.HandleError(w, http.StatusInternalServerError, "Failed to process checkout")
 		return
 	}
 
-	helpers.WriteJSONResponse(w, http.StatusOK, map[string]string{"message": "Order placed"})
+	if checkout.PaymentMethod == "pay_now" {
+		if _, err := s.chargeOrder(r, order, checkout.PaymentMethod, checkout.PaymentToken, true); err != nil {
+			// The order stays pending so the customer can retry the payment
+			helpers.WriteJSONResponse(w, http.StatusPaymentRequired, map[string]interface{}{
+				"error": "Payment failed: " + err.Error(),
+				"order": order,
+			})
+			return
+		}
+		// The order is placed and paid either way, so a failed reload only
+		// means answering with the order as it was before payment
+		paidOrder, err := s.db.FetchOrder(order.ID.String())
+		if err == nil {
+			err = s.db.AttachOrderItems(&paidOrder)
+		}
+		if err != nil {
+			logger.Log.WithError(err).WithField("order_id", order.ID).Error("Failed to reload paid order")
+		} else {
+			order = paidOrder
+		}
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{"message": "Order placed", "order": order})
 }
 
 /////////////
 
 func (s *Server) IndexVendorsHandler(w http.ResponseWriter, r *http.Request) {
 	vendors, meta, err := s.db.ListVendors(r.URL.Query())
+	if errors.Is(err, database.ErrInvalidLocation) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: vendors})
+}
+
+// NearbyVendorsHandler lists vendors around ?lat=&lng=, optionally within
+// ?radius= km, closest first with each vendor's distance_km.
+func (s *Server) NearbyVendorsHandler(w http.ResponseWriter, r *http.Request) {
+	vendors, meta, err := s.db.NearbyVendors(r.URL.Query())
+	if errors.Is(err, database.ErrInvalidLocation) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -638,6 +1029,10 @@ func (s *Server) CreateVendorHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	createdVendor, err := s.db.CreateVendor(vendor)
+	if errors.Is(err, database.ErrInvalidLocation) || errors.Is(err, database.ErrInvalidVendor) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -653,6 +1048,10 @@ func (s *Server) UpdateVendorHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	updatedVendor, err := s.db.UpdateVendor(vendor, id)
+	if errors.Is(err, database.ErrInvalidLocation) || errors.Is(err, database.ErrInvalidVendor) {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
 		return
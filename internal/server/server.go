@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,20 +11,32 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 
 	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/notify"
+	"restaurant-management-backend/internal/payments"
+	"restaurant-management-backend/internal/realtime"
+	"restaurant-management-backend/internal/webhooks"
 )
 
 type Server struct {
 	port int
 
-	db database.Service
+	db       database.Service
+	payments payments.PaymentProvider
+	notifier notify.Notifier
+	hub      *realtime.Hub
 }
 
 func NewServer() *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	NewServer := &Server{
-		port: port,
-		db:   database.New(),
+		port:     port,
+		db:       database.New(),
+		payments: payments.New(),
+		notifier: notify.New(),
+		hub:      realtime.NewHub(),
 	}
+	go NewServer.hub.Run(context.Background(), NewServer.db.Listen, database.EventsChannel)
+	go webhooks.NewDispatcher(NewServer.db).Run(context.Background())
 
 	// Declare Server config
 	server := &http.Server{
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexServiceChargeRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, meta, err := s.db.ListServiceChargeRules(r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: rules})
+}
+
+func (s *Server) GetServiceChargeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	rule, err := s.db.GetServiceChargeRuleByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Service charge rule not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, rule)
+}
+
+func (s *Server) CreateServiceChargeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule types.ServiceChargeRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, rule.VendorId); !ok {
+		return
+	}
+
+	createdRule, err := s.db.CreateServiceChargeRule(rule)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdRule)
+}
+
+func (s *Server) UpdateServiceChargeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetServiceChargeRuleByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Service charge rule not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	var rule types.ServiceChargeRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedRule, err := s.db.UpdateServiceChargeRule(existing.ID.String(), rule)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedRule)
+}
+
+func (s *Server) DeleteServiceChargeRuleHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetServiceChargeRuleByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Service charge rule not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	if err := s.db.DeleteServiceChargeRule(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Service charge rule deleted successfully")
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+// CreateServiceRequestHandler lets a customer call for staff from a table.
+func (s *Server) CreateServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var request types.ServiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	// A guest can only call for staff at the table whose code they scanned
+	if tableID, isGuest := guestTableID(user); isGuest {
+		request.TableId = tableID
+	}
+	if request.TableId == uuid.Nil {
+		helpers.HandleError(w, http.StatusBadRequest, "table_id is required")
+		return
+	}
+	request.CustomerId = &user.ID
+
+	createdRequest, created, err := s.db.CreateServiceRequest(request)
+	if err != nil {
+		writeServiceRequestError(w, err)
+		return
+	}
+
+	if created {
+		helpers.WriteJSONResponse(w, http.StatusCreated, createdRequest)
+		return
+	}
+	// Someone else at the table already asked, only they get to see the request
+	if createdRequest.CustomerId == nil || *createdRequest.CustomerId != user.ID {
+		helpers.WriteJSONResponse(w, http.StatusOK, map[string]uuid.UUID{"id": createdRequest.ID})
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, createdRequest)
+}
+
+func (s *Server) GetServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	request, _, ok := s.accessibleServiceRequest(w, r)
+	if !ok {
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, request)
+}
+
+// IndexVendorServiceRequestsHandler is the staff queue of requests still
+// waiting to be dealt with, oldest first.
+func (s *Server) IndexVendorServiceRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	requests, meta, err := s.db.ListServiceRequests(vendorID, r.URL.Query())
+	if err != nil {
+		writeServiceRequestError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: requests})
+}
+
+func (s *Server) AcknowledgeServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	s.staffServiceRequestTransition(w, r, "acknowledged")
+}
+
+func (s *Server) ResolveServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	s.staffServiceRequestTransition(w, r, "resolved")
+}
+
+// CancelServiceRequestHandler lets the customer who raised a request, or the
+// vendor's staff, withdraw it.
+func (s *Server) CancelServiceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	request, user, ok := s.accessibleServiceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	updatedRequest, err := s.db.UpdateServiceRequestStatus(request.ID.String(), "cancelled", &user.ID)
+	if err != nil {
+		writeServiceRequestError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedRequest)
+}
+
+func (s *Server) ServiceReportHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	from, to, err := parseReportRange(r)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Dates must be formatted as YYYY-MM-DD")
+		return
+	}
+
+	report, err := s.db.ServiceReport(vendorID.String(), from, to)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, report)
+}
+
+// accessibleServiceRequest loads the request in the path if the user raised
+// it or works for its vendor.
+func (s *Server) accessibleServiceRequest(w http.ResponseWriter, r *http.Request) (*types.ServiceRequest, types.User, bool) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return nil, user, false
+	}
+
+	request, err := s.db.GetServiceRequest(r.PathValue("id"))
+	if err != nil {
+		writeServiceRequestError(w, err)
+		return nil, user, false
+	}
+
+	if request.CustomerId != nil && *request.CustomerId == user.ID {
+		return request, user, true
+	}
+	if _, ok := s.requireVendorAdmin(w, r, request.VendorId); !ok {
+		return nil, user, false
+	}
+	return request, user, true
+}
+
+func (s *Server) staffServiceRequestTransition(w http.ResponseWriter, r *http.Request, status string) {
+	request, err := s.db.GetServiceRequest(r.PathValue("id"))
+	if err != nil {
+		writeServiceRequestError(w, err)
+		return
+	}
+	user, ok := s.requireVendorAdmin(w, r, request.VendorId)
+	if !ok {
+		return
+	}
+
+	updatedRequest, err := s.db.UpdateServiceRequestStatus(request.ID.String(), status, &user.ID)
+	if err != nil {
+		writeServiceRequestError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedRequest)
+}
+
+func writeServiceRequestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrServiceRequestNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Service request not found")
+	case errors.Is(err, database.ErrInvalidServiceRequest):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to process service request")
+	}
+}
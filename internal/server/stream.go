@@ -0,0 +1,228 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/url"
+	"os"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	middleware2 "restaurant-management-backend/internal/middleware"
+	"restaurant-management-backend/internal/types"
+	"strings"
+	"time"
+)
+
+const (
+	maxStreamTopics = 20
+	// streamHeartbeat keeps proxies from closing idle streams.
+	streamHeartbeat   = 25 * time.Second
+	streamWriteWait   = 10 * time.Second
+	websocketPongWait = 2 * streamHeartbeat
+)
+
+var upgrader = websocket.Upgrader{CheckOrigin: checkStreamOrigin}
+
+// StreamHandler pushes real-time events for the topics listed in
+// ?topics=vendor:{id}:orders,order:{id},vendor:{id}:tables. Clients that ask
+// to upgrade get a WebSocket, everyone else server-sent events.
+func (s *Server) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var topics []string
+	for _, value := range r.URL.Query()["topics"] {
+		for _, topic := range strings.Split(value, ",") {
+			if topic = strings.TrimSpace(topic); topic != "" {
+				topics = append(topics, topic)
+			}
+		}
+	}
+	if len(topics) == 0 || len(topics) > maxStreamTopics {
+		helpers.HandleError(w, http.StatusBadRequest, fmt.Sprintf("Between 1 and %d topics are required", maxStreamTopics))
+		return
+	}
+	for _, topic := range topics {
+		if status, message := s.authorizeTopic(user, topic); status != http.StatusOK {
+			helpers.HandleError(w, status, message)
+			return
+		}
+	}
+
+	if websocket.IsWebSocketUpgrade(r) {
+		s.streamWebSocket(w, r, topics)
+		return
+	}
+	s.streamEvents(w, r, topics)
+}
+
+// authorizeTopic checks the user may follow the topic. Vendor topics are for
+// the vendor's staff, an order's topic also for the customer who placed it.
+func (s *Server) authorizeTopic(user types.User, topic string) (int, string) {
+	parts := strings.Split(topic, ":")
+	switch {
+	case len(parts) == 3 && parts[0] == "vendor" && (parts[2] == "orders" || parts[2] == "tables"):
+		vendorID, err := uuid.Parse(parts[1])
+		if err != nil {
+			return http.StatusBadRequest, "Invalid topic " + topic
+		}
+		return s.authorizeVendorStaff(user, vendorID, topic)
+	case len(parts) == 2 && parts[0] == "order":
+		order, err := s.db.FetchOrder(parts[1])
+		if err != nil {
+			return http.StatusNotFound, "Order not found for topic " + topic
+		}
+		if order.CustomerId == user.ID {
+			return http.StatusOK, ""
+		}
+		return s.authorizeVendorStaff(user, order.VendorId, topic)
+	default:
+		return http.StatusBadRequest, "Unknown topic " + topic
+	}
+}
+
+func (s *Server) authorizeVendorStaff(user types.User, vendorID uuid.UUID, topic string) (int, string) {
+	if middleware2.HasRole(user, 1) {
+		return http.StatusOK, ""
+	}
+	isAdmin, err := s.db.IsVendorAdmin(user.ID, vendorID)
+	if err != nil {
+		logger.Log.WithError(err).Error("Failed to check vendor admin")
+		return http.StatusInternalServerError, "Unable to verify permissions"
+	}
+	if !isAdmin {
+		return http.StatusForbidden, "Forbidden: You cannot follow " + topic
+	}
+	return http.StatusOK, ""
+}
+
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, topics []string) {
+	controller := http.NewResponseController(w)
+	// Streams outlive the server's write timeout
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Log.WithError(err).Warn("Unable to lift the write deadline for an event stream")
+	}
+
+	sub := s.hub.Subscribe(topics)
+	defer s.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "event: ready\ndata: %s\n\n", mustJSON(map[string][]string{"topics": topics}))
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, mustJSON(event))
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) streamWebSocket(w http.ResponseWriter, r *http.Request, topics []string) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered the client
+		return
+	}
+	defer conn.Close()
+
+	sub := s.hub.Subscribe(topics)
+	defer s.hub.Unsubscribe(sub)
+
+	// Clients don't send anything but control frames; reading is only how
+	// pongs and the close handshake are noticed
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := writeWebSocketJSON(conn, map[string]interface{}{"type": "ready", "topics": topics}); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				return
+			}
+		case event, ok := <-sub.C:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too far behind"),
+					time.Now().Add(streamWriteWait))
+				return
+			}
+			if err := writeWebSocketJSON(conn, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeWebSocketJSON(conn *websocket.Conn, v interface{}) error {
+	conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	return conn.WriteJSON(v)
+}
+
+// checkStreamOrigin accepts WebSocket clients from the origins listed in
+// STREAM_ALLOWED_ORIGINS ("*" for any), or from the API's own host.
+func checkStreamOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range strings.Split(os.Getenv("STREAM_ALLOWED_ORIGINS"), ",") {
+		if allowed = strings.TrimSpace(allowed); allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
+
+func mustJSON(v interface{}) []byte {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return []byte("{}")
+	}
+	return encoded
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+// SeatPartyHandler opens a table session. Staff seat walk-ins at a table_id,
+// or a reservation_id at the tables it was given.
+func (s *Server) SeatPartyHandler(w http.ResponseWriter, r *http.Request) {
+	var seat types.SeatParty
+	if err := json.NewDecoder(r.Body).Decode(&seat); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	var vendorID uuid.UUID
+	switch {
+	case seat.TableId != nil:
+		table, err := s.db.GetTableByID(seat.TableId.String())
+		if err != nil {
+			helpers.HandleError(w, http.StatusNotFound, "Table not found")
+			return
+		}
+		vendorID = table.VendorId
+	case seat.ReservationId != nil:
+		reservation, err := s.db.GetReservation(seat.ReservationId.String())
+		if err != nil {
+			writeReservationError(w, err)
+			return
+		}
+		vendorID = reservation.VendorId
+	default:
+		helpers.HandleError(w, http.StatusBadRequest, "table_id or reservation_id is required")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	session, err := s.db.SeatParty(seat)
+	if err != nil {
+		writeTableSessionError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusCreated, session)
+}
+
+// GetTableSessionHandler shows the session and its running bill to the
+// vendor's staff and to the customer it was opened for.
+func (s *Server) GetTableSessionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	session, err := s.db.GetTableSession(r.PathValue("id"))
+	if err != nil {
+		writeTableSessionError(w, err)
+		return
+	}
+	if session.CustomerId == nil || *session.CustomerId != user.ID {
+		if _, ok := s.requireVendorAdmin(w, r, session.VendorId); !ok {
+			return
+		}
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, session)
+}
+
+func (s *Server) IndexVendorTableSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	sessions, meta, err := s.db.ListTableSessions(vendorID, r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: sessions})
+}
+
+func (s *Server) TransferTableSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.staffTableSession(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		FromTableId *uuid.UUID `json:"from_table_id"`
+		ToTableId   uuid.UUID  `json:"to_table_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ToTableId == uuid.Nil {
+		helpers.HandleError(w, http.StatusBadRequest, "to_table_id is required")
+		return
+	}
+
+	updatedSession, err := s.db.TransferTableSession(session.ID.String(), body.FromTableId, body.ToTableId)
+	if err != nil {
+		writeTableSessionError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedSession)
+}
+
+func (s *Server) MergeTableSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.staffTableSession(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		TableId uuid.UUID `json:"table_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.TableId == uuid.Nil {
+		helpers.HandleError(w, http.StatusBadRequest, "table_id is required")
+		return
+	}
+
+	updatedSession, err := s.db.MergeTableSession(session.ID.String(), body.TableId)
+	if err != nil {
+		writeTableSessionError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedSession)
+}
+
+// CloseTableSessionHandler closes out the bill. {"force": true} closes it
+// even though orders are still unpaid.
+func (s *Server) CloseTableSessionHandler(w http.ResponseWriter, r *http.Request) {
+	session, ok := s.staffTableSession(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Force bool `json:"force"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	closedSession, err := s.db.CloseTableSession(session.ID.String(), body.Force)
+	if err != nil {
+		writeTableSessionError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, closedSession)
+}
+
+// staffTableSession loads the session in the path for its vendor's admins.
+func (s *Server) staffTableSession(w http.ResponseWriter, r *http.Request) (*types.TableSession, bool) {
+	session, err := s.db.GetTableSession(r.PathValue("id"))
+	if err != nil {
+		writeTableSessionError(w, err)
+		return nil, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, session.VendorId); !ok {
+		return nil, false
+	}
+	return session, true
+}
+
+func writeTableSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrTableSessionNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Table session not found")
+	case errors.Is(err, database.ErrTableOccupied), errors.Is(err, database.ErrBalanceDue):
+		helpers.HandleError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, database.ErrInvalidTableSession):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, database.ErrReservationNotFound), errors.Is(err, database.ErrInvalidReservation):
+		writeReservationError(w, err)
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to update table session")
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexTaxClassesHandler(w http.ResponseWriter, r *http.Request) {
+	taxClasses, meta, err := s.db.ListTaxClasses(r.URL.Query())
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: taxClasses})
+}
+
+func (s *Server) GetTaxClassHandler(w http.ResponseWriter, r *http.Request) {
+	taxClass, err := s.db.GetTaxClassByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Tax class not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, taxClass)
+}
+
+func (s *Server) CreateTaxClassHandler(w http.ResponseWriter, r *http.Request) {
+	var taxClass types.TaxClass
+	if err := json.NewDecoder(r.Body).Decode(&taxClass); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, taxClass.VendorId); !ok {
+		return
+	}
+
+	createdTaxClass, err := s.db.CreateTaxClass(taxClass)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdTaxClass)
+}
+
+func (s *Server) UpdateTaxClassHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetTaxClassByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Tax class not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	var taxClass types.TaxClass
+	if err := json.NewDecoder(r.Body).Decode(&taxClass); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedTaxClass, err := s.db.UpdateTaxClass(existing.ID.String(), taxClass)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedTaxClass)
+}
+
+func (s *Server) DeleteTaxClassHandler(w http.ResponseWriter, r *http.Request) {
+	existing, err := s.db.GetTaxClassByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Tax class not found")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, existing.VendorId); !ok {
+		return
+	}
+
+	if err := s.db.DeleteTaxClass(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Tax class deleted successfully")
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexItemVariantsHandler(w http.ResponseWriter, r *http.Request) {
+	item, err := s.db.GetItemByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Item not found")
+		return
+	}
+
+	variants, err := s.db.ListItemVariants(item.ID)
+	if err != nil {
+		helpers.HandleError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, variants)
+}
+
+func (s *Server) GetItemVariantHandler(w http.ResponseWriter, r *http.Request) {
+	variant, err := s.db.GetItemVariantByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Variant not found")
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, variant)
+}
+
+func (s *Server) CreateItemVariantHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := s.itemVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var variant types.ItemVariant
+	if err := json.NewDecoder(r.Body).Decode(&variant); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	createdVariant, err := s.db.CreateItemVariant(item.ID.String(), variant)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusCreated, createdVariant)
+}
+
+func (s *Server) UpdateItemVariantHandler(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.variantVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var variant types.ItemVariant
+	if err := json.NewDecoder(r.Body).Decode(&variant); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updatedVariant, err := s.db.UpdateItemVariant(existing.ID.String(), variant)
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedVariant)
+}
+
+func (s *Server) DeleteItemVariantHandler(w http.ResponseWriter, r *http.Request) {
+	existing, ok := s.variantVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteItemVariant(existing.ID.String()); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, "Variant deleted successfully")
+}
+
+// variantVendorAdmin loads the variant in the path if the user administers
+// the vendor of its item.
+func (s *Server) variantVendorAdmin(w http.ResponseWriter, r *http.Request) (*types.ItemVariant, bool) {
+	variant, err := s.db.GetItemVariantByID(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Variant not found")
+		return nil, false
+	}
+	item, err := s.db.GetItemByID(variant.ItemId.String())
+	if err != nil {
+		helpers.HandleError(w, http.StatusNotFound, "Item not found")
+		return nil, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, item.VendorId); !ok {
+		return nil, false
+	}
+	return variant, true
+}
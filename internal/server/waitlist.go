@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+)
+
+// WaitlistQuoteHandler tells a party of party_size how long they would wait
+// if they joined the line now.
+func (s *Server) WaitlistQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	partySize, err := strconv.Atoi(r.URL.Query().Get("party_size"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "party_size must be a number")
+		return
+	}
+
+	quote, err := s.db.QuoteWaitlist(vendorID, partySize)
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, quote)
+}
+
+// CreateWaitlistEntryHandler adds a walk-in party to the line. The response
+// carries the token the guest uses to follow their place.
+func (s *Server) CreateWaitlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	var entry types.WaitlistEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	entry.VendorId = vendorID
+
+	created, err := s.db.CreateWaitlistEntry(entry)
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusCreated, created)
+}
+
+func (s *Server) IndexVendorWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid vendor ID")
+		return
+	}
+	if _, ok := s.requireVendorAdmin(w, r, vendorID); !ok {
+		return
+	}
+
+	entries, meta, err := s.db.ListWaitlist(vendorID, r.URL.Query())
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: entries})
+}
+
+func (s *Server) GetWaitlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.staffWaitlistEntry(w, r)
+	if !ok {
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, entry)
+}
+
+// NotifyWaitlistEntryHandler tells the party their table is ready. It can be
+// repeated to remind them.
+func (s *Server) NotifyWaitlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.staffWaitlistEntry(w, r)
+	if !ok {
+		return
+	}
+	if entry.Status != "waiting" && entry.Status != "notified" {
+		helpers.HandleError(w, http.StatusBadRequest, "Only waiting parties can be notified")
+		return
+	}
+
+	if err := s.notifier.WaitlistReady(r.Context(), *entry); err != nil {
+		logger.Log.WithError(err).WithField("notifier", s.notifier.Name()).Error("Failed to notify waitlist party")
+		helpers.HandleError(w, http.StatusBadGateway, "Failed to notify the party")
+		return
+	}
+
+	updatedEntry, err := s.db.UpdateWaitlistStatus(entry.ID.String(), "notified")
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedEntry)
+}
+
+// SeatWaitlistEntryHandler seats the party at {"table_id": ...}, opening a
+// table session for them.
+func (s *Server) SeatWaitlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	entry, ok := s.staffWaitlistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	var seat types.SeatParty
+	if err := json.NewDecoder(r.Body).Decode(&seat); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	seatedEntry, err := s.db.SeatWaitlistEntry(entry.ID.String(), seat)
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, seatedEntry)
+}
+
+func (s *Server) CancelWaitlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	s.staffWaitlistTransition(w, r, "cancelled")
+}
+
+func (s *Server) NoShowWaitlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	s.staffWaitlistTransition(w, r, "no_show")
+}
+
+// WaitlistStatusHandler lets a guest follow their place in line with the
+// token they were given, without signing in.
+func (s *Server) WaitlistStatusHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.db.GetWaitlistEntryByToken(r.PathValue("token"))
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, entry)
+}
+
+// LeaveWaitlistHandler lets a guest give up their place in line.
+func (s *Server) LeaveWaitlistHandler(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.db.GetWaitlistEntryByToken(r.PathValue("token"))
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+
+	updatedEntry, err := s.db.UpdateWaitlistStatus(entry.ID.String(), "cancelled")
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedEntry)
+}
+
+// staffWaitlistEntry loads the entry in the path for its vendor's admins.
+func (s *Server) staffWaitlistEntry(w http.ResponseWriter, r *http.Request) (*types.WaitlistEntry, bool) {
+	entry, err := s.db.GetWaitlistEntry(r.PathValue("id"))
+	if err != nil {
+		writeWaitlistError(w, err)
+		return nil, false
+	}
+	if _, ok := s.requireVendorAdmin(w, r, entry.VendorId); !ok {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *Server) staffWaitlistTransition(w http.ResponseWriter, r *http.Request, status string) {
+	entry, ok := s.staffWaitlistEntry(w, r)
+	if !ok {
+		return
+	}
+
+	updatedEntry, err := s.db.UpdateWaitlistStatus(entry.ID.String(), status)
+	if err != nil {
+		writeWaitlistError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updatedEntry)
+}
+
+func writeWaitlistError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrWaitlistEntryNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Waitlist entry not found")
+	case errors.Is(err, database.ErrInvalidWaitlistEntry):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, database.ErrTableOccupied), errors.Is(err, database.ErrInvalidTableSession):
+		writeTableSessionError(w, err)
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to update waitlist")
+	}
+}
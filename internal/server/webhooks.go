@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"restaurant-management-backend/internal/database"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/types"
+)
+
+func (s *Server) IndexWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	endpoints, err := s.db.ListWebhookEndpoints(vendorID)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, endpoints)
+}
+
+// CreateWebhookHandler registers {"url": ..., "event_types": [...]}. The
+// response carries the signing secret, which is not shown again.
+func (s *Server) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var endpoint types.WebhookEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	created, err := s.db.CreateWebhookEndpoint(vendorID, endpoint)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusCreated, created)
+}
+
+func (s *Server) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, err := s.db.GetWebhookEndpoint(vendorID, r.PathValue("webhookId"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, endpoint)
+}
+
+func (s *Server) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	var endpoint types.WebhookEndpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		helpers.HandleError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	updated, err := s.db.UpdateWebhookEndpoint(vendorID, r.PathValue("webhookId"), endpoint)
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, updated)
+}
+
+func (s *Server) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.db.DeleteWebhookEndpoint(vendorID, r.PathValue("webhookId")); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) RotateWebhookSecretHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	endpoint, err := s.db.RotateWebhookSecret(vendorID, r.PathValue("webhookId"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, endpoint)
+}
+
+// TestWebhookHandler queues a webhook.test event for the endpoint. The
+// outcome shows up in its delivery log.
+func (s *Server) TestWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.db.SendTestWebhook(vendorID, r.PathValue("webhookId"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusAccepted, delivery)
+}
+
+func (s *Server) IndexWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, meta, err := s.db.ListWebhookDeliveries(vendorID, r.PathValue("webhookId"), r.URL.Query())
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, types.Response{Meta: meta, Data: deliveries})
+}
+
+func (s *Server) GetWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.db.GetWebhookDelivery(vendorID, r.PathValue("webhookId"), r.PathValue("deliveryId"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusOK, delivery)
+}
+
+// RetryWebhookDeliveryHandler sends a delivery again, dead-lettered ones
+// included.
+func (s *Server) RetryWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	vendorID, ok := s.pathVendorAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := s.db.RetryWebhookDelivery(vendorID, r.PathValue("webhookId"), r.PathValue("deliveryId"))
+	if err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	helpers.WriteJSONResponse(w, http.StatusAccepted, delivery)
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, database.ErrWebhookEndpointNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Webhook not found")
+	case errors.Is(err, database.ErrWebhookDeliveryNotFound):
+		helpers.HandleError(w, http.StatusNotFound, "Webhook delivery not found")
+	case errors.Is(err, database.ErrInvalidWebhookEndpoint), errors.Is(err, database.ErrInvalidWebhookDelivery):
+		helpers.HandleError(w, http.StatusBadRequest, err.Error())
+	default:
+		helpers.HandleError(w, http.StatusInternalServerError, "Failed to update webhooks")
+	}
+}
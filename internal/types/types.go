@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
@@ -15,15 +16,64 @@ type User struct {
 	Created_at string    `db:"created_at" json:"created_at,omitempty"`
 	Updated_at string    `db:"updated_at" json:"updated_at,omitempty"`
 	Roles      []int     `db:"roles" json:"roles,omitempty"`
+
+	IsGuest           bool       `db:"is_guest"            json:"is_guest,omitempty"`
+	GuestTableId      *uuid.UUID `db:"guest_table_id"      json:"guest_table_id,omitempty"`
+	GuestTokenVersion *int       `db:"guest_token_version" json:"-"`
 }
 
 type Vendor struct {
-	ID          uuid.UUID `db:"id"          json:"id,omitempty"`
-	Name        string    `db:"name"        json:"name,omitempty"`
-	Img         *string   `db:"img"         json:"img,omitempty"`
-	Description string    `db:"description" json:"description,omitempty"`
-	Created_at  time.Time `db:"created_at"  json:"created_at,omitempty"`
-	Updated_at  time.Time `db:"updated_at"  json:"updated_at,omitempty"`
+	ID               uuid.UUID `db:"id"          json:"id,omitempty"`
+	Name             string    `db:"name"        json:"name,omitempty"`
+	Img              *string   `db:"img"         json:"img,omitempty"`
+	Description      string    `db:"description" json:"description,omitempty"`
+	PricesIncludeTax *bool     `db:"prices_include_tax" json:"prices_include_tax"`
+	Timezone         string    `db:"timezone"    json:"timezone,omitempty"`
+	Created_at       time.Time `db:"created_at"  json:"created_at,omitempty"`
+	Updated_at       time.Time `db:"updated_at"  json:"updated_at,omitempty"`
+
+	DineInEnabled     *bool `db:"dine_in_enabled"     json:"dine_in_enabled,omitempty"`
+	PickupEnabled     *bool `db:"pickup_enabled"      json:"pickup_enabled,omitempty"`
+	DeliveryEnabled   *bool `db:"delivery_enabled"    json:"delivery_enabled,omitempty"`
+	PickupLeadMinutes *int  `db:"pickup_lead_minutes" json:"pickup_lead_minutes,omitempty"`
+
+	ReservationTurnMinutes     *int `db:"reservation_turn_minutes"     json:"reservation_turn_minutes,omitempty"`
+	ReservationIntervalMinutes *int `db:"reservation_interval_minutes" json:"reservation_interval_minutes,omitempty"`
+
+	AddressLine1 *string  `db:"address_line1" json:"address_line1,omitempty"`
+	AddressLine2 *string  `db:"address_line2" json:"address_line2,omitempty"`
+	City         *string  `db:"city"          json:"city,omitempty"`
+	PostalCode   *string  `db:"postal_code"   json:"postal_code,omitempty"`
+	Country      *string  `db:"country"       json:"country,omitempty"`
+	Latitude     *float64 `db:"latitude"      json:"latitude,omitempty"`
+	Longitude    *float64 `db:"longitude"     json:"longitude,omitempty"`
+	DistanceKm   *float64 `db:"distance_km"   json:"distance_km,omitempty"`
+
+	IsOpenNow   bool       `db:"-" json:"is_open_now"`
+	NextOpening *time.Time `db:"-" json:"next_opening,omitempty"`
+}
+
+// OpeningHours is a weekly window in the vendor's timezone. DayOfWeek runs
+// from 0 (Sunday) to 6 and times are formatted as HH:MM.
+type OpeningHours struct {
+	ID        uuid.UUID `db:"id"          json:"id,omitempty"`
+	VendorId  uuid.UUID `db:"vendor_id"   json:"vendor_id,omitempty"`
+	DayOfWeek int       `db:"day_of_week" json:"day_of_week"`
+	OpenTime  string    `db:"open_time"   json:"open_time"`
+	CloseTime string    `db:"close_time"  json:"close_time"`
+}
+
+// HoursOverride closes a vendor, or sets special hours, for a range of dates
+// formatted as YYYY-MM-DD.
+type HoursOverride struct {
+	ID         uuid.UUID `db:"id"         json:"id,omitempty"`
+	VendorId   uuid.UUID `db:"vendor_id"  json:"vendor_id,omitempty"`
+	StartsOn   string    `db:"starts_on"  json:"starts_on"`
+	EndsOn     string    `db:"ends_on"    json:"ends_on"`
+	OpenTime   *string   `db:"open_time"  json:"open_time,omitempty"`
+	CloseTime  *string   `db:"close_time" json:"close_time,omitempty"`
+	Note       *string   `db:"note"       json:"note,omitempty"`
+	Created_at time.Time `db:"created_at" json:"created_at,omitempty"`
 }
 
 type Role struct {
@@ -32,50 +82,518 @@ type Role struct {
 }
 
 type Item struct {
+	ID         uuid.UUID  `db:"id"          json:"id,omitempty"`
+	VendorId   uuid.UUID  `db:"vendor_id"   json:"vendor_id,omitempty"`
+	Name       string     `db:"name"        json:"name,omitempty"`
+	Price      float64    `db:"price"       json:"price,omitempty"`
+	Img        *string    `db:"img"         json:"img,omitempty"`
+	TaxClassId *uuid.UUID `db:"tax_class_id" json:"tax_class_id,omitempty"`
+	Created_at time.Time  `db:"created_at"  json:"created_at,omitempty"`
+	Updated_at time.Time  `db:"updated_at"  json:"updated_at,omitempty"`
+
+	StockQuantity     *int `db:"stock_quantity"      json:"stock_quantity,omitempty"`
+	LowStockThreshold *int `db:"low_stock_threshold" json:"low_stock_threshold,omitempty"`
+	IsAvailable       bool `db:"is_available"        json:"is_available"`
+
+	Schedules      []AvailabilitySchedule `db:"-" json:"schedules,omitempty"`
+	Variants       []ItemVariant          `db:"-" json:"variants,omitempty"`
+	ModifierGroups []ModifierGroup        `db:"-" json:"modifier_groups,omitempty"`
+}
+
+// AvailabilitySchedule is a weekly window, in the vendor's timezone, during
+// which an item can be ordered. DayOfWeek runs from 0 (Sunday) to 6 and the
+// times are formatted as HH:MM.
+type AvailabilitySchedule struct {
+	ID        uuid.UUID `db:"id"          json:"id,omitempty"`
+	ItemId    uuid.UUID `db:"item_id"     json:"item_id,omitempty"`
+	DayOfWeek int       `db:"day_of_week" json:"day_of_week"`
+	StartTime string    `db:"start_time"  json:"start_time"`
+	EndTime   string    `db:"end_time"    json:"end_time"`
+}
+
+type ItemVariant struct {
+	ID           uuid.UUID `db:"id"            json:"id,omitempty"`
+	ItemId       uuid.UUID `db:"item_id"       json:"item_id,omitempty"`
+	Name         string    `db:"name"          json:"name,omitempty"`
+	Sku          *string   `db:"sku"           json:"sku,omitempty"`
+	Price        float64   `db:"price"         json:"price,omitempty"`
+	IsDefault    bool      `db:"is_default"    json:"is_default"`
+	IsAvailable  *bool     `db:"is_available"  json:"is_available"`
+	DisplayOrder *int      `db:"display_order" json:"display_order"`
+	Created_at   time.Time `db:"created_at"    json:"created_at,omitempty"`
+	Updated_at   time.Time `db:"updated_at"    json:"updated_at,omitempty"`
+
+	StockQuantity     *int `db:"stock_quantity"      json:"stock_quantity,omitempty"`
+	LowStockThreshold *int `db:"low_stock_threshold" json:"low_stock_threshold,omitempty"`
+}
+
+type InventoryAdjustment struct {
+	ID             uuid.UUID  `db:"id"              json:"id,omitempty"`
+	VendorId       uuid.UUID  `db:"vendor_id"       json:"vendor_id,omitempty"`
+	ItemId         uuid.UUID  `db:"item_id"         json:"item_id,omitempty"`
+	VariantId      *uuid.UUID `db:"variant_id"      json:"variant_id,omitempty"`
+	Reason         string     `db:"reason"          json:"reason,omitempty"`
+	QuantityChange int        `db:"quantity_change" json:"quantity_change"`
+	QuantityAfter  int        `db:"quantity_after"  json:"quantity_after"`
+	OrderId        *uuid.UUID `db:"order_id"        json:"order_id,omitempty"`
+	UserId         *uuid.UUID `db:"user_id"         json:"user_id,omitempty"`
+	Note           *string    `db:"note"            json:"note,omitempty"`
+	Created_at     time.Time  `db:"created_at"      json:"created_at,omitempty"`
+}
+
+// StockAdjustmentRequest records stock coming in, going to waste or a fresh
+// count. Quantity is the amount received or wasted, or the counted level.
+type StockAdjustmentRequest struct {
+	ItemId    uuid.UUID  `json:"item_id"`
+	VariantId *uuid.UUID `json:"variant_id,omitempty"`
+	Reason    string     `json:"reason"`
+	Quantity  int        `json:"quantity"`
+	Note      *string    `json:"note,omitempty"`
+}
+
+type StockLevel struct {
+	ItemId            uuid.UUID  `db:"item_id"             json:"item_id"`
+	VariantId         *uuid.UUID `db:"variant_id"          json:"variant_id,omitempty"`
+	ItemName          string     `db:"item_name"           json:"item_name"`
+	VariantName       *string    `db:"variant_name"        json:"variant_name,omitempty"`
+	StockQuantity     int        `db:"stock_quantity"      json:"stock_quantity"`
+	LowStockThreshold int        `db:"low_stock_threshold" json:"low_stock_threshold"`
+}
+
+type StockShortage struct {
+	ItemId    uuid.UUID  `json:"item_id"`
+	VariantId *uuid.UUID `json:"variant_id,omitempty"`
+	Name      string     `json:"name"`
+	Requested int        `json:"requested"`
+	Available int        `json:"available"`
+}
+
+type ModifierGroup struct {
+	ID            uuid.UUID        `db:"id"             json:"id,omitempty"`
+	VendorId      uuid.UUID        `db:"vendor_id"      json:"vendor_id,omitempty"`
+	Name          string           `db:"name"           json:"name,omitempty"`
+	SelectionType string           `db:"selection_type" json:"selection_type,omitempty"`
+	MinSelections *int             `db:"min_selections" json:"min_selections"`
+	MaxSelections *int             `db:"max_selections" json:"max_selections,omitempty"`
+	IsRequired    *bool            `db:"is_required"    json:"is_required"`
+	Created_at    time.Time        `db:"created_at"     json:"created_at,omitempty"`
+	Updated_at    time.Time        `db:"updated_at"     json:"updated_at,omitempty"`
+	Options       []ModifierOption `db:"-"              json:"options,omitempty"`
+}
+
+type ModifierOption struct {
+	ID           uuid.UUID `db:"id"            json:"id,omitempty"`
+	GroupId      uuid.UUID `db:"group_id"      json:"group_id,omitempty"`
+	Name         string    `db:"name"          json:"name,omitempty"`
+	PriceDelta   *float64  `db:"price_delta"   json:"price_delta"`
+	DisplayOrder *int      `db:"display_order" json:"display_order"`
+	IsActive     *bool     `db:"is_active"     json:"is_active"`
+	Created_at   time.Time `db:"created_at"    json:"created_at,omitempty"`
+	Updated_at   time.Time `db:"updated_at"    json:"updated_at,omitempty"`
+}
+
+type Category struct {
+	ID           uuid.UUID  `db:"id"            json:"id,omitempty"`
+	VendorId     uuid.UUID  `db:"vendor_id"     json:"vendor_id,omitempty"`
+	ParentId     *uuid.UUID `db:"parent_id"     json:"parent_id,omitempty"`
+	Name         string     `db:"name"          json:"name,omitempty"`
+	Description  *string    `db:"description"   json:"description,omitempty"`
+	DisplayOrder *int       `db:"display_order" json:"display_order"`
+	IsActive     *bool      `db:"is_active"     json:"is_active"`
+	Created_at   time.Time  `db:"created_at"    json:"created_at,omitempty"`
+	Updated_at   time.Time  `db:"updated_at"    json:"updated_at,omitempty"`
+	Children     []Category `db:"-"             json:"children,omitempty"`
+	Items        []Item     `db:"-"             json:"items,omitempty"`
+}
+
+type Menu struct {
+	Vendor        *Vendor    `json:"vendor"`
+	Categories    []Category `json:"categories"`
+	Uncategorized []Item     `json:"uncategorized,omitempty"`
+}
+
+type Order struct {
+	ID                   uuid.UUID         `db:"id"          json:"id,omitempty"`
+	Subtotal             float64           `db:"subtotal"    json:"subtotal"`
+	DiscountTotal        float64           `db:"discount_total" json:"discount_total"`
+	TaxTotal             float64           `db:"tax_total"   json:"tax_total"`
+	ServiceChargeTotal   float64           `db:"service_charge_total" json:"service_charge_total"`
+	TipTotal             float64           `db:"tip_total"   json:"tip_total"`
+	TotalOrderCost       float64           `db:"total_order_cost" json:"total_order_cost,omitempty"`
+	RefundedTotal        float64           `db:"refunded_total" json:"refunded_total"`
+	NetTotal             float64           `db:"net_total"   json:"net_total"`
+	VendorId             uuid.UUID         `db:"vendor_id"   json:"vendor_id,omitempty"`
+	CustomerId           uuid.UUID         `db:"customer_id"   json:"customer_id,omitempty"`
+	TableId              *uuid.UUID        `db:"table_id"    json:"table_id,omitempty"`
+	PartySize            *int              `db:"party_size"  json:"party_size,omitempty"`
+	TableSessionId       *uuid.UUID        `db:"table_session_id" json:"table_session_id,omitempty"`
+	Status               string            `db:"status"        json:"status,omitempty"`
+	PaymentStatus        string            `db:"payment_status" json:"payment_status,omitempty"`
+	FulfillmentType      string            `db:"fulfillment_type" json:"fulfillment_type,omitempty"`
+	PickupAt             *time.Time        `db:"pickup_at"   json:"pickup_at,omitempty"`
+	DeliveryAddress      *string           `db:"delivery_address" json:"delivery_address,omitempty"`
+	DeliveryLatitude     *float64          `db:"delivery_latitude" json:"delivery_latitude,omitempty"`
+	DeliveryLongitude    *float64          `db:"delivery_longitude" json:"delivery_longitude,omitempty"`
+	DeliveryZoneId       *uuid.UUID        `db:"delivery_zone_id" json:"delivery_zone_id,omitempty"`
+	DeliveryDistanceKm   *float64          `db:"delivery_distance_km" json:"delivery_distance_km,omitempty"`
+	DeliveryFee          float64           `db:"delivery_fee" json:"delivery_fee"`
+	DeliveryAddressId    *uuid.UUID        `db:"delivery_address_id" json:"delivery_address_id,omitempty"`
+	DeliveryInstructions *string           `db:"delivery_instructions" json:"delivery_instructions,omitempty"`
+	Created_at           time.Time         `db:"created_at"  json:"created_at,omitempty"`
+	Updated_at           time.Time         `db:"updated_at"  json:"updated_at,omitempty"`
+	OrderItems           []OrderItems      `db:"-" json:"order_items,omitempty"`
+	Discounts            []OrderDiscount   `db:"-" json:"discounts,omitempty"`
+	Adjustments          []OrderAdjustment `db:"-" json:"adjustments,omitempty"`
+	Payments             []Payment         `db:"-" json:"payments,omitempty"`
+	Refunds              []Refund          `db:"-" json:"refunds,omitempty"`
+}
+
+type Refund struct {
+	ID            uuid.UUID    `db:"id"             json:"id,omitempty"`
+	OrderId       uuid.UUID    `db:"order_id"       json:"order_id,omitempty"`
+	Amount        float64      `db:"amount"         json:"amount"`
+	Reason        string       `db:"reason"         json:"reason,omitempty"`
+	Status        string       `db:"status"         json:"status,omitempty"`
+	ApprovedBy    *uuid.UUID   `db:"approved_by"    json:"approved_by,omitempty"`
+	FailureReason *string      `db:"failure_reason" json:"failure_reason,omitempty"`
+	Created_at    time.Time    `db:"created_at"     json:"created_at,omitempty"`
+	Updated_at    time.Time    `db:"updated_at"     json:"updated_at,omitempty"`
+	Items         []RefundItem `db:"-"              json:"items,omitempty"`
+}
+
+type RefundItem struct {
+	ID          uuid.UUID `db:"id"            json:"id,omitempty"`
+	RefundId    uuid.UUID `db:"refund_id"     json:"refund_id,omitempty"`
+	OrderItemId uuid.UUID `db:"order_item_id" json:"order_item_id,omitempty"`
+	Quantity    int       `db:"quantity"      json:"quantity"`
+	Amount      float64   `db:"amount"        json:"amount"`
+}
+
+type RefundRequest struct {
+	Reason string       `json:"reason"`
+	Items  []RefundLine `json:"items,omitempty"`
+}
+
+type RefundLine struct {
+	OrderItemId uuid.UUID `json:"order_item_id"`
+	Quantity    int       `json:"quantity"`
+}
+
+type Payment struct {
+	ID             uuid.UUID `db:"id"              json:"id,omitempty"`
+	OrderId        uuid.UUID `db:"order_id"        json:"order_id,omitempty"`
+	Provider       string    `db:"provider"        json:"provider,omitempty"`
+	ProviderRef    *string   `db:"provider_ref"    json:"provider_ref,omitempty"`
+	Method         string    `db:"method"          json:"method,omitempty"`
+	Status         string    `db:"status"          json:"status,omitempty"`
+	Amount         float64   `db:"amount"          json:"amount"`
+	CapturedAmount float64   `db:"captured_amount" json:"captured_amount"`
+	RefundedAmount float64   `db:"refunded_amount" json:"refunded_amount"`
+	FailureReason  *string   `db:"failure_reason"  json:"failure_reason,omitempty"`
+	Created_at     time.Time `db:"created_at"      json:"created_at,omitempty"`
+	Updated_at     time.Time `db:"updated_at"      json:"updated_at,omitempty"`
+}
+
+type PaymentTransaction struct {
+	ID          uuid.UUID `db:"id"           json:"id,omitempty"`
+	PaymentId   uuid.UUID `db:"payment_id"   json:"payment_id,omitempty"`
+	Type        string    `db:"type"         json:"type,omitempty"`
+	Amount      float64   `db:"amount"       json:"amount"`
+	Status      string    `db:"status"       json:"status,omitempty"`
+	ProviderRef *string   `db:"provider_ref" json:"provider_ref,omitempty"`
+	EventId     *string   `db:"event_id"     json:"event_id,omitempty"`
+	Payload     []byte    `db:"payload"      json:"-"`
+	Created_at  time.Time `db:"created_at"   json:"created_at,omitempty"`
+}
+
+type OrderAdjustment struct {
+	ID                  uuid.UUID  `db:"id"                     json:"id,omitempty"`
+	OrderId             uuid.UUID  `db:"order_id"               json:"order_id,omitempty"`
+	Type                string     `db:"type"                   json:"type,omitempty"`
+	ServiceChargeRuleId *uuid.UUID `db:"service_charge_rule_id" json:"service_charge_rule_id,omitempty"`
+	Name                string     `db:"name"                   json:"name,omitempty"`
+	Rate                *float64   `db:"rate"                   json:"rate,omitempty"`
+	Amount              float64    `db:"amount"                 json:"amount"`
+	Created_at          time.Time  `db:"created_at"             json:"created_at,omitempty"`
+}
+
+type ServiceChargeRule struct {
+	ID           uuid.UUID `db:"id"             json:"id,omitempty"`
+	VendorId     uuid.UUID `db:"vendor_id"      json:"vendor_id,omitempty"`
+	Name         string    `db:"name"           json:"name,omitempty"`
+	Rate         float64   `db:"rate"           json:"rate"`
+	MinPartySize int       `db:"min_party_size" json:"min_party_size"`
+	IsActive     *bool     `db:"is_active"      json:"is_active"`
+	Created_at   time.Time `db:"created_at"     json:"created_at,omitempty"`
+	Updated_at   time.Time `db:"updated_at"     json:"updated_at,omitempty"`
+}
+
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// DeliveryZone is an area a vendor delivers to, either a radius around the
+// vendor or a polygon. PolygonJSON is the stored form of Polygon.
+type DeliveryZone struct {
+	ID           uuid.UUID         `db:"id"            json:"id,omitempty"`
+	VendorId     uuid.UUID         `db:"vendor_id"     json:"vendor_id,omitempty"`
+	Name         string            `db:"name"          json:"name,omitempty"`
+	ZoneType     string            `db:"zone_type"     json:"zone_type,omitempty"`
+	RadiusKm     *float64          `db:"radius_km"     json:"radius_km,omitempty"`
+	PolygonJSON  []byte            `db:"polygon"       json:"-"`
+	Polygon      []LatLng          `db:"-"             json:"polygon,omitempty"`
+	DisplayOrder *int              `db:"display_order" json:"display_order"`
+	IsActive     *bool             `db:"is_active"     json:"is_active"`
+	Created_at   time.Time         `db:"created_at"    json:"created_at,omitempty"`
+	Updated_at   time.Time         `db:"updated_at"    json:"updated_at,omitempty"`
+	FeeTiers     []DeliveryFeeTier `db:"-"             json:"fee_tiers"`
+}
+
+// DeliveryFeeTier applies up to MaxDistanceKm (any distance when nil) to
+// orders of at least MinOrderAmount.
+type DeliveryFeeTier struct {
+	ID             uuid.UUID `db:"id"               json:"id,omitempty"`
+	ZoneId         uuid.UUID `db:"zone_id"          json:"zone_id,omitempty"`
+	MaxDistanceKm  *float64  `db:"max_distance_km"  json:"max_distance_km,omitempty"`
+	MinOrderAmount float64   `db:"min_order_amount" json:"min_order_amount"`
+	Fee            float64   `db:"fee"              json:"fee"`
+}
+
+type UserAddress struct {
+	ID                   uuid.UUID `db:"id"                    json:"id,omitempty"`
+	UserId               uuid.UUID `db:"user_id"               json:"user_id,omitempty"`
+	Label                *string   `db:"label"                 json:"label,omitempty"`
+	AddressLine1         string    `db:"address_line1"         json:"address_line1,omitempty"`
+	AddressLine2         *string   `db:"address_line2"         json:"address_line2,omitempty"`
+	City                 *string   `db:"city"                  json:"city,omitempty"`
+	PostalCode           *string   `db:"postal_code"           json:"postal_code,omitempty"`
+	Country              *string   `db:"country"               json:"country,omitempty"`
+	Latitude             *float64  `db:"latitude"              json:"latitude,omitempty"`
+	Longitude            *float64  `db:"longitude"             json:"longitude,omitempty"`
+	DeliveryInstructions *string   `db:"delivery_instructions" json:"delivery_instructions,omitempty"`
+	IsDefault            bool      `db:"is_default"            json:"is_default"`
+	Created_at           time.Time `db:"created_at"            json:"created_at,omitempty"`
+	Updated_at           time.Time `db:"updated_at"            json:"updated_at,omitempty"`
+}
+
+// DeliveryDestination is where a cart is delivered to: one of the user's
+// saved addresses, their default address, or a one-off address.
+type DeliveryDestination struct {
+	AddressId    *uuid.UUID
+	Address      string
+	Point        LatLng
+	Instructions *string
+}
+
+type DeliveryQuote struct {
+	ZoneId     uuid.UUID `json:"zone_id"`
+	ZoneName   string    `json:"zone_name"`
+	DistanceKm float64   `json:"distance_km"`
+	Fee        float64   `json:"fee"`
+}
+
+// Fulfillment is how an order reaches the customer: dine_in at a table,
+// pickup at a given time (as soon as possible when nil) or delivery to the
+// cart's delivery address.
+type Fulfillment struct {
+	Type     string     `json:"fulfillment_type"`
+	TableId  *uuid.UUID `json:"table_id,omitempty"`
+	PickupAt *time.Time `json:"pickup_at,omitempty"`
+}
+
+type Checkout struct {
+	TableId       *uuid.UUID `json:"table_id,omitempty"`
+	PartySize     int        `json:"party_size,omitempty"`
+	Tip           float64    `json:"tip,omitempty"`
+	PaymentMethod string     `json:"payment_method,omitempty"`
+	PaymentToken  string     `json:"-"`
+}
+
+type SalesReport struct {
+	VendorId           uuid.UUID `db:"vendor_id"            json:"vendor_id"`
+	From               time.Time `db:"-"                    json:"from"`
+	To                 time.Time `db:"-"                    json:"to"`
+	OrderCount         int       `db:"order_count"          json:"order_count"`
+	Subtotal           float64   `db:"subtotal"             json:"subtotal"`
+	DiscountTotal      float64   `db:"discount_total"       json:"discount_total"`
+	TaxTotal           float64   `db:"tax_total"            json:"tax_total"`
+	ServiceChargeTotal float64   `db:"service_charge_total" json:"service_charge_total"`
+	TipTotal           float64   `db:"tip_total"            json:"tip_total"`
+	DeliveryFeeTotal   float64   `db:"delivery_fee_total"   json:"delivery_fee_total"`
+	Total              float64   `db:"total"                json:"total"`
+	RefundTotal        float64   `db:"refund_total"         json:"refund_total"`
+	NetTotal           float64   `db:"-"                    json:"net_total"`
+}
+
+// TurnoverReport describes how long parties held tables over a period.
+type TurnoverReport struct {
+	VendorId       uuid.UUID       `db:"vendor_id"       json:"vendor_id"`
+	From           time.Time       `db:"-"               json:"from"`
+	To             time.Time       `db:"-"               json:"to"`
+	SessionCount   int             `db:"session_count"   json:"session_count"`
+	CoverCount     int             `db:"cover_count"     json:"cover_count"`
+	AverageMinutes float64         `db:"average_minutes" json:"average_minutes"`
+	MinMinutes     float64         `db:"min_minutes"     json:"min_minutes"`
+	MaxMinutes     float64         `db:"max_minutes"     json:"max_minutes"`
+	Tables         []TableTurnover `db:"-"               json:"tables"`
+}
+
+type TableTurnover struct {
+	TableId         uuid.UUID `db:"table_id"         json:"table_id"`
+	TableName       string    `db:"table_name"       json:"table_name"`
+	SessionCount    int       `db:"session_count"    json:"session_count"`
+	AverageMinutes  float64   `db:"average_minutes"  json:"average_minutes"`
+	OccupiedMinutes float64   `db:"occupied_minutes" json:"occupied_minutes"`
+}
+
+// ServiceReport describes how quickly staff answered service requests.
+type ServiceReport struct {
+	VendorId uuid.UUID           `json:"vendor_id"`
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Types    []ServiceTypeReport `json:"types"`
+}
+
+type ServiceTypeReport struct {
+	Type                      string  `db:"type"                        json:"type"`
+	RequestCount              int     `db:"request_count"               json:"request_count"`
+	ResolvedCount             int     `db:"resolved_count"              json:"resolved_count"`
+	AverageAcknowledgeSeconds float64 `db:"average_acknowledge_seconds" json:"average_acknowledge_seconds"`
+	AverageResolveSeconds     float64 `db:"average_resolve_seconds"     json:"average_resolve_seconds"`
+}
+
+type OrderItems struct {
+	ID               uuid.UUID           `db:"id"          json:"id,omitempty"`
+	OrderId          uuid.UUID           `db:"order_id"     json:"order_id,omitempty"`
+	Quantity         int                 `db:"quantity"    json:"quantity,omitempty"`
+	Price            float64             `db:"price"       json:"price,omitempty"`
+	DiscountAmount   float64             `db:"discount_amount" json:"discount_amount"`
+	TaxAmount        float64             `db:"tax_amount"  json:"tax_amount"`
+	Total            float64             `db:"total"       json:"total"`
+	RefundedQuantity int                 `db:"refunded_quantity" json:"refunded_quantity"`
+	ItemId           uuid.UUID           `db:"item_id"     json:"item_id,omitempty"`
+	VariantId        *uuid.UUID          `db:"variant_id"  json:"variant_id,omitempty"`
+	VariantName      *string             `db:"variant_name" json:"variant_name,omitempty"`
+	Sku              *string             `db:"sku"         json:"sku,omitempty"`
+	Taxes            []OrderItemTax      `db:"-" json:"taxes,omitempty"`
+	Modifiers        []OrderItemModifier `db:"-" json:"modifiers,omitempty"`
+}
+
+type OrderItemModifier struct {
+	ID               uuid.UUID  `db:"id"                 json:"id,omitempty"`
+	OrderItemId      uuid.UUID  `db:"order_item_id"      json:"order_item_id,omitempty"`
+	ModifierOptionId *uuid.UUID `db:"modifier_option_id" json:"modifier_option_id,omitempty"`
+	GroupName        string     `db:"group_name"         json:"group_name,omitempty"`
+	Name             string     `db:"name"               json:"name,omitempty"`
+	PriceDelta       float64    `db:"price_delta"        json:"price_delta"`
+}
+
+type OrderItemTax struct {
+	ID            uuid.UUID  `db:"id"             json:"id,omitempty"`
+	OrderItemId   uuid.UUID  `db:"order_item_id"  json:"order_item_id,omitempty"`
+	TaxClassId    *uuid.UUID `db:"tax_class_id"   json:"tax_class_id,omitempty"`
+	Name          string     `db:"name"           json:"name,omitempty"`
+	Rate          float64    `db:"rate"           json:"rate"`
+	IsInclusive   bool       `db:"is_inclusive"   json:"is_inclusive"`
+	TaxableAmount float64    `db:"taxable_amount" json:"taxable_amount"`
+	Amount        float64    `db:"amount"         json:"amount"`
+}
+
+type TaxClass struct {
 	ID         uuid.UUID `db:"id"          json:"id,omitempty"`
 	VendorId   uuid.UUID `db:"vendor_id"   json:"vendor_id,omitempty"`
 	Name       string    `db:"name"        json:"name,omitempty"`
-	Price      float64   `db:"price"       json:"price,omitempty"`
-	Img        *string   `db:"img"         json:"img,omitempty"`
+	Rate       *float64  `db:"rate"        json:"rate"`
+	IsDefault  *bool     `db:"is_default"  json:"is_default"`
 	Created_at time.Time `db:"created_at"  json:"created_at,omitempty"`
 	Updated_at time.Time `db:"updated_at"  json:"updated_at,omitempty"`
 }
 
-type Order struct {
-	ID             uuid.UUID    `db:"id"          json:"id,omitempty"`
-	TotalOrderCost float64      `db:"total_order_cost" json:"total_order_cost,omitempty"`
-	VendorId       uuid.UUID    `db:"vendor_id"   json:"vendor_id,omitempty"`
-	CustomerId     uuid.UUID    `db:"customer_id"   json:"customer_id,omitempty"`
-	Status         string       `db:"status"        json:"status,omitempty"`
-	Created_at     time.Time    `db:"created_at"  json:"created_at,omitempty"`
-	Updated_at     time.Time    `db:"updated_at"  json:"updated_at,omitempty"`
-	OrderItems     []OrderItems `db:"-" json:"order_items,omitempty"`
+type OrderDiscount struct {
+	ID          uuid.UUID  `db:"id"          json:"id,omitempty"`
+	OrderId     uuid.UUID  `db:"order_id"    json:"order_id,omitempty"`
+	CouponId    *uuid.UUID `db:"coupon_id"   json:"coupon_id,omitempty"`
+	Code        string     `db:"code"        json:"code,omitempty"`
+	Description *string    `db:"description" json:"description,omitempty"`
+	Amount      float64    `db:"amount"      json:"amount"`
 }
 
-type OrderItems struct {
-	ID       uuid.UUID `db:"id"          json:"id,omitempty"`
-	OrderId  uuid.UUID `db:"order_id"     json:"order_id,omitempty"`
-	Quantity int       `db:"quantity"    json:"quantity,omitempty"`
-	Price    float64   `db:"price"       json:"price,omitempty"`
-	ItemId   uuid.UUID `db:"item_id"     json:"item_id,omitempty"`
+type Coupon struct {
+	ID                uuid.UUID   `db:"id"                   json:"id,omitempty"`
+	VendorId          *uuid.UUID  `db:"vendor_id"            json:"vendor_id,omitempty"`
+	Code              string      `db:"code"                 json:"code,omitempty"`
+	Description       *string     `db:"description"          json:"description,omitempty"`
+	DiscountType      string      `db:"discount_type"        json:"discount_type,omitempty"`
+	Value             float64     `db:"value"                json:"value,omitempty"`
+	MinSpend          *float64    `db:"min_spend"            json:"min_spend"`
+	MaxDiscount       *float64    `db:"max_discount"         json:"max_discount,omitempty"`
+	StartsAt          *time.Time  `db:"starts_at"            json:"starts_at,omitempty"`
+	EndsAt            *time.Time  `db:"ends_at"              json:"ends_at,omitempty"`
+	UsageLimit        *int        `db:"usage_limit"          json:"usage_limit,omitempty"`
+	UsageLimitPerUser *int        `db:"usage_limit_per_user" json:"usage_limit_per_user,omitempty"`
+	IsActive          *bool       `db:"is_active"            json:"is_active"`
+	ItemIds           []uuid.UUID `db:"-"                    json:"item_ids,omitempty"`
+	CategoryIds       []uuid.UUID `db:"-"                    json:"category_ids,omitempty"`
+	Created_at        time.Time   `db:"created_at"           json:"created_at,omitempty"`
+	Updated_at        time.Time   `db:"updated_at"           json:"updated_at,omitempty"`
 }
 
 type Cart struct {
-	ID         uuid.UUID   `db:"id"          json:"id,omitempty"`
-	TotalPrice float64     `db:"total_price" json:"total_price,omitempty"`
-	Quantity   int         `db:"quantity"    json:"quantity,omitempty"`
-	VendorId   uuid.UUID   `db:"vendor_id"   json:"vendor_id,omitempty"`
+	ID            uuid.UUID  `db:"id"          json:"id,omitempty"`
+	Subtotal      float64    `db:"subtotal"    json:"subtotal"`
+	DiscountTotal float64    `db:"discount_total" json:"discount_total"`
+	TaxTotal      float64    `db:"tax_total"   json:"tax_total"`
+	TotalPrice    float64    `db:"total_price" json:"total_price,omitempty"`
+	CouponId      *uuid.UUID `db:"coupon_id"   json:"coupon_id,omitempty"`
+	Quantity      int        `db:"quantity"    json:"quantity,omitempty"`
+	VendorId      uuid.UUID  `db:"vendor_id"   json:"vendor_id,omitempty"`
+
+	DeliveryAddress   *string  `db:"delivery_address"   json:"delivery_address,omitempty"`
+	DeliveryLatitude  *float64 `db:"delivery_latitude"  json:"delivery_latitude,omitempty"`
+	DeliveryLongitude *float64 `db:"delivery_longitude" json:"delivery_longitude,omitempty"`
+	DeliveryFee       float64  `db:"delivery_fee"       json:"delivery_fee"`
+
+	DeliveryAddressId    *uuid.UUID `db:"delivery_address_id"   json:"delivery_address_id,omitempty"`
+	DeliveryInstructions *string    `db:"delivery_instructions" json:"delivery_instructions,omitempty"`
+
+	FulfillmentType *string    `db:"fulfillment_type" json:"fulfillment_type,omitempty"`
+	TableId         *uuid.UUID `db:"table_id"         json:"table_id,omitempty"`
+	PickupAt        *time.Time `db:"pickup_at"        json:"pickup_at,omitempty"`
+
 	Created_at time.Time   `db:"created_at"  json:"created_at,omitempty"`
 	Updated_at time.Time   `db:"updated_at"  json:"updated_at,omitempty"`
 	CartItem   []CartItems `db:"-" json:"cart_item,omitempty"`
 }
 
 type CartItems struct {
-	CartId   uuid.UUID `db:"cart_id"     json:"cart_id,omitempty"`
-	Quantity int       `db:"quantity"    json:"quantity,omitempty"`
-	ItemId   uuid.UUID `db:"item_id"     json:"item_id,omitempty"`
+	ID          uuid.UUID          `db:"id"           json:"id,omitempty"`
+	CartId      uuid.UUID          `db:"cart_id"      json:"cart_id,omitempty"`
+	Quantity    int                `db:"quantity"     json:"quantity,omitempty"`
+	ItemId      uuid.UUID          `db:"item_id"      json:"item_id,omitempty"`
+	VariantId   uuid.UUID          `db:"variant_id"   json:"variant_id,omitempty"`
+	ModifierKey string             `db:"modifier_key" json:"-"`
+	Modifiers   []CartItemModifier `db:"-"            json:"modifiers,omitempty"`
+}
+
+type CartItemModifier struct {
+	CartItemId       uuid.UUID `db:"cart_item_id"       json:"-"`
+	ModifierOptionId uuid.UUID `db:"modifier_option_id" json:"modifier_option_id"`
+	GroupName        string    `db:"group_name"         json:"group_name"`
+	Name             string    `db:"name"               json:"name"`
+	PriceDelta       float64   `db:"price_delta"        json:"price_delta"`
+}
+
+// AddCartItem is a request to put an item, with its chosen modifiers, in the cart.
+type AddCartItem struct {
+	ItemId            uuid.UUID
+	VariantId         *uuid.UUID
+	Quantity          int
+	ModifierOptionIds []uuid.UUID
 }
 
+// Table is a table on a vendor's floor. NeedsService is derived from its
+// unresolved service requests.
 type Table struct {
 	ID           uuid.UUID `db:"id"          json:"id,omitempty"`
 	Name         string    `db:"name"        json:"name,omitempty"`
@@ -83,6 +601,412 @@ type Table struct {
 	CustomerId   uuid.UUID `db:"customer_id"   json:"customer_id,omitempty"`
 	IsAvailable  bool      `db:"is_available"        json:"is_available,omitempty"`
 	NeedsService bool      `db:"is_needs_service" json:"needs_service,omitempty"`
+	Capacity     int       `db:"capacity"      json:"capacity"`
+	MinCapacity  int       `db:"min_capacity"  json:"min_capacity"`
+	IsReservable bool      `db:"is_reservable" json:"is_reservable"`
+	IsCombinable bool      `db:"is_combinable" json:"is_combinable"`
+
+	QrTokenVersion int `db:"qr_token_version" json:"qr_token_version,omitempty"`
+
+	SectionId *uuid.UUID `db:"section_id" json:"section_id,omitempty"`
+	PosX      float64    `db:"pos_x"      json:"x"`
+	PosY      float64    `db:"pos_y"      json:"y"`
+	Shape     string     `db:"shape"      json:"shape,omitempty"`
+	Rotation  int        `db:"rotation"   json:"rotation"`
+}
+
+// TableSection is an area of a vendor's floor, such as the patio or the bar.
+type TableSection struct {
+	ID         uuid.UUID `db:"id"         json:"id,omitempty"`
+	VendorId   uuid.UUID `db:"vendor_id"  json:"vendor_id,omitempty"`
+	Name       string    `db:"name"       json:"name,omitempty"`
+	SortOrder  int       `db:"sort_order" json:"sort_order"`
+	Created_at time.Time `db:"created_at" json:"created_at,omitempty"`
+	Updated_at time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// FloorPlan is a vendor's whole layout. Tables outside any section are listed
+// under Unassigned.
+type FloorPlan struct {
+	VendorId    uuid.UUID          `json:"vendor_id"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Sections    []FloorPlanSection `json:"sections"`
+	Unassigned  []FloorPlanTable   `json:"unassigned"`
+}
+
+type FloorPlanSection struct {
+	TableSection
+	Tables []FloorPlanTable `json:"tables"`
+}
+
+// FloorPlanTable is a table with its live status: available, reserved (a
+// booking starts soon), occupied or unavailable.
+type FloorPlanTable struct {
+	Table
+	Status         string     `db:"status"           json:"status"`
+	TableSessionId *uuid.UUID `db:"table_session_id" json:"table_session_id,omitempty"`
+	ReservationId  *uuid.UUID `db:"reservation_id"   json:"reservation_id,omitempty"`
+}
+
+// TableLayout moves one table on the floor plan.
+type TableLayout struct {
+	TableId   uuid.UUID  `json:"table_id"`
+	SectionId *uuid.UUID `json:"section_id"`
+	PosX      float64    `json:"x"`
+	PosY      float64    `json:"y"`
+	Shape     string     `json:"shape"`
+	Rotation  int        `json:"rotation"`
+}
+
+type Reservation struct {
+	ID         uuid.UUID   `db:"id"          json:"id,omitempty"`
+	VendorId   uuid.UUID   `db:"vendor_id"   json:"vendor_id,omitempty"`
+	CustomerId *uuid.UUID  `db:"customer_id" json:"customer_id,omitempty"`
+	PartySize  int         `db:"party_size"  json:"party_size,omitempty"`
+	StartsAt   time.Time   `db:"starts_at"   json:"starts_at,omitempty"`
+	EndsAt     time.Time   `db:"ends_at"     json:"ends_at,omitempty"`
+	Status     string      `db:"status"      json:"status,omitempty"`
+	Name       *string     `db:"name"        json:"name,omitempty"`
+	Phone      *string     `db:"phone"       json:"phone,omitempty"`
+	Notes      *string     `db:"notes"       json:"notes,omitempty"`
+	TableIds   []uuid.UUID `db:"-"           json:"table_ids,omitempty"`
+	Created_at time.Time   `db:"created_at"  json:"created_at,omitempty"`
+	Updated_at time.Time   `db:"updated_at"  json:"updated_at,omitempty"`
+}
+
+// AvailabilitySlot is a reservation start time that can seat the party.
+type AvailabilitySlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// TableSession is a dine-in party's stay, from being seated until the bill
+// is closed out. Dine-in orders placed at its tables accumulate on it.
+type TableSession struct {
+	ID            uuid.UUID         `db:"id"             json:"id,omitempty"`
+	VendorId      uuid.UUID         `db:"vendor_id"      json:"vendor_id,omitempty"`
+	PartySize     int               `db:"party_size"     json:"party_size,omitempty"`
+	ServerId      *uuid.UUID        `db:"server_id"      json:"server_id,omitempty"`
+	CustomerId    *uuid.UUID        `db:"customer_id"    json:"customer_id,omitempty"`
+	ReservationId *uuid.UUID        `db:"reservation_id" json:"reservation_id,omitempty"`
+	MergedIntoId  *uuid.UUID        `db:"merged_into_id" json:"merged_into_id,omitempty"`
+	OpenedAt      time.Time         `db:"opened_at"      json:"opened_at,omitempty"`
+	ClosedAt      *time.Time        `db:"closed_at"      json:"closed_at,omitempty"`
+	Created_at    time.Time         `db:"created_at"     json:"created_at,omitempty"`
+	Updated_at    time.Time         `db:"updated_at"     json:"updated_at,omitempty"`
+	TableIds      []uuid.UUID       `db:"-"              json:"table_ids,omitempty"`
+	Orders        []Order           `db:"-"              json:"orders,omitempty"`
+	Bill          *TableSessionBill `db:"-"              json:"bill,omitempty"`
+}
+
+type TableSessionBill struct {
+	OrderCount         int     `db:"order_count"          json:"order_count"`
+	Subtotal           float64 `db:"subtotal"             json:"subtotal"`
+	DiscountTotal      float64 `db:"discount_total"       json:"discount_total"`
+	TaxTotal           float64 `db:"tax_total"            json:"tax_total"`
+	ServiceChargeTotal float64 `db:"service_charge_total" json:"service_charge_total"`
+	TipTotal           float64 `db:"tip_total"            json:"tip_total"`
+	Total              float64 `db:"total"                json:"total"`
+	PaidTotal          float64 `db:"paid_total"           json:"paid_total"`
+	BalanceDue         float64 `db:"-"                    json:"balance_due"`
+}
+
+// ServiceRequest is a call for staff from a table. Response times are in
+// seconds from when the request was raised.
+type ServiceRequest struct {
+	ID                 uuid.UUID  `db:"id"                  json:"id,omitempty"`
+	VendorId           uuid.UUID  `db:"vendor_id"           json:"vendor_id,omitempty"`
+	TableId            uuid.UUID  `db:"table_id"            json:"table_id,omitempty"`
+	TableSessionId     *uuid.UUID `db:"table_session_id"    json:"table_session_id,omitempty"`
+	CustomerId         *uuid.UUID `db:"customer_id"         json:"customer_id,omitempty"`
+	Type               string     `db:"type"                json:"type,omitempty"`
+	Status             string     `db:"status"              json:"status,omitempty"`
+	Note               *string    `db:"note"                json:"note,omitempty"`
+	AcknowledgedAt     *time.Time `db:"acknowledged_at"     json:"acknowledged_at,omitempty"`
+	AcknowledgedBy     *uuid.UUID `db:"acknowledged_by"     json:"acknowledged_by,omitempty"`
+	ResolvedAt         *time.Time `db:"resolved_at"         json:"resolved_at,omitempty"`
+	ResolvedBy         *uuid.UUID `db:"resolved_by"         json:"resolved_by,omitempty"`
+	AcknowledgeSeconds *int       `db:"acknowledge_seconds" json:"acknowledge_seconds,omitempty"`
+	ResolveSeconds     *int       `db:"resolve_seconds"     json:"resolve_seconds,omitempty"`
+	Created_at         time.Time  `db:"created_at"          json:"created_at,omitempty"`
+	Updated_at         time.Time  `db:"updated_at"          json:"updated_at,omitempty"`
+}
+
+// SeatParty opens a table session. Without a table the party is seated at
+// the tables held by its reservation.
+type SeatParty struct {
+	TableId       *uuid.UUID `json:"table_id,omitempty"`
+	PartySize     int        `json:"party_size"`
+	ServerId      *uuid.UUID `json:"server_id,omitempty"`
+	CustomerId    *uuid.UUID `json:"customer_id,omitempty"`
+	ReservationId *uuid.UUID `json:"reservation_id,omitempty"`
+}
+
+// WaitlistEntry is a walk-in party waiting for a table. Position counts
+// from 1 while the party is still waiting or has been told its table is
+// ready.
+type WaitlistEntry struct {
+	ID                uuid.UUID  `db:"id"                  json:"id,omitempty"`
+	VendorId          uuid.UUID  `db:"vendor_id"           json:"vendor_id,omitempty"`
+	CustomerId        *uuid.UUID `db:"customer_id"         json:"customer_id,omitempty"`
+	PartyName         string     `db:"party_name"          json:"party_name,omitempty"`
+	PartySize         int        `db:"party_size"          json:"party_size,omitempty"`
+	Phone             *string    `db:"phone"               json:"phone,omitempty"`
+	Notes             *string    `db:"notes"               json:"notes,omitempty"`
+	QuotedWaitMinutes int        `db:"quoted_wait_minutes" json:"quoted_wait_minutes"`
+	QuotedReadyAt     time.Time  `db:"quoted_ready_at"     json:"quoted_ready_at,omitempty"`
+	Status            string     `db:"status"              json:"status,omitempty"`
+	Position          int        `db:"position"            json:"position,omitempty"`
+	Token             string     `db:"token"               json:"token,omitempty"`
+	NotifiedAt        *time.Time `db:"notified_at"         json:"notified_at,omitempty"`
+	SeatedAt          *time.Time `db:"seated_at"           json:"seated_at,omitempty"`
+	TableSessionId    *uuid.UUID `db:"table_session_id"    json:"table_session_id,omitempty"`
+	Created_at        time.Time  `db:"created_at"          json:"created_at,omitempty"`
+	Updated_at        time.Time  `db:"updated_at"          json:"updated_at,omitempty"`
+}
+
+// WaitlistQuote is how long a party of PartySize walking in now would wait.
+type WaitlistQuote struct {
+	VendorId          uuid.UUID `json:"vendor_id"`
+	PartySize         int       `json:"party_size"`
+	PartiesAhead      int       `json:"parties_ahead"`
+	QuotedWaitMinutes int       `json:"quoted_wait_minutes"`
+}
+
+// KitchenStation is a station on the kitchen display, such as the grill or
+// the bar. Lines no item or category is routed for go to the default station.
+type KitchenStation struct {
+	ID          uuid.UUID   `db:"id"         json:"id,omitempty"`
+	VendorId    uuid.UUID   `db:"vendor_id"  json:"vendor_id,omitempty"`
+	Name        string      `db:"name"       json:"name,omitempty"`
+	IsDefault   bool        `db:"is_default" json:"is_default"`
+	SortOrder   int         `db:"sort_order" json:"sort_order"`
+	ItemIds     []uuid.UUID `db:"-"          json:"item_ids"`
+	CategoryIds []uuid.UUID `db:"-"          json:"category_ids"`
+	Created_at  time.Time   `db:"created_at" json:"created_at,omitempty"`
+	Updated_at  time.Time   `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// KitchenStationRoutes lists the items and categories a station makes.
+type KitchenStationRoutes struct {
+	ItemIds     []uuid.UUID `json:"item_ids"`
+	CategoryIds []uuid.UUID `json:"category_ids"`
+}
+
+// KitchenTicket is the part of an order one station has to make. It is
+// bumped once every line on it is.
+type KitchenTicket struct {
+	ID              uuid.UUID           `db:"id"               json:"id,omitempty"`
+	OrderId         uuid.UUID           `db:"order_id"         json:"order_id,omitempty"`
+	VendorId        uuid.UUID           `db:"vendor_id"        json:"vendor_id,omitempty"`
+	StationId       uuid.UUID           `db:"station_id"       json:"station_id,omitempty"`
+	Status          string              `db:"status"           json:"status,omitempty"`
+	Priority        int                 `db:"priority"         json:"priority"`
+	AgeSeconds      int                 `db:"age_seconds"      json:"age_seconds"`
+	OrderStatus     string              `db:"order_status"     json:"order_status,omitempty"`
+	FulfillmentType string              `db:"fulfillment_type" json:"fulfillment_type,omitempty"`
+	TableId         *uuid.UUID          `db:"table_id"         json:"table_id,omitempty"`
+	TableName       *string             `db:"table_name"       json:"table_name,omitempty"`
+	PickupAt        *time.Time          `db:"pickup_at"        json:"pickup_at,omitempty"`
+	Lines           []KitchenTicketLine `db:"-"                json:"lines"`
+	BumpedAt        *time.Time          `db:"bumped_at"        json:"bumped_at,omitempty"`
+	Created_at      time.Time           `db:"created_at"       json:"created_at,omitempty"`
+	Updated_at      time.Time           `db:"updated_at"       json:"updated_at,omitempty"`
+}
+
+type KitchenTicketLine struct {
+	ID          uuid.UUID           `db:"id"            json:"id,omitempty"`
+	TicketId    uuid.UUID           `db:"ticket_id"     json:"ticket_id,omitempty"`
+	OrderItemId uuid.UUID           `db:"order_item_id" json:"order_item_id,omitempty"`
+	ItemName    string              `db:"item_name"     json:"item_name,omitempty"`
+	VariantName *string             `db:"variant_name"  json:"variant_name,omitempty"`
+	Quantity    int                 `db:"quantity"      json:"quantity"`
+	Status      string              `db:"status"        json:"status,omitempty"`
+	BumpedAt    *time.Time          `db:"bumped_at"     json:"bumped_at,omitempty"`
+	Modifiers   []OrderItemModifier `db:"-"             json:"modifiers,omitempty"`
+}
+
+// ReceiptTemplate replaces one of the built-in receipt layouts for a
+// vendor. Kind is receipt or kitchen, Format is text or html; ESC/POS and
+// PDF output are printed from the text layout.
+type ReceiptTemplate struct {
+	ID         uuid.UUID `db:"id"         json:"id,omitempty"`
+	VendorId   uuid.UUID `db:"vendor_id"  json:"vendor_id,omitempty"`
+	Kind       string    `db:"kind"       json:"kind,omitempty"`
+	Format     string    `db:"format"     json:"format,omitempty"`
+	Body       string    `db:"body"       json:"body"`
+	IsCustom   bool      `db:"-"          json:"is_custom"`
+	Created_at time.Time `db:"created_at" json:"created_at,omitempty"`
+	Updated_at time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// Receipt is what receipt and kitchen ticket templates are rendered from.
+// Times are in the vendor's timezone. Width is how many characters fit
+// across the PaperMM wide paper.
+type Receipt struct {
+	Vendor               ReceiptVendor   `json:"vendor"`
+	OrderId              uuid.UUID       `json:"order_id"`
+	Number               string          `json:"number"`
+	Status               string          `json:"status"`
+	PaymentStatus        string          `json:"payment_status"`
+	FulfillmentType      string          `json:"fulfillment_type"`
+	TableName            string          `json:"table_name,omitempty"`
+	PartySize            int             `json:"party_size,omitempty"`
+	PickupAt             *time.Time      `json:"pickup_at,omitempty"`
+	DeliveryAddress      string          `json:"delivery_address,omitempty"`
+	DeliveryInstructions string          `json:"delivery_instructions,omitempty"`
+	PlacedAt             time.Time       `json:"placed_at"`
+	PrintedAt            time.Time       `json:"printed_at"`
+	Lines                []ReceiptLine   `json:"lines"`
+	Subtotal             float64         `json:"subtotal"`
+	Discounts            []ReceiptAmount `json:"discounts"`
+	DiscountTotal        float64         `json:"discount_total"`
+	Taxes                []ReceiptTax    `json:"taxes"`
+	TaxTotal             float64         `json:"tax_total"`
+	Adjustments          []ReceiptAmount `json:"adjustments"`
+	Total                float64         `json:"total"`
+	RefundedTotal        float64         `json:"refunded_total"`
+	NetTotal             float64         `json:"net_total"`
+	PricesIncludeTax     bool            `json:"prices_include_tax"`
+	PaperMM              int             `json:"paper_mm"`
+	Width                int             `json:"width"`
+}
+
+type ReceiptVendor struct {
+	Name         string   `json:"name"`
+	AddressLines []string `json:"address_lines"`
+}
+
+type ReceiptLine struct {
+	Name        string            `json:"name"`
+	VariantName string            `json:"variant_name,omitempty"`
+	Quantity    int               `json:"quantity"`
+	UnitPrice   float64           `json:"unit_price"`
+	Amount      float64           `json:"amount"`
+	Modifiers   []ReceiptModifier `json:"modifiers"`
+}
+
+type ReceiptModifier struct {
+	GroupName  string  `json:"group_name"`
+	Name       string  `json:"name"`
+	PriceDelta float64 `json:"price_delta"`
+}
+
+type ReceiptTax struct {
+	Name        string  `db:"name"         json:"name"`
+	Rate        float64 `db:"rate"         json:"rate"`
+	IsInclusive bool    `db:"is_inclusive" json:"is_inclusive"`
+	Amount      float64 `db:"amount"       json:"amount"`
+}
+
+type ReceiptAmount struct {
+	Label  string  `db:"label"  json:"label"`
+	Amount float64 `db:"amount" json:"amount"`
+}
+
+// WebhookEndpoint is where a vendor's integration partners receive events.
+// Without EventTypes it receives every event. Secret signs the deliveries
+// and is only shown when the endpoint is created or its secret rotated.
+type WebhookEndpoint struct {
+	ID          uuid.UUID `db:"id"          json:"id,omitempty"`
+	VendorId    uuid.UUID `db:"vendor_id"   json:"vendor_id,omitempty"`
+	Url         string    `db:"url"         json:"url,omitempty"`
+	Secret      string    `db:"secret"      json:"secret,omitempty"`
+	Description *string   `db:"description" json:"description,omitempty"`
+	IsActive    *bool     `db:"is_active"   json:"is_active,omitempty"`
+	EventTypes  []string  `db:"-"           json:"event_types"`
+	Created_at  time.Time `db:"created_at"  json:"created_at,omitempty"`
+	Updated_at  time.Time `db:"updated_at"  json:"updated_at,omitempty"`
+}
+
+// WebhookDelivery is one event on its way to one endpoint. Dead deliveries
+// gave up after too many failed attempts and wait to be retried by hand.
+type WebhookDelivery struct {
+	ID                 uuid.UUID                `db:"id"                   json:"id,omitempty"`
+	EventId            uuid.UUID                `db:"event_id"             json:"event_id,omitempty"`
+	EventType          string                   `db:"event_type"           json:"event_type,omitempty"`
+	EndpointId         uuid.UUID                `db:"endpoint_id"          json:"endpoint_id,omitempty"`
+	Status             string                   `db:"status"               json:"status,omitempty"`
+	Attempts           int                      `db:"attempts"             json:"attempts"`
+	NextAttemptAt      *time.Time               `db:"next_attempt_at"      json:"next_attempt_at,omitempty"`
+	LastAttemptAt      *time.Time               `db:"last_attempt_at"      json:"last_attempt_at,omitempty"`
+	LastResponseStatus *int                     `db:"last_response_status" json:"last_response_status,omitempty"`
+	LastError          *string                  `db:"last_error"           json:"last_error,omitempty"`
+	Payload            json.RawMessage          `db:"-"                    json:"payload,omitempty"`
+	AttemptLog         []WebhookDeliveryAttempt `db:"-"                    json:"attempt_log,omitempty"`
+	Created_at         time.Time                `db:"created_at"           json:"created_at,omitempty"`
+	Updated_at         time.Time                `db:"updated_at"           json:"updated_at,omitempty"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             uuid.UUID `db:"id"              json:"id,omitempty"`
+	DeliveryId     uuid.UUID `db:"delivery_id"     json:"delivery_id,omitempty"`
+	Attempt        int       `db:"attempt"         json:"attempt"`
+	ResponseStatus *int      `db:"response_status" json:"response_status,omitempty"`
+	ResponseBody   *string   `db:"response_body"   json:"response_body,omitempty"`
+	Error          *string   `db:"error"           json:"error,omitempty"`
+	DurationMs     int       `db:"duration_ms"     json:"duration_ms"`
+	Created_at     time.Time `db:"created_at"      json:"created_at,omitempty"`
+}
+
+// WebhookJob is a delivery claimed by the dispatcher, with everything needed
+// to send it. Attempt is the number of the attempt about to be made.
+type WebhookJob struct {
+	DeliveryId     uuid.UUID       `db:"delivery_id"      json:"delivery_id"`
+	EventId        uuid.UUID       `db:"event_id"         json:"event_id"`
+	EventType      string          `db:"event_type"       json:"event_type"`
+	VendorId       uuid.UUID       `db:"vendor_id"        json:"vendor_id"`
+	EventCreatedAt time.Time       `db:"event_created_at" json:"event_created_at"`
+	Attempt        int             `db:"attempt"          json:"attempt"`
+	Url            string          `db:"url"              json:"url"`
+	Secret         string          `db:"secret"           json:"-"`
+	Payload        json.RawMessage `db:"-"                json:"payload"`
+}
+
+// RealtimeEvent is pushed to clients subscribed to any of its topics, e.g.
+// vendor:{id}:orders, order:{id} or vendor:{id}:tables.
+type RealtimeEvent struct {
+	Type   string          `json:"type"`
+	Topics []string        `json:"topics"`
+	Data   json.RawMessage `json:"data"`
+	At     time.Time       `json:"at"`
+}
+
+// OrderEvent is the slice of an order carried by real-time events. Clients
+// fetch the order for anything else.
+type OrderEvent struct {
+	ID              uuid.UUID  `db:"id"               json:"id"`
+	VendorId        uuid.UUID  `db:"vendor_id"        json:"vendor_id"`
+	CustomerId      uuid.UUID  `db:"customer_id"      json:"customer_id"`
+	TableId         *uuid.UUID `db:"table_id"         json:"table_id,omitempty"`
+	TableSessionId  *uuid.UUID `db:"table_session_id" json:"table_session_id,omitempty"`
+	Status          string     `db:"status"           json:"status"`
+	PaymentStatus   string     `db:"payment_status"   json:"payment_status"`
+	FulfillmentType string     `db:"fulfillment_type" json:"fulfillment_type"`
+	Total           float64    `db:"total_order_cost" json:"total"`
+	Updated_at      time.Time  `db:"updated_at"       json:"updated_at"`
+}
+
+// KitchenTicketEvent is what goes out in real time when a kitchen ticket
+// changes. Screens fetch the full ticket with its lines from the API, a whole
+// ticket can be too big for a Postgres notification.
+type KitchenTicketEvent struct {
+	ID         uuid.UUID `db:"id"         json:"id"`
+	OrderId    uuid.UUID `db:"order_id"   json:"order_id"`
+	VendorId   uuid.UUID `db:"vendor_id"  json:"vendor_id"`
+	StationId  uuid.UUID `db:"station_id" json:"station_id"`
+	Status     string    `db:"status"     json:"status"`
+	Priority   int       `db:"priority"   json:"priority"`
+	Updated_at time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GuestSession is what scanning a table's QR code hands out: a token for a
+// guest user who can order dine-in at that table.
+type GuestSession struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+	User      User   `json:"user"`
+	Table     Table  `json:"table"`
 }
 
 type Meta struct {
@@ -0,0 +1,274 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"restaurant-management-backend/internal/helpers"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderAttempt   = "X-Webhook-Attempt"
+)
+
+const (
+	defaultBatchSize    = 50
+	defaultPollInterval = 5 * time.Second
+	defaultTimeout      = 10 * time.Second
+	// Waiting 30s, 1m, 2m ... up to 6h between attempts gives an endpoint
+	// about a day to recover before its deliveries are dead-lettered.
+	defaultMaxAttempts = 14
+	defaultBaseBackoff = 30 * time.Second
+	defaultMaxBackoff  = 6 * time.Hour
+	// maxResponseBody is how much of an endpoint's response is kept in the
+	// delivery log.
+	maxResponseBody = 1024
+)
+
+// Store is where the dispatcher finds its work and records how it went.
+type Store interface {
+	FanOutOutboxEvents(limit int) (int, error)
+	ClaimWebhookDeliveries(limit int, lease time.Duration) ([]types.WebhookJob, error)
+	RecordWebhookAttempt(attempt types.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error
+}
+
+// Event is the body POSTed to an endpoint.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	VendorId  string          `json:"vendor_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Dispatcher delivers outbox events to vendors' webhook endpoints. A delivery
+// succeeds on any 2xx response. Anything else is retried with Backoff until
+// MaxAttempts is reached, or at once for 410 Gone, after which the delivery
+// is dead until it is retried by hand. Several dispatchers, in one process
+// or many, can share a store.
+type Dispatcher struct {
+	store Store
+
+	Client       *http.Client
+	BatchSize    int
+	PollInterval time.Duration
+	MaxAttempts  int
+	// Backoff is how long to wait after the given failed attempt, counting
+	// from 1.
+	Backoff func(attempt int) time.Duration
+}
+
+func NewDispatcher(store Store) *Dispatcher {
+	// Endpoints are vendor input, so only public addresses are dialled, and
+	// straight rather than through any proxy
+	dialer := &net.Dialer{Timeout: defaultTimeout, Control: publicAddressOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		store: store,
+		Client: &http.Client{
+			Transport: transport,
+			Timeout:   defaultTimeout,
+			// A redirect is the endpoint's problem to fix, not ours to follow
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		BatchSize:    defaultBatchSize,
+		PollInterval: defaultPollInterval,
+		MaxAttempts:  defaultMaxAttempts,
+		Backoff:      ExponentialBackoff(defaultBaseBackoff, defaultMaxBackoff),
+	}
+}
+
+// publicAddressOnly refuses connections to addresses that are not on the
+// public internet. It runs after name resolution, so a host cannot pass
+// validation and resolve to an internal address later.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !helpers.CheckPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// ExponentialBackoff waits base after the first failed attempt and twice as
+// long after each one after that, up to limit.
+func ExponentialBackoff(base, limit time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < limit; i++ {
+			delay *= 2
+		}
+		return min(delay, limit)
+	}
+}
+
+// Run dispatches until ctx is done, going straight on to the next batch
+// while there is more work than fits in one.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		busy, err := d.DispatchOnce(ctx)
+		if err != nil {
+			logger.Log.WithError(err).Error("Webhook dispatch failed")
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if busy && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.PollInterval):
+		}
+	}
+}
+
+// DispatchOnce fans new outbox events out to their endpoints and sends one
+// batch of due deliveries. It reports whether either batch was full, i.e.
+// whether there may be more to do right away.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (bool, error) {
+	fanned, err := d.store.FanOutOutboxEvents(d.BatchSize)
+	if err != nil {
+		return false, err
+	}
+
+	// Held long enough for every request in the batch to time out
+	lease := 2 * d.timeout()
+	jobs, err := d.store.ClaimWebhookDeliveries(d.BatchSize, lease)
+	if err != nil {
+		return false, err
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job types.WebhookJob) {
+			defer wg.Done()
+			d.deliver(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+
+	return fanned >= d.BatchSize || len(jobs) >= d.BatchSize, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job types.WebhookJob) {
+	entry := logger.Log.WithField("delivery_id", job.DeliveryId).WithField("event_type", job.EventType)
+
+	body, err := json.Marshal(Event{
+		ID:        job.EventId.String(),
+		Type:      job.EventType,
+		VendorId:  job.VendorId.String(),
+		CreatedAt: job.EventCreatedAt,
+		Data:      job.Payload,
+	})
+	if err != nil {
+		entry.WithError(err).Error("Failed to encode webhook event")
+		return
+	}
+
+	attempt := types.WebhookDeliveryAttempt{DeliveryId: job.DeliveryId, Attempt: job.Attempt}
+	started := time.Now()
+	status, response, err := d.post(ctx, job, body)
+	attempt.DurationMs = int(time.Since(started).Milliseconds())
+	if ctx.Err() != nil {
+		// Shutting down. The lease runs out and the delivery is sent again.
+		return
+	}
+
+	switch {
+	case err != nil:
+		message := err.Error()
+		attempt.Error = &message
+	default:
+		attempt.ResponseStatus = &status
+		if response != "" {
+			attempt.ResponseBody = &response
+		}
+		if status < 200 || status > 299 {
+			message := fmt.Sprintf("endpoint responded with status %d", status)
+			attempt.Error = &message
+		}
+	}
+
+	outcome, next := d.outcome(attempt)
+	if outcome != "succeeded" {
+		entry.WithField("attempt", job.Attempt).WithField("outcome", outcome).Warn(*attempt.Error)
+	}
+	if err := d.store.RecordWebhookAttempt(attempt, outcome, next); err != nil {
+		entry.WithError(err).Error("Failed to record webhook attempt")
+	}
+}
+
+// post sends a signed event and returns the response status and the start of
+// the response body.
+func (d *Dispatcher) post(ctx context.Context, job types.WebhookJob, body []byte) (int, string, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, job.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "restaurant-management-webhooks/1")
+	request.Header.Set(HeaderID, job.EventId.String())
+	request.Header.Set(HeaderEvent, job.EventType)
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, body))
+	request.Header.Set(HeaderAttempt, strconv.Itoa(job.Attempt))
+
+	response, err := d.Client.Do(request)
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+	// Postgres text holds neither NUL bytes nor invalid UTF-8
+	text := strings.ToValidUTF8(strings.ReplaceAll(string(excerpt), "\x00", ""), "�")
+	return response.StatusCode, text, nil
+}
+
+// outcome decides what becomes of a delivery after an attempt.
+func (d *Dispatcher) outcome(attempt types.WebhookDeliveryAttempt) (string, time.Time) {
+	now := time.Now()
+	if attempt.Error == nil {
+		return "succeeded", now
+	}
+	if attempt.Attempt >= d.MaxAttempts ||
+		(attempt.ResponseStatus != nil && *attempt.ResponseStatus == http.StatusGone) {
+		return "dead", now
+	}
+	return "pending", now.Add(d.Backoff(attempt.Attempt))
+}
+
+func (d *Dispatcher) timeout() time.Duration {
+	if d.Client.Timeout > 0 {
+		return d.Client.Timeout
+	}
+	return defaultTimeout
+}
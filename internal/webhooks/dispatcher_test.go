@@ -0,0 +1,240 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"restaurant-management-backend/internal/logger"
+	"restaurant-management-backend/internal/types"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	logger.InitLogger()
+	logger.Log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// fakeStore holds a single delivery, handed out again for as long as it is
+// pending. Backoff is checked through the recorded next attempt times rather
+// than by waiting them out.
+type fakeStore struct {
+	mu       sync.Mutex
+	job      types.WebhookJob
+	status   string
+	attempts []types.WebhookDeliveryAttempt
+	next     []time.Time
+}
+
+func newFakeStore(url string) *fakeStore {
+	return &fakeStore{
+		status: "pending",
+		job: types.WebhookJob{
+			DeliveryId:     uuid.New(),
+			EventId:        uuid.New(),
+			EventType:      "order.created",
+			VendorId:       uuid.New(),
+			EventCreatedAt: time.Now(),
+			Attempt:        1,
+			Url:            url,
+			Secret:         "whsec_test",
+			Payload:        json.RawMessage(`{"order_id":"42"}`),
+		},
+	}
+}
+
+func (f *fakeStore) FanOutOutboxEvents(int) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeStore) ClaimWebhookDeliveries(int, time.Duration) ([]types.WebhookJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.status != "pending" {
+		return nil, nil
+	}
+	return []types.WebhookJob{f.job}, nil
+}
+
+func (f *fakeStore) RecordWebhookAttempt(attempt types.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, attempt)
+	f.next = append(f.next, nextAttemptAt)
+	f.status = status
+	f.job.Attempt++
+	return nil
+}
+
+// endpoint answers every delivery with the given status and counts them.
+type endpoint struct {
+	t      *testing.T
+	status int
+	mu     sync.Mutex
+	hits   int
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	e.hits++
+	e.mu.Unlock()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		e.t.Errorf("reading body: %v", err)
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		e.t.Errorf("bad %s header %q", HeaderTimestamp, r.Header.Get(HeaderTimestamp))
+	}
+	if !Verify("whsec_test", r.Header.Get(HeaderSignature), timestamp, body) {
+		e.t.Errorf("signature %q does not verify", r.Header.Get(HeaderSignature))
+	}
+	if Verify("another secret", r.Header.Get(HeaderSignature), timestamp, body) {
+		e.t.Error("signature verifies with the wrong secret")
+	}
+
+	w.WriteHeader(e.status)
+}
+
+func (e *endpoint) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.hits
+}
+
+func newTestDispatcher(t *testing.T, status int) (*Dispatcher, *fakeStore, *endpoint) {
+	t.Helper()
+	handler := &endpoint{t: t, status: status}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	store := newFakeStore(server.URL)
+	dispatcher := NewDispatcher(store)
+	// The test server listens on loopback, which the default client refuses
+	dispatcher.Client = server.Client()
+	dispatcher.MaxAttempts = 3
+	dispatcher.Backoff = ExponentialBackoff(time.Minute, time.Hour)
+	return dispatcher, store, handler
+}
+
+func dispatch(t *testing.T, dispatcher *Dispatcher) {
+	t.Helper()
+	if _, err := dispatcher.DispatchOnce(context.Background()); err != nil {
+		t.Fatalf("DispatchOnce: %v", err)
+	}
+}
+
+func TestDispatcherSucceedsOnce(t *testing.T) {
+	dispatcher, store, handler := newTestDispatcher(t, http.StatusNoContent)
+
+	dispatch(t, dispatcher)
+	dispatch(t, dispatcher)
+
+	if handler.count() != 1 {
+		t.Fatalf("endpoint called %d times, want 1", handler.count())
+	}
+	if store.status != "succeeded" {
+		t.Fatalf("status = %q, want succeeded", store.status)
+	}
+	attempt := store.attempts[0]
+	if attempt.Error != nil {
+		t.Errorf("attempt error = %q, want none", *attempt.Error)
+	}
+	if attempt.ResponseStatus == nil || *attempt.ResponseStatus != http.StatusNoContent {
+		t.Errorf("response status = %v, want 204", attempt.ResponseStatus)
+	}
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	dispatcher, store, handler := newTestDispatcher(t, http.StatusInternalServerError)
+
+	for i := 0; i < 5; i++ {
+		dispatch(t, dispatcher)
+	}
+
+	if handler.count() != 3 {
+		t.Fatalf("endpoint called %d times, want MaxAttempts (3)", handler.count())
+	}
+	if store.status != "dead" {
+		t.Fatalf("status = %q, want dead", store.status)
+	}
+
+	// Each retry waits twice as long as the one before
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute} {
+		wait := store.next[i].Sub(time.Now())
+		if wait < want-5*time.Second || wait > want {
+			t.Errorf("after attempt %d the delivery waits %v, want about %v", i+1, wait.Round(time.Second), want)
+		}
+		if store.attempts[i].Error == nil {
+			t.Errorf("attempt %d recorded no error", i+1)
+		}
+	}
+}
+
+func TestDispatcherDeadLettersGone(t *testing.T) {
+	dispatcher, store, handler := newTestDispatcher(t, http.StatusGone)
+
+	dispatch(t, dispatcher)
+	dispatch(t, dispatcher)
+
+	if handler.count() != 1 {
+		t.Fatalf("endpoint called %d times, want 1", handler.count())
+	}
+	if store.status != "dead" {
+		t.Fatalf("status = %q, want dead", store.status)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(30*time.Second, 6*time.Hour)
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		10: 256 * time.Minute,
+		11: 6 * time.Hour,
+		40: 6 * time.Hour,
+	}
+	for attempt, want := range cases {
+		if got := backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+func TestPublicAddressOnly(t *testing.T) {
+	refused := []string{"127.0.0.1:80", "[::1]:443", "169.254.169.254:80", "10.1.2.3:443", "172.16.0.1:80",
+		"192.168.1.1:8080", "100.64.0.1:80", "0.0.0.0:80"}
+	for _, address := range refused {
+		if err := publicAddressOnly("tcp", address, nil); err == nil {
+			t.Errorf("connecting to %s was allowed", address)
+		}
+	}
+	if err := publicAddressOnly("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("connecting to a public address was refused: %v", err)
+	}
+}
+
+func TestDefaultClientRefusesLoopback(t *testing.T) {
+	handler := &endpoint{t: t, status: http.StatusOK}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	store := newFakeStore(server.URL)
+	dispatch(t, NewDispatcher(store))
+
+	if handler.count() != 0 {
+		t.Fatal("the dispatcher delivered to a loopback address")
+	}
+	if store.status != "pending" || store.attempts[0].Error == nil {
+		t.Fatalf("status = %q, want a failed attempt left pending", store.status)
+	}
+}